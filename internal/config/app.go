@@ -26,6 +26,12 @@ type AppConfig struct {
 	OpenWeather                string
 	OpenWeatherApiKey          string
 	NotificationHeaderName     string
+	PaymentProvider            string
+	PaymentWebhookSecret       string
+	PaymentCurrency            string
+	PaymentFakeCheckout        bool
+	ExchangeRateSource         string
+	EventStreamBroker          string
 	AllowedPhotoLinks          []string
 }
 
@@ -34,8 +40,14 @@ type AppLimit struct {
 	PocketListItems                int64
 	EventPolaroidLimit             int64
 	EventAccountingLimit           int64
+	EventAccountingReceiptLimit    int64
 	EventAnnouncementLimit         int64
 	EventMessageBoardLimit         int64
+	EventSeriesOccurrenceLimit     int64
+	EventCancelGracePeriodHours    int64
+	PaymentDeadlineHours           int64
+	AnnouncementSchedulerSeconds   int64
+	EventRouteFileSize             int64
 	LengthPocketListName           int64
 	LengthRewildingName            int64
 	LengthRewildingImage           int64
@@ -45,9 +57,12 @@ type AppLimit struct {
 	LengthEventAccountingMessage   int64
 	LengthEventInvitationMessage   int64
 	LengthEventPolaroidMessage     int64
+	LengthEventPolaroidComment     int64
 	LengthEventParticipantMessage  int64
 	MinimumTopRanking              int64
 	PolaroidAchievementRadius      float64
+	PolaroidExifToleranceHours     int64
+	PolaroidDuplicateDistance      int64
 }
 
 var APP AppConfig
@@ -76,13 +91,25 @@ func InitialiseConfig() {
 	APP.OpenWeather = os.Getenv("OPENWEATHER_API_BASE_URL")
 	APP.OpenWeatherApiKey = os.Getenv("OPENWEATHER_API_KEY")
 	APP.NotificationHeaderName = os.Getenv("NOTIFICATION_HEADER_NAME")
+	APP.PaymentProvider = os.Getenv("PAYMENT_PROVIDER")
+	APP.PaymentWebhookSecret = os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	APP.PaymentCurrency = os.Getenv("PAYMENT_CURRENCY")
+	APP.PaymentFakeCheckout = os.Getenv("PAYMENT_FAKE_CHECKOUT") == "true"
+	APP.ExchangeRateSource = os.Getenv("EXCHANGE_RATE_SOURCE")
+	APP.EventStreamBroker = os.Getenv("EVENT_STREAM_BROKER")
 	photoLinks := os.Getenv("ALLOWED_PHOTO_LINKS")
 	APP.AllowedPhotoLinks = strings.Split(photoLinks, ",")
 
 	APP_LIMIT.EventPolaroidLimit = 0
 	APP_LIMIT.EventAccountingLimit = 0
+	APP_LIMIT.EventAccountingReceiptLimit = 0
 	APP_LIMIT.EventAnnouncementLimit = 0
 	APP_LIMIT.EventMessageBoardLimit = 0
+	APP_LIMIT.EventSeriesOccurrenceLimit = 0
+	APP_LIMIT.EventCancelGracePeriodHours = 0
+	APP_LIMIT.PaymentDeadlineHours = 0
+	APP_LIMIT.AnnouncementSchedulerSeconds = 0
+	APP_LIMIT.EventRouteFileSize = 0
 	APP_LIMIT.PocketList = 0
 	APP_LIMIT.PocketListItems = 0
 	APP_LIMIT.LengthPocketListName = 0
@@ -94,14 +121,23 @@ func InitialiseConfig() {
 	APP_LIMIT.LengthEventAccountingMessage = 0
 	APP_LIMIT.LengthEventInvitationMessage = 0
 	APP_LIMIT.LengthEventPolaroidMessage = 0
+	APP_LIMIT.LengthEventPolaroidComment = 0
 	APP_LIMIT.LengthEventParticipantMessage = 0
 	APP_LIMIT.MinimumTopRanking = 0
 	APP_LIMIT.PolaroidAchievementRadius = 0
+	APP_LIMIT.PolaroidExifToleranceHours = 0
+	APP_LIMIT.PolaroidDuplicateDistance = 0
 
 	polaroidLimit, err := strconv.ParseInt(os.Getenv("EVENT_POLAROID_LIMIT"), 10, 64)
 	eventAccountingLimit, eventAccountingLimitErr := strconv.ParseInt(os.Getenv("EVENT_ACCOUNTING_LIMIT"), 10, 64)
+	eventAccountingReceiptLimit, eventAccountingReceiptLimitErr := strconv.ParseInt(os.Getenv("EVENT_ACCOUNTING_RECEIPT_LIMIT"), 10, 64)
 	eventAnnouncementLimit, eventAnnouncementLimitErr := strconv.ParseInt(os.Getenv("EVENT_ANNOUNCEMENT_LIMIT"), 10, 64)
 	eventMessageBoardLimit, eventMessageBoardLimitErr := strconv.ParseInt(os.Getenv("EVENT_MESSAGE_BOARD_LIMIT"), 10, 64)
+	eventSeriesOccurrenceLimit, eventSeriesOccurrenceLimitErr := strconv.ParseInt(os.Getenv("EVENT_SERIES_OCCURRENCE_LIMIT"), 10, 64)
+	eventCancelGracePeriodHours, eventCancelGracePeriodHoursErr := strconv.ParseInt(os.Getenv("EVENT_CANCEL_GRACE_PERIOD_HOURS"), 10, 64)
+	paymentDeadlineHours, paymentDeadlineHoursErr := strconv.ParseInt(os.Getenv("PAYMENT_DEADLINE_HOURS"), 10, 64)
+	eventAnnouncementSchedulerSeconds, eventAnnouncementSchedulerSecondsErr := strconv.ParseInt(os.Getenv("EVENT_ANNOUNCEMENT_SCHEDULER_SECONDS"), 10, 64)
+	eventRouteFileSize, eventRouteFileSizeErr := strconv.ParseInt(os.Getenv("EVENT_ROUTE_FILE_SIZE"), 10, 64)
 	pocketListLimit, pocketlistLimitErr := strconv.ParseInt(os.Getenv("POCKET_LIST_LIMIT"), 10, 64)
 	pocketListitemsLimit, pocketlistitemsLimitErr := strconv.ParseInt(os.Getenv("POCKET_LIST_ITEMS_LIMIT"), 10, 64)
 	lengthPocketListName, lengthPocketListNameErr := strconv.ParseInt(os.Getenv("LENGTH_POCKET_LIST_NAME"), 10, 64)
@@ -113,9 +149,12 @@ func InitialiseConfig() {
 	lengthEventAccountingMessage, lengthEventAccountingMessageErr := strconv.ParseInt(os.Getenv("LENGTH_EVENT_ACCOUNTING_MESSAGE"), 10, 64)
 	lengthEventInvitationMessage, lengthEventInvitationMessageErr := strconv.ParseInt(os.Getenv("LENGTH_EVENT_INVITATION_MESSAGE"), 10, 64)
 	lengthEventPolaroidMessage, lengthEventPolaroidMessageErr := strconv.ParseInt(os.Getenv("LENGTH_EVENT_POLAROID_MESSAGE"), 10, 64)
+	lengthEventPolaroidComment, lengthEventPolaroidCommentErr := strconv.ParseInt(os.Getenv("LENGTH_EVENT_POLAROID_COMMENT"), 10, 64)
 	lengthEventParticipantMessage, lengthEventParticipantMessageErr := strconv.ParseInt(os.Getenv("LENGTH_EVENT_PARTICIPANT_MESSAGE"), 10, 64)
 	minimumTopRanking, minimumTopRankingErr := strconv.ParseInt(os.Getenv("MINIMUM_TOP_RANKING"), 10, 64)
 	polaroidAchievementRadius, polaroidAchievementRadiusErr := strconv.ParseFloat(os.Getenv("POLAROID_ACHIEVEMENT_RADIUS"), 64)
+	polaroidExifToleranceHours, polaroidExifToleranceHoursErr := strconv.ParseInt(os.Getenv("POLAROID_EXIF_TOLERANCE_HOURS"), 10, 64)
+	polaroidDuplicateDistance, polaroidDuplicateDistanceErr := strconv.ParseInt(os.Getenv("POLAROID_DUPLICATE_DISTANCE"), 10, 64)
 
 	if err == nil {
 		APP_LIMIT.EventPolaroidLimit = polaroidLimit
@@ -123,12 +162,30 @@ func InitialiseConfig() {
 	if eventAccountingLimitErr == nil {
 		APP_LIMIT.EventAccountingLimit = eventAccountingLimit
 	}
+	if eventAccountingReceiptLimitErr == nil {
+		APP_LIMIT.EventAccountingReceiptLimit = eventAccountingReceiptLimit
+	}
 	if eventAnnouncementLimitErr == nil {
 		APP_LIMIT.EventAnnouncementLimit = eventAnnouncementLimit
 	}
 	if eventMessageBoardLimitErr == nil {
 		APP_LIMIT.EventMessageBoardLimit = eventMessageBoardLimit
 	}
+	if eventSeriesOccurrenceLimitErr == nil {
+		APP_LIMIT.EventSeriesOccurrenceLimit = eventSeriesOccurrenceLimit
+	}
+	if eventCancelGracePeriodHoursErr == nil {
+		APP_LIMIT.EventCancelGracePeriodHours = eventCancelGracePeriodHours
+	}
+	if paymentDeadlineHoursErr == nil {
+		APP_LIMIT.PaymentDeadlineHours = paymentDeadlineHours
+	}
+	if eventAnnouncementSchedulerSecondsErr == nil {
+		APP_LIMIT.AnnouncementSchedulerSeconds = eventAnnouncementSchedulerSeconds
+	}
+	if eventRouteFileSizeErr == nil {
+		APP_LIMIT.EventRouteFileSize = eventRouteFileSize
+	}
 	if pocketlistLimitErr == nil {
 		APP_LIMIT.PocketList = pocketListLimit
 	}
@@ -165,10 +222,19 @@ func InitialiseConfig() {
 	if lengthEventPolaroidMessageErr == nil {
 		APP_LIMIT.LengthEventPolaroidMessage = lengthEventPolaroidMessage
 	}
+	if lengthEventPolaroidCommentErr == nil {
+		APP_LIMIT.LengthEventPolaroidComment = lengthEventPolaroidComment
+	}
 	if lengthEventParticipantMessageErr == nil {
 		APP_LIMIT.LengthEventParticipantMessage = lengthEventParticipantMessage
 	}
 	if polaroidAchievementRadiusErr == nil {
 		APP_LIMIT.PolaroidAchievementRadius = polaroidAchievementRadius
 	}
+	if polaroidExifToleranceHoursErr == nil {
+		APP_LIMIT.PolaroidExifToleranceHours = polaroidExifToleranceHours
+	}
+	if polaroidDuplicateDistanceErr == nil {
+		APP_LIMIT.PolaroidDuplicateDistance = polaroidDuplicateDistance
+	}
 }
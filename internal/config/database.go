@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -24,3 +25,78 @@ func ConnectDatabase() *mongo.Database {
 	DB = client.Database(dbName)
 	return DB
 }
+
+// EnsureIndexes creates the indexes the API relies on and backfills the
+// GeoJSON location of events created before it was stored.
+func EnsureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	backfillFilter := bson.M{
+		"events_location": bson.M{"$exists": false},
+		"events_lat":      bson.M{"$exists": true},
+		"events_lng":      bson.M{"$exists": true},
+	}
+	backfill := mongo.Pipeline{
+		bson.D{{Key: "$set", Value: bson.M{
+			"events_location": bson.M{
+				"type":        "Point",
+				"coordinates": bson.A{"$events_lng", "$events_lat"},
+			},
+		}}},
+	}
+	_, err := DB.Collection("Events").UpdateMany(ctx, backfillFilter, backfill)
+	if err != nil {
+		fmt.Println("ERROR", err)
+	}
+
+	_, err = DB.Collection("Events").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "events_location", Value: "2dsphere"}},
+	})
+	if err != nil {
+		fmt.Println("ERROR", err)
+	}
+
+	// Polled by the announcement scheduler; only scheduled announcements have
+	// the flag, so the index stays small
+	_, err = DB.Collection("EventMessageBoard").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "event_message_board_scheduled", Value: 1}, {Key: "event_message_board_publish_at", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	})
+	if err != nil {
+		fmt.Println("ERROR", err)
+	}
+
+	// Looked up for near-duplicates on every polaroid upload
+	_, err = DB.Collection("EventPolaroids").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "event_polaroids_hash_chunks", Value: 1}},
+	})
+	if err != nil {
+		fmt.Println("ERROR", err)
+	}
+
+	// Reads used to be kept per event rather than per announcement; those
+	// cannot tell which announcement was read
+	_, err = DB.Collection("EventAnnouncementReads").DeleteMany(ctx, bson.M{"event_announcement_reads_announcement": bson.M{"$exists": false}})
+	if err != nil {
+		fmt.Println("ERROR", err)
+	}
+
+	// A user reads an announcement once, even when reading it concurrently
+	_, err = DB.Collection("EventAnnouncementReads").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "event_announcement_reads_announcement", Value: 1}, {Key: "event_announcement_reads_user", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		fmt.Println("ERROR", err)
+	}
+
+	// A user likes a polaroid once, even when liking it concurrently
+	_, err = DB.Collection("EventPolaroidLikes").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "event_polaroid_likes_polaroid", Value: 1}, {Key: "event_polaroid_likes_user", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		fmt.Println("ERROR", err)
+	}
+}
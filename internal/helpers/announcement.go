@@ -0,0 +1,9 @@
+package helpers
+
+// Announcement statuses. Scheduled announcements wait for the scheduler to
+// publish them and expired ones are hidden from the participants.
+const (
+	ANNOUNCEMENT_STATUS_SCHEDULED = "SCHEDULED"
+	ANNOUNCEMENT_STATUS_PUBLISHED = "PUBLISHED"
+	ANNOUNCEMENT_STATUS_EXPIRED   = "EXPIRED"
+)
@@ -23,12 +23,17 @@ var (
 )
 
 func BadgeAllocate(c *gin.Context, badgeCode string, badgeSource int, badgeReference primitive.ObjectID, userId primitive.ObjectID) {
-	badgeDetail := BadgeDetail(badgeCode)
-
+	userDetail := GetAuthUser(c)
 	if userId == primitive.NilObjectID {
-		userDetail := GetAuthUser(c)
 		userId = userDetail.UsersId
 	}
+	BadgeInsert(badgeCode, badgeSource, badgeReference, userId, userDetail.UsersId)
+}
+
+// BadgeInsert allocates a badge outside of a request, such as from a
+// background job, on behalf of createdBy.
+func BadgeInsert(badgeCode string, badgeSource int, badgeReference primitive.ObjectID, userId primitive.ObjectID, createdBy primitive.ObjectID) {
+	badgeDetail := BadgeDetail(badgeCode)
 
 	var UserBadges models.UserBadges
 
@@ -66,7 +71,7 @@ func BadgeAllocate(c *gin.Context, badgeCode string, badgeSource int, badgeRefer
 		Data:    []map[string]interface{}{NotificationFormatBadges(badgeDetail)},
 	}
 
-	NotificationsCreate(c, NOTIFICATION_BADGE_NEW, userId, NotificationMessage, result.InsertedID.(primitive.ObjectID))
+	NotificationsInsert(NOTIFICATION_BADGE_NEW, userId, NotificationMessage, result.InsertedID.(primitive.ObjectID), createdBy)
 	if err != nil {
 		fmt.Println("ERROR", err.Error())
 		return
@@ -0,0 +1,21 @@
+package helpers
+
+// Budget categories are shared by the budget items and the accounting records
+// so that estimated and actual spend can be compared per category.
+const (
+	BUDGET_CATEGORY_TRANSPORT = "TRANSPORT"
+	BUDGET_CATEGORY_LODGING   = "LODGING"
+	BUDGET_CATEGORY_PERMITS   = "PERMITS"
+	BUDGET_CATEGORY_FOOD      = "FOOD"
+	BUDGET_CATEGORY_GEAR      = "GEAR"
+	BUDGET_CATEGORY_OTHER     = "OTHER"
+)
+
+var BudgetCategories = []string{
+	BUDGET_CATEGORY_TRANSPORT,
+	BUDGET_CATEGORY_LODGING,
+	BUDGET_CATEGORY_PERMITS,
+	BUDGET_CATEGORY_FOOD,
+	BUDGET_CATEGORY_GEAR,
+	BUDGET_CATEGORY_OTHER,
+}
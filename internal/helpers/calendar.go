@@ -0,0 +1,108 @@
+package helpers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const calendarDateFormat = "20060102T150405Z"
+
+type CalendarEvent struct {
+	Uid         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	Lat         float64
+	Lng         float64
+	Url         string
+	UpdatedAt   time.Time
+}
+
+// CalendarRender builds an iCalendar (RFC 5545) document with one VEVENT per
+// event. All times are written in UTC.
+func CalendarRender(name string, events []CalendarEvent) string {
+	var lines []string
+	lines = append(lines,
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//OOSA//Rewild//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:"+calendarEscape(name),
+	)
+
+	now := time.Now().UTC().Format(calendarDateFormat)
+	for _, v := range events {
+		stamp := now
+		if !v.UpdatedAt.IsZero() {
+			stamp = v.UpdatedAt.UTC().Format(calendarDateFormat)
+		}
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+v.Uid,
+			"DTSTAMP:"+stamp,
+			"DTSTART:"+v.Start.UTC().Format(calendarDateFormat),
+		)
+		if !v.End.IsZero() {
+			lines = append(lines, "DTEND:"+v.End.UTC().Format(calendarDateFormat))
+		}
+		lines = append(lines, "SUMMARY:"+calendarEscape(v.Summary))
+		if v.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+calendarEscape(v.Description))
+		}
+		if v.Location != "" {
+			lines = append(lines, "LOCATION:"+calendarEscape(v.Location))
+		}
+		if v.Lat != 0 || v.Lng != 0 {
+			lines = append(lines, "GEO:"+strconv.FormatFloat(v.Lat, 'f', 6, 64)+";"+strconv.FormatFloat(v.Lng, 'f', 6, 64))
+		}
+		if v.Url != "" {
+			lines = append(lines, "URL:"+v.Url)
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var builder strings.Builder
+	for _, v := range lines {
+		builder.WriteString(calendarFold(v))
+		builder.WriteString("\r\n")
+	}
+	return builder.String()
+}
+
+func calendarEscape(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(value)
+}
+
+// calendarFold splits content lines longer than 75 octets without breaking
+// multi-byte characters, as required by RFC 5545.
+func calendarFold(line string) string {
+	if len(line) <= 75 {
+		return line
+	}
+	var builder strings.Builder
+	size := 0
+	limit := 75
+	for _, r := range line {
+		runeSize := len(string(r))
+		if size+runeSize > limit {
+			builder.WriteString("\r\n ")
+			size = 0
+			limit = 74
+		}
+		builder.WriteRune(r)
+		size += runeSize
+	}
+	return builder.String()
+}
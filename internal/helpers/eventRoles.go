@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"context"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	EVENT_ROLE_OWNER  = "OWNER"
+	EVENT_ROLE_COHOST = "COHOST"
+	EVENT_ROLE_MEMBER = "MEMBER"
+)
+
+// EventRole returns the role of the user in the event, or an empty string
+// when the user has not joined it. The event creator is always the owner and
+// accepted participants without a stored role are members.
+func EventRole(eventId primitive.ObjectID, userId primitive.ObjectID) string {
+	if MongoZeroID(userId) {
+		return ""
+	}
+
+	var Events models.Events
+	err := config.DB.Collection("Events").FindOne(context.TODO(), bson.D{{Key: "_id", Value: eventId}}).Decode(&Events)
+	if err != nil {
+		return ""
+	}
+	if Events.EventsCreatedBy == userId {
+		return EVENT_ROLE_OWNER
+	}
+
+	var EventParticipants models.EventParticipants
+	filter := bson.D{
+		{Key: "event_participants_event", Value: eventId},
+		{Key: "event_participants_user", Value: userId},
+		{Key: "event_participants_status", Value: 1},
+	}
+	err = config.DB.Collection("EventParticipants").FindOne(context.TODO(), filter).Decode(&EventParticipants)
+	if err != nil {
+		return ""
+	}
+	if EventParticipants.EventParticipantsRole == "" {
+		return EVENT_ROLE_MEMBER
+	}
+	return EventParticipants.EventParticipantsRole
+}
+
+func EventRoleIn(role string, roles []string) bool {
+	return role != "" && StringInSlice(role, roles)
+}
@@ -0,0 +1,215 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"oosa_rewild/internal/config"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	EVENT_STREAM_BROKER_MEMORY = "MEMORY"
+	EVENT_STREAM_BROKER_MONGO  = "MONGO"
+
+	EVENT_STREAM_MESSAGE_CREATED      = "MESSAGE_CREATED"
+	EVENT_STREAM_ANNOUNCEMENT_CREATED = "ANNOUNCEMENT_CREATED"
+	EVENT_STREAM_PARTICIPANT_JOINED   = "PARTICIPANT_JOINED"
+	EVENT_STREAM_PARTICIPANT_LEFT     = "PARTICIPANT_LEFT"
+	EVENT_STREAM_POLAROID_CREATED     = "POLAROID_CREATED"
+	EVENT_STREAM_POLAROID_UPDATED     = "POLAROID_UPDATED"
+	EVENT_STREAM_POLAROID_DELETED     = "POLAROID_DELETED"
+)
+
+// EventStreamMessage is pushed to the clients streaming an event. Data is the
+// same JSON the REST route returns for the record.
+type EventStreamMessage struct {
+	Id        string             `bson:"event_streams_id" json:"id"`
+	Event     primitive.ObjectID `bson:"event_streams_event" json:"event"`
+	Type      string             `bson:"event_streams_type" json:"type"`
+	Data      json.RawMessage    `bson:"event_streams_data" json:"data"`
+	CreatedAt primitive.DateTime `bson:"event_streams_created_at" json:"created_at"`
+}
+
+// EventStreamBroker fans messages out to the subscribers of an event.
+// Subscribe returns the channel of messages and a function that ends the
+// subscription.
+type EventStreamBroker interface {
+	Publish(message EventStreamMessage) error
+	Subscribe(eventId primitive.ObjectID) (<-chan EventStreamMessage, func())
+}
+
+var (
+	eventStreamBroker     EventStreamBroker
+	eventStreamBrokerErr  error
+	eventStreamBrokerOnce sync.Once
+)
+
+// EventStream returns the broker configured by EVENT_STREAM_BROKER. The
+// in-memory broker only reaches clients connected to the same instance; the
+// Mongo broker relays messages through the database to every instance.
+func EventStream() (EventStreamBroker, error) {
+	eventStreamBrokerOnce.Do(func() {
+		switch config.APP.EventStreamBroker {
+		case "", EVENT_STREAM_BROKER_MEMORY:
+			eventStreamBroker = NewMemoryEventStreamBroker()
+		case EVENT_STREAM_BROKER_MONGO:
+			eventStreamBroker = NewMongoEventStreamBroker()
+		default:
+			eventStreamBrokerErr = errors.New("unsupported event stream broker " + config.APP.EventStreamBroker)
+		}
+	})
+	return eventStreamBroker, eventStreamBrokerErr
+}
+
+// EventStreamPublish pushes the data to the clients streaming the event.
+// Streaming is best effort, so failures are only logged.
+func EventStreamPublish(eventId primitive.ObjectID, messageType string, data interface{}) {
+	broker, err := EventStream()
+	if err != nil {
+		fmt.Println("event stream err: " + err.Error())
+		return
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		fmt.Println("event stream err: " + err.Error())
+		return
+	}
+
+	err = broker.Publish(EventStreamMessage{
+		Id:        primitive.NewObjectID().Hex(),
+		Event:     eventId,
+		Type:      messageType,
+		Data:      raw,
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	})
+	if err != nil {
+		fmt.Println("event stream err: " + err.Error())
+	}
+}
+
+// MemoryEventStreamBroker delivers messages to the subscribers of this
+// instance. Subscribers that fall behind miss messages rather than blocking
+// the publisher.
+type MemoryEventStreamBroker struct {
+	mutex       sync.RWMutex
+	subscribers map[primitive.ObjectID]map[chan EventStreamMessage]bool
+}
+
+func NewMemoryEventStreamBroker() *MemoryEventStreamBroker {
+	return &MemoryEventStreamBroker{subscribers: map[primitive.ObjectID]map[chan EventStreamMessage]bool{}}
+}
+
+func (b *MemoryEventStreamBroker) Publish(message EventStreamMessage) error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for subscriber := range b.subscribers[message.Event] {
+		select {
+		case subscriber <- message:
+		default:
+		}
+	}
+	return nil
+}
+
+func (b *MemoryEventStreamBroker) Subscribe(eventId primitive.ObjectID) (<-chan EventStreamMessage, func()) {
+	subscriber := make(chan EventStreamMessage, 32)
+	b.mutex.Lock()
+	if b.subscribers[eventId] == nil {
+		b.subscribers[eventId] = map[chan EventStreamMessage]bool{}
+	}
+	b.subscribers[eventId][subscriber] = true
+	b.mutex.Unlock()
+
+	var once sync.Once
+	return subscriber, func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			delete(b.subscribers[eventId], subscriber)
+			if len(b.subscribers[eventId]) == 0 {
+				delete(b.subscribers, eventId)
+			}
+			b.mutex.Unlock()
+			close(subscriber)
+		})
+	}
+}
+
+// MongoEventStreamBroker writes messages to the EventStreams collection and
+// every instance polls it, handing new messages to its in-memory broker. It
+// works on standalone servers, where change streams are not available.
+type MongoEventStreamBroker struct {
+	local *MemoryEventStreamBroker
+	once  sync.Once
+}
+
+const (
+	eventStreamPollInterval = time.Second
+	// Messages are read again for this long, so that messages written with a
+	// slightly earlier time by another instance are not missed
+	eventStreamPollOverlap = 5 * time.Second
+	eventStreamRetention   = time.Hour
+)
+
+func NewMongoEventStreamBroker() *MongoEventStreamBroker {
+	return &MongoEventStreamBroker{local: NewMemoryEventStreamBroker()}
+}
+
+func (b *MongoEventStreamBroker) Publish(message EventStreamMessage) error {
+	_, err := config.DB.Collection("EventStreams").InsertOne(context.TODO(), message)
+	return err
+}
+
+func (b *MongoEventStreamBroker) Subscribe(eventId primitive.ObjectID) (<-chan EventStreamMessage, func()) {
+	b.once.Do(func() { go b.poll() })
+	return b.local.Subscribe(eventId)
+}
+
+func (b *MongoEventStreamBroker) poll() {
+	since := time.Now()
+	seen := map[string]time.Time{}
+	lastCleanup := time.Now()
+	for {
+		time.Sleep(eventStreamPollInterval)
+
+		from := since.Add(-eventStreamPollOverlap)
+		filter := bson.D{{Key: "event_streams_created_at", Value: bson.M{"$gte": primitive.NewDateTimeFromTime(from)}}}
+		opts := options.Find().SetSort(bson.D{{Key: "event_streams_created_at", Value: 1}})
+		var messages []EventStreamMessage
+		cursor, err := config.DB.Collection("EventStreams").Find(context.TODO(), filter, opts)
+		if err != nil {
+			fmt.Println("event stream err: " + err.Error())
+			continue
+		}
+		cursor.All(context.TODO(), &messages)
+
+		for _, v := range messages {
+			if _, ok := seen[v.Id]; ok {
+				continue
+			}
+			seen[v.Id] = v.CreatedAt.Time()
+			b.local.Publish(v)
+			if v.CreatedAt.Time().After(since) {
+				since = v.CreatedAt.Time()
+			}
+		}
+		for id, createdAt := range seen {
+			if createdAt.Before(from) {
+				delete(seen, id)
+			}
+		}
+
+		if time.Since(lastCleanup) > eventStreamRetention {
+			lastCleanup = time.Now()
+			expired := bson.D{{Key: "event_streams_created_at", Value: bson.M{"$lt": primitive.NewDateTimeFromTime(time.Now().Add(-eventStreamRetention))}}}
+			config.DB.Collection("EventStreams").DeleteMany(context.TODO(), expired)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package helpers
+
+import (
+	"context"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	EVENT_VISIBILITY_PUBLIC  = "PUBLIC"
+	EVENT_VISIBILITY_FRIENDS = "FRIENDS"
+	EVENT_VISIBILITY_INVITE  = "INVITE"
+)
+
+// USER_FRIENDS_STATUS_ACCEPTED is the UserFriends status of an accepted
+// friendship. 0 is a suggested friend and 3 a removed one.
+var USER_FRIENDS_STATUS_ACCEPTED = 1
+
+// EventVisible reports whether the user may see the event. Public events are
+// visible to everyone, friends-only events to the creator's friends, and
+// both friends-only and invite-only events to anyone invited to or taking
+// part in them.
+func EventVisible(Events models.Events, userId primitive.ObjectID) bool {
+	if Events.EventsVisibility == "" || Events.EventsVisibility == EVENT_VISIBILITY_PUBLIC {
+		return true
+	}
+	if MongoZeroID(userId) {
+		return false
+	}
+	if Events.EventsCreatedBy == userId {
+		return true
+	}
+
+	participantFilter := bson.D{
+		{Key: "event_participants_event", Value: Events.EventsId},
+		{Key: "event_participants_user", Value: userId},
+		{Key: "event_participants_status", Value: bson.M{"$ne": 2}},
+	}
+	count, _ := config.DB.Collection("EventParticipants").CountDocuments(context.TODO(), participantFilter)
+	if count > 0 {
+		return true
+	}
+
+	if Events.EventsVisibility == EVENT_VISIBILITY_FRIENDS {
+		for _, v := range UserFriendIds(userId) {
+			if v == Events.EventsCreatedBy {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EventVisibilityFilter returns the match condition limiting an Events query
+// to the events the user may see.
+func EventVisibilityFilter(userId primitive.ObjectID) bson.M {
+	public := bson.M{"events_visibility": bson.M{"$in": bson.A{nil, EVENT_VISIBILITY_PUBLIC}}}
+	if MongoZeroID(userId) {
+		return public
+	}
+
+	var EventParticipants []models.EventParticipants
+	participantFilter := bson.D{
+		{Key: "event_participants_user", Value: userId},
+		{Key: "event_participants_status", Value: bson.M{"$ne": 2}},
+	}
+	cursor, _ := config.DB.Collection("EventParticipants").Find(context.TODO(), participantFilter)
+	cursor.All(context.TODO(), &EventParticipants)
+
+	eventIds := []primitive.ObjectID{}
+	for _, v := range EventParticipants {
+		eventIds = append(eventIds, v.EventParticipantsEvent)
+	}
+
+	return bson.M{"$or": bson.A{
+		public,
+		bson.M{"events_created_by": userId},
+		bson.M{"_id": bson.M{"$in": eventIds}},
+		bson.M{
+			"events_visibility": EVENT_VISIBILITY_FRIENDS,
+			"events_created_by": bson.M{"$in": UserFriendIds(userId)},
+		},
+	}}
+}
+
+func UserFriendIds(userId primitive.ObjectID) []primitive.ObjectID {
+	var UserFriends []models.UserFriends
+	filter := bson.D{
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "user_friends_user_1", Value: userId}},
+			bson.D{{Key: "user_friends_user_2", Value: userId}},
+		}},
+		{Key: "user_friends_status", Value: USER_FRIENDS_STATUS_ACCEPTED},
+	}
+	cursor, _ := config.DB.Collection("UserFriends").Find(context.TODO(), filter)
+	cursor.All(context.TODO(), &UserFriends)
+
+	friendIds := []primitive.ObjectID{}
+	for _, v := range UserFriends {
+		if v.UserFriendsUser1 == userId {
+			friendIds = append(friendIds, v.UserFriendsUser2)
+		} else {
+			friendIds = append(friendIds, v.UserFriendsUser1)
+		}
+	}
+	return friendIds
+}
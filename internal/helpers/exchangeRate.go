@@ -0,0 +1,79 @@
+package helpers
+
+import (
+	"errors"
+	"math"
+	"oosa_rewild/internal/config"
+	"strings"
+)
+
+const (
+	EXCHANGE_RATE_SOURCE_STATIC = "STATIC"
+	EXCHANGE_RATE_SOURCE_MANUAL = "MANUAL"
+
+	DEFAULT_CURRENCY = "TWD"
+)
+
+// ExchangeRateSource converts between currencies. Rate returns how many units
+// of the to currency one unit of the from currency is worth.
+type ExchangeRateSource interface {
+	Name() string
+	Rate(from string, to string) (float64, error)
+}
+
+// ExchangeRates returns the source configured by EXCHANGE_RATE_SOURCE.
+func ExchangeRates() (ExchangeRateSource, error) {
+	switch config.APP.ExchangeRateSource {
+	case "", EXCHANGE_RATE_SOURCE_STATIC:
+		return StaticExchangeRateSource{}, nil
+	}
+	return nil, errors.New("unsupported exchange rate source " + config.APP.ExchangeRateSource)
+}
+
+// StaticExchangeRateSource stands in for a live rate feed with approximate
+// rates, expressed per US dollar.
+type StaticExchangeRateSource struct{}
+
+var staticExchangeRates = map[string]float64{
+	"USD": 1,
+	"TWD": 32,
+	"JPY": 150,
+	"EUR": 0.92,
+	"GBP": 0.79,
+	"HKD": 7.8,
+	"CNY": 7.2,
+	"KRW": 1350,
+	"SGD": 1.35,
+	"THB": 36,
+	"MYR": 4.7,
+	"PHP": 56,
+	"VND": 25000,
+	"IDR": 16000,
+	"AUD": 1.5,
+	"NZD": 1.65,
+	"CAD": 1.36,
+}
+
+func (s StaticExchangeRateSource) Name() string {
+	return EXCHANGE_RATE_SOURCE_STATIC
+}
+
+func (s StaticExchangeRateSource) Rate(from string, to string) (float64, error) {
+	from = strings.ToUpper(from)
+	to = strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+
+	fromRate, fromOk := staticExchangeRates[from]
+	toRate, toOk := staticExchangeRates[to]
+	if !fromOk || !toOk {
+		return 0, errors.New("no exchange rate from " + from + " to " + to)
+	}
+	return toRate / fromRate, nil
+}
+
+// ExchangeConvert converts an amount with the rate, rounded to cents.
+func ExchangeConvert(amount float64, rate float64) float64 {
+	return math.Round(amount*rate*100) / 100
+}
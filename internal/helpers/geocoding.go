@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"googlemaps.github.io/maps"
@@ -67,3 +68,10 @@ func Haversine(lat1 float64, lon1 float64, lat2 float64, lon2 float64) float64 {
 func toRad(deg float64) float64 {
 	return deg * (math.Pi / 180)
 }
+
+func GeoPoint(lat float64, lng float64) *models.GeoJsonPoint {
+	return &models.GeoJsonPoint{
+		Type:        "Point",
+		Coordinates: []float64{lng, lat},
+	}
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MentionToken is an @username found in a text. Offset and Length count
+// characters and include the @.
+type MentionToken struct {
+	Username string
+	Offset   int
+	Length   int
+}
+
+// MentionParse finds the @username mentions in the text. A mention has to
+// start the text or follow a character that is not part of a word, so that
+// e-mail addresses are not taken for mentions. Trailing dots and dashes are
+// treated as punctuation.
+func MentionParse(text string) []MentionToken {
+	var mentions []MentionToken
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && mentionRune(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && mentionRune(runes[end]) {
+			end++
+		}
+		for end > i+1 && strings.ContainsRune(".-", runes[end-1]) {
+			end--
+		}
+		if end == i+1 {
+			continue
+		}
+
+		mentions = append(mentions, MentionToken{
+			Username: string(runes[i+1 : end]),
+			Offset:   i,
+			Length:   end - i,
+		})
+		i = end - 1
+	}
+	return mentions
+}
+
+func mentionRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-'
+}
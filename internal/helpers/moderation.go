@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"oosa_rewild/internal/models"
+	"time"
+)
+
+// Content that can be reported
+const (
+	REPORT_TYPE_MESSAGE_BOARD   = "MESSAGE_BOARD"
+	REPORT_TYPE_POLAROID        = "POLAROID"
+	REPORT_TYPE_REWILDING_PHOTO = "REWILDING_PHOTO"
+	REPORT_TYPE_REWILDING       = "REWILDING"
+)
+
+const (
+	REPORT_REASON_SPAM           = "SPAM"
+	REPORT_REASON_HARASSMENT     = "HARASSMENT"
+	REPORT_REASON_HATE_SPEECH    = "HATE_SPEECH"
+	REPORT_REASON_VIOLENCE       = "VIOLENCE"
+	REPORT_REASON_NUDITY         = "NUDITY"
+	REPORT_REASON_MISINFORMATION = "MISINFORMATION"
+	REPORT_REASON_COPYRIGHT      = "COPYRIGHT"
+	REPORT_REASON_OTHER          = "OTHER"
+	// Raised by the API, not by users, for polaroids whose EXIF looks off
+	REPORT_REASON_SUSPICIOUS_PHOTO = "SUSPICIOUS_PHOTO"
+)
+
+const (
+	REPORT_STATUS_OPEN      = "OPEN"
+	REPORT_STATUS_RESOLVED  = "RESOLVED"
+	REPORT_STATUS_DISMISSED = "DISMISSED"
+)
+
+const (
+	MODERATION_ACTION_HIDE      = "HIDE"
+	MODERATION_ACTION_RESTORE   = "RESTORE"
+	MODERATION_ACTION_DELETE    = "DELETE"
+	MODERATION_ACTION_DISMISS   = "DISMISS"
+	MODERATION_ACTION_WARN      = "WARN"
+	MODERATION_ACTION_SUSPEND   = "SUSPEND"
+	MODERATION_ACTION_UNSUSPEND = "UNSUSPEND"
+)
+
+// UserSuspended tells whether the user is suspended at the moment.
+func UserSuspended(Users models.Users) bool {
+	return Users.UsersSuspendedUntil.Time().After(time.Now())
+}
@@ -25,19 +25,39 @@ var (
 	NOTIFICATION_EVENT_MEMBER_DELETED    = "EVENT_MEMBER_DELETED"
 	NOTIFICATION_EVENT_JOIN_DENIED       = "EVENT_JOIN_DENIED"
 	NOTIFICATION_EVENT_JOIN_ACCEPTED     = "EVENT_JOIN_ACCEPTED"
+	NOTIFICATION_EVENT_WAITLIST_PROMOTED = "EVENT_WAITLIST_PROMOTED"
+	NOTIFICATION_EVENT_WAITLISTED        = "EVENT_WAITLISTED"
+	NOTIFICATION_EVENT_CANCELLED         = "EVENT_CANCELLED"
+	NOTIFICATION_EVENT_RESTORED          = "EVENT_RESTORED"
+	NOTIFICATION_EVENT_PAYMENT_REQUIRED  = "EVENT_PAYMENT_REQUIRED"
+	NOTIFICATION_EVENT_PAYMENT_PAID      = "EVENT_PAYMENT_PAID"
+	NOTIFICATION_EVENT_PAYMENT_EXPIRED   = "EVENT_PAYMENT_EXPIRED"
+	NOTIFICATION_EVENT_MESSAGE_REPLY     = "EVENT_MESSAGE_REPLY"
+	NOTIFICATION_EVENT_MENTION           = "EVENT_MENTION"
+	NOTIFICATION_EVENT_ANNOUNCEMENT      = "EVENT_ANNOUNCEMENT"
+	NOTIFICATION_MODERATION_WARNING      = "MODERATION_WARNING"
+	NOTIFICATION_MODERATION_SUSPENDED    = "MODERATION_SUSPENDED"
 	NOTIFICATION_COLOG_PHOTO_UPLOADED    = "COLOG_PHOTO_UPLOADED"
 	NOTIFICATION_COLOG_REMIND            = "COLOG_REMIND"
+	NOTIFICATION_COLOG_PHOTO_LIKED       = "COLOG_PHOTO_LIKED"
+	NOTIFICATION_COLOG_PHOTO_COMMENTED   = "COLOG_PHOTO_COMMENTED"
 )
 
 func NotificationsCreate(c *gin.Context, notifCode string, userId primitive.ObjectID, message models.NotificationMessage, identifier primitive.ObjectID) {
 	userDetail := GetAuthUser(c)
+	NotificationsInsert(notifCode, userId, message, identifier, userDetail.UsersId)
+}
+
+// NotificationsInsert creates a notification outside of a request, such as
+// from a background job, on behalf of createdBy.
+func NotificationsInsert(notifCode string, userId primitive.ObjectID, message models.NotificationMessage, identifier primitive.ObjectID, createdBy primitive.ObjectID) {
 	insert := models.Notifications{
 		NotificationsCode:       notifCode,
 		NotificationsUser:       userId,
 		NotificationsMessage:    message,
 		NotificationsIdentifier: identifier,
 		NotificationsCreatedAt:  primitive.NewDateTimeFromTime(time.Now()),
-		NotificationsCreatedBy:  userDetail.UsersId,
+		NotificationsCreatedBy:  createdBy,
 	}
 	config.DB.Collection("Notifications").InsertOne(context.TODO(), insert)
 }
@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"time"
+)
+
+const (
+	PAYMENT_PROVIDER_FAKE = "FAKE"
+
+	PAYMENT_STATUS_PENDING = "PENDING"
+	PAYMENT_STATUS_PAID    = "PAID"
+	PAYMENT_STATUS_FAILED  = "FAILED"
+
+	PAYMENT_SIGNATURE_HEADER = "X-Payment-Signature"
+)
+
+type PaymentIntentRequest struct {
+	Reference   string  `json:"reference"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	Description string  `json:"description"`
+}
+
+type PaymentIntent struct {
+	Provider    string    `json:"provider"`
+	Id          string    `json:"id"`
+	Status      string    `json:"status"`
+	Amount      float64   `json:"amount"`
+	Currency    string    `json:"currency"`
+	CheckoutUrl string    `json:"checkout_url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PaymentWebhook is the provider callback, normalised to our statuses.
+type PaymentWebhook struct {
+	IntentId string  `json:"intent_id"`
+	Status   string  `json:"status"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// PaymentProvider is implemented by each payment gateway. ParseWebhook must
+// reject callbacks whose signature does not verify.
+type PaymentProvider interface {
+	Name() string
+	CreateIntent(request PaymentIntentRequest) (PaymentIntent, error)
+	ParseWebhook(header http.Header, body []byte) (PaymentWebhook, error)
+}
+
+// Payment returns the provider configured by PAYMENT_PROVIDER.
+func Payment() (PaymentProvider, error) {
+	switch config.APP.PaymentProvider {
+	case PAYMENT_PROVIDER_FAKE:
+		return FakePaymentProvider{}, nil
+	case "":
+		return nil, errors.New("payment provider is not configured")
+	}
+	return nil, errors.New("unsupported payment provider " + config.APP.PaymentProvider)
+}
+
+// FakePaymentProvider completes payments locally. Its checkout URL points at
+// an endpoint of this service which sends a signed webhook, so the whole flow
+// can be exercised without a gateway.
+type FakePaymentProvider struct{}
+
+func (p FakePaymentProvider) Name() string {
+	return PAYMENT_PROVIDER_FAKE
+}
+
+func (p FakePaymentProvider) CreateIntent(request PaymentIntentRequest) (PaymentIntent, error) {
+	token, err := RandomToken(12)
+	if err != nil {
+		return PaymentIntent{}, err
+	}
+	id := "fake_" + token
+	return PaymentIntent{
+		Provider:    p.Name(),
+		Id:          id,
+		Status:      PAYMENT_STATUS_PENDING,
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		CheckoutUrl: config.APP.BaseUrl + "payment/fake/" + id,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+func (p FakePaymentProvider) ParseWebhook(header http.Header, body []byte) (PaymentWebhook, error) {
+	var webhook PaymentWebhook
+	if config.APP.PaymentWebhookSecret == "" {
+		return webhook, errors.New("webhook secret is not configured")
+	}
+	if !hmac.Equal([]byte(header.Get(PAYMENT_SIGNATURE_HEADER)), []byte(p.Sign(body))) {
+		return webhook, errors.New("invalid signature")
+	}
+	err := json.Unmarshal(body, &webhook)
+	if err != nil {
+		return webhook, errors.New("invalid payload")
+	}
+	return webhook, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of the body with PAYMENT_WEBHOOK_SECRET.
+func (p FakePaymentProvider) Sign(body []byte) string {
+	h := hmac.New(sha256.New, []byte(config.APP.PaymentWebhookSecret))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
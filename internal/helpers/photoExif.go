@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// Reasons a polaroid cannot be trusted for the 1 star achievement
+const (
+	POLAROID_FLAG_EXIF_MISSING      = "EXIF_MISSING"
+	POLAROID_FLAG_GPS_MISSING       = "GPS_MISSING"
+	POLAROID_FLAG_TIME_MISSING      = "TIME_MISSING"
+	POLAROID_FLAG_TIME_INCONSISTENT = "TIME_INCONSISTENT"
+	POLAROID_FLAG_EDITED            = "EDITED"
+)
+
+// Photo editors that leave their name in the EXIF Software tag. Phones write
+// their OS version there, so only known editors are flagged.
+var photoEditors = []string{
+	"photoshop",
+	"lightroom",
+	"adobe",
+	"gimp",
+	"snapseed",
+	"picsart",
+	"vsco",
+	"meitu",
+	"facetune",
+	"airbrush",
+	"pixelmator",
+	"affinity photo",
+	"paint.net",
+	"canva",
+	"faceapp",
+	"photoscape",
+}
+
+// PhotoExifFlags checks the EXIF of a photo taken for an event held from start
+// to end. x is nil when the photo has no readable EXIF.
+func PhotoExifFlags(x *exif.Exif, start time.Time, end time.Time, tolerance time.Duration) []string {
+	if x == nil {
+		return []string{POLAROID_FLAG_EXIF_MISSING}
+	}
+
+	flags := []string{}
+	if _, _, err := x.LatLong(); err != nil {
+		flags = append(flags, POLAROID_FLAG_GPS_MISSING)
+	}
+
+	tm, err := x.DateTime()
+	if err != nil {
+		flags = append(flags, POLAROID_FLAG_TIME_MISSING)
+	} else if tm.Before(start.Add(-tolerance)) || tm.After(end.Add(tolerance)) {
+		flags = append(flags, POLAROID_FLAG_TIME_INCONSISTENT)
+	}
+
+	if PhotoEditedBy(x) != "" {
+		flags = append(flags, POLAROID_FLAG_EDITED)
+	}
+	return flags
+}
+
+// PhotoEditedBy returns the Software tag of the photo when it names a known
+// photo editor.
+func PhotoEditedBy(x *exif.Exif) string {
+	tag, err := x.Get(exif.Software)
+	if err != nil {
+		return ""
+	}
+	software, err := tag.StringVal()
+	if err != nil {
+		return ""
+	}
+
+	lower := strings.ToLower(software)
+	for _, editor := range photoEditors {
+		if strings.Contains(lower, editor) {
+			return strings.TrimSpace(software)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math/bits"
+	"strconv"
+)
+
+const (
+	photoHashWidth  = 9
+	photoHashHeight = 8
+	// Pixels sampled per side of each cell, so that large photos hash quickly
+	photoHashSamples = 8
+	// The hash is split into this many chunks to look up similar hashes. Two
+	// hashes fewer than PhotoHashChunkCount bits apart share a chunk.
+	PhotoHashChunkCount = 8
+	// Larger photos are not decoded, since a small file can declare a huge
+	// image and the decoded pixels are held in memory
+	PhotoHashMaxPixels = 40_000_000
+)
+
+// PhotoHash computes the difference hash (dHash) of a JPEG, PNG or GIF photo:
+// the photo is shrunk to 9x8 grey cells and each bit tells whether a cell is
+// brighter than its right neighbour. Recompressed, resized or slightly
+// retouched copies of a photo hash the same or a few bits apart.
+func PhotoHash(b []byte) (uint64, error) {
+	photoConfig, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return 0, err
+	}
+	if int64(photoConfig.Width)*int64(photoConfig.Height) > PhotoHashMaxPixels {
+		return 0, fmt.Errorf("photo is too large to hash")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return 0, err
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() < photoHashWidth || bounds.Dy() < photoHashHeight {
+		return 0, fmt.Errorf("photo is too small to hash")
+	}
+
+	var cells [photoHashHeight][photoHashWidth]float64
+	for y := 0; y < photoHashHeight; y++ {
+		for x := 0; x < photoHashWidth; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/photoHashWidth
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/photoHashWidth
+			y0 := bounds.Min.Y + y*bounds.Dy()/photoHashHeight
+			y1 := bounds.Min.Y + (y+1)*bounds.Dy()/photoHashHeight
+			cells[y][x] = photoHashLuminance(img, x0, x1, y0, y1)
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < photoHashHeight; y++ {
+		for x := 0; x < photoHashWidth-1; x++ {
+			hash <<= 1
+			if cells[y][x] > cells[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash, nil
+}
+
+// photoHashLuminance averages the grey level of a sample of the pixels in
+// the cell.
+func photoHashLuminance(img image.Image, x0, x1, y0, y1 int) float64 {
+	stepX := max((x1-x0)/photoHashSamples, 1)
+	stepY := max((y1-y0)/photoHashSamples, 1)
+	total := float64(0)
+	count := 0
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			total += float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+			count++
+		}
+	}
+	return total / float64(count)
+}
+
+// PhotoHashDistance counts the bits two hashes differ in.
+func PhotoHashDistance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func PhotoHashString(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+func PhotoHashParse(value string) (uint64, error) {
+	return strconv.ParseUint(value, 16, 64)
+}
+
+// PhotoHashChunks splits the hash into indexed chunks. Stored with the photo
+// they let the database find the candidates for a near-duplicate.
+func PhotoHashChunks(hash uint64) []string {
+	chunks := make([]string, PhotoHashChunkCount)
+	size := 64 / PhotoHashChunkCount
+	for i := range chunks {
+		chunk := (hash >> (i * size)) & (1<<size - 1)
+		chunks[i] = strconv.Itoa(i) + ":" + strconv.FormatUint(chunk, 16)
+	}
+	return chunks
+}
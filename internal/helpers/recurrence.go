@@ -0,0 +1,268 @@
+package helpers
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	RECURRENCE_DAILY   = "DAILY"
+	RECURRENCE_WEEKLY  = "WEEKLY"
+	RECURRENCE_MONTHLY = "MONTHLY"
+)
+
+var recurrenceWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Recurrence is the subset of an iCalendar RRULE supported for event series:
+// FREQ (DAILY, WEEKLY, MONTHLY), INTERVAL, COUNT, UNTIL, BYDAY and BYMONTHDAY.
+type Recurrence struct {
+	Frequency  string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []RecurrenceDay
+	ByMonthDay []int
+}
+
+// RecurrenceDay is a BYDAY entry. Ordinal is only used by monthly rules,
+// e.g. 1SA is the first Saturday and -1SU the last Sunday of the month.
+type RecurrenceDay struct {
+	Weekday time.Weekday
+	Ordinal int
+}
+
+func RecurrenceParse(rule string) (Recurrence, error) {
+	rec := Recurrence{Interval: 1}
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return rec, errors.New("recurrence rule is empty")
+	}
+
+	for _, part := range strings.Split(rule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return rec, errors.New("invalid recurrence rule part: " + part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		switch key {
+		case "FREQ":
+			if value != RECURRENCE_DAILY && value != RECURRENCE_WEEKLY && value != RECURRENCE_MONTHLY {
+				return rec, errors.New("unsupported frequency: " + value)
+			}
+			rec.Frequency = value
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return rec, errors.New("invalid interval: " + value)
+			}
+			rec.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return rec, errors.New("invalid count: " + value)
+			}
+			rec.Count = count
+		case "UNTIL":
+			until, err := recurrenceParseUntil(value)
+			if err != nil {
+				return rec, err
+			}
+			rec.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				byDay, err := recurrenceParseDay(day)
+				if err != nil {
+					return rec, err
+				}
+				rec.ByDay = append(rec.ByDay, byDay)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return rec, errors.New("invalid month day: " + day)
+				}
+				rec.ByMonthDay = append(rec.ByMonthDay, monthDay)
+			}
+		default:
+			return rec, errors.New("unsupported recurrence rule part: " + key)
+		}
+	}
+
+	if rec.Frequency == "" {
+		return rec, errors.New("recurrence rule requires FREQ")
+	}
+	if rec.Frequency != RECURRENCE_MONTHLY && len(rec.ByMonthDay) > 0 {
+		return rec, errors.New("BYMONTHDAY is only supported for monthly rules")
+	}
+	for _, v := range rec.ByDay {
+		if v.Ordinal != 0 && rec.Frequency != RECURRENCE_MONTHLY {
+			return rec, errors.New("BYDAY ordinals are only supported for monthly rules")
+		}
+	}
+	return rec, nil
+}
+
+func recurrenceParseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102"} {
+		until, err := time.Parse(layout, value)
+		if err == nil {
+			if layout == "20060102" {
+				until = until.Add(24*time.Hour - time.Second)
+			}
+			return until, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid until: " + value)
+}
+
+func recurrenceParseDay(value string) (RecurrenceDay, error) {
+	if len(value) < 2 {
+		return RecurrenceDay{}, errors.New("invalid day: " + value)
+	}
+	weekday, ok := recurrenceWeekdays[value[len(value)-2:]]
+	if !ok {
+		return RecurrenceDay{}, errors.New("invalid day: " + value)
+	}
+	ordinal := 0
+	if len(value) > 2 {
+		n, err := strconv.Atoi(value[:len(value)-2])
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return RecurrenceDay{}, errors.New("invalid day: " + value)
+		}
+		ordinal = n
+	}
+	return RecurrenceDay{Weekday: weekday, Ordinal: ordinal}, nil
+}
+
+// String formats the rule back into RRULE syntax for storage.
+func (rec Recurrence) String() string {
+	parts := []string{"FREQ=" + rec.Frequency}
+	if rec.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(rec.Interval))
+	}
+	if rec.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(rec.Count))
+	}
+	if !rec.Until.IsZero() {
+		parts = append(parts, "UNTIL="+rec.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(rec.ByDay) > 0 {
+		var days []string
+		for _, v := range rec.ByDay {
+			day := ""
+			if v.Ordinal != 0 {
+				day = strconv.Itoa(v.Ordinal)
+			}
+			for code, weekday := range recurrenceWeekdays {
+				if weekday == v.Weekday {
+					day += code
+				}
+			}
+			days = append(days, day)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(rec.ByMonthDay) > 0 {
+		var days []string
+		for _, v := range rec.ByMonthDay {
+			days = append(days, strconv.Itoa(v))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences expands the rule from start, which is always the first
+// occurrence. Weekdays and month days are evaluated in start's location and
+// at most limit occurrences are returned.
+func (rec Recurrence) Occurrences(start time.Time, limit int) []time.Time {
+	occurrences := []time.Time{start}
+	maxPeriods := 1000
+
+	for period := 0; period < maxPeriods; period++ {
+		for _, v := range rec.periodCandidates(start, period) {
+			if !v.After(occurrences[len(occurrences)-1]) {
+				continue
+			}
+			if !rec.Until.IsZero() && v.After(rec.Until) {
+				return occurrences
+			}
+			if (rec.Count > 0 && len(occurrences) >= rec.Count) || len(occurrences) >= limit {
+				return occurrences
+			}
+			occurrences = append(occurrences, v)
+		}
+	}
+	return occurrences
+}
+
+// periodCandidates returns the sorted candidate times within the period (day,
+// week or month) that lies n intervals after start's period.
+func (rec Recurrence) periodCandidates(start time.Time, n int) []time.Time {
+	var candidates []time.Time
+	hour, min, sec := start.Clock()
+	loc := start.Location()
+	step := n * rec.Interval
+
+	switch rec.Frequency {
+	case RECURRENCE_DAILY:
+		candidates = append(candidates, start.AddDate(0, 0, step))
+	case RECURRENCE_WEEKLY:
+		weekStart := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*step)
+		if len(rec.ByDay) == 0 {
+			candidates = append(candidates, start.AddDate(0, 0, 7*step))
+		}
+		for _, v := range rec.ByDay {
+			offset := (int(v.Weekday) + 6) % 7
+			day := weekStart.AddDate(0, 0, offset)
+			candidates = append(candidates, time.Date(day.Year(), day.Month(), day.Day(), hour, min, sec, 0, loc))
+		}
+	case RECURRENCE_MONTHLY:
+		month := time.Date(start.Year(), start.Month()+time.Month(step), 1, hour, min, sec, 0, loc)
+		daysInMonth := month.AddDate(0, 1, -1).Day()
+		monthDays := rec.ByMonthDay
+		if len(monthDays) == 0 && len(rec.ByDay) == 0 {
+			monthDays = []int{start.Day()}
+		}
+		for _, v := range monthDays {
+			day := v
+			if day < 0 {
+				day = daysInMonth + day + 1
+			}
+			if day >= 1 && day <= daysInMonth {
+				candidates = append(candidates, month.AddDate(0, 0, day-1))
+			}
+		}
+		for _, v := range rec.ByDay {
+			for day := 1; day <= daysInMonth; day++ {
+				candidate := month.AddDate(0, 0, day-1)
+				if candidate.Weekday() != v.Weekday {
+					continue
+				}
+				nth := (day-1)/7 + 1
+				nthFromEnd := -((daysInMonth-day)/7 + 1)
+				if v.Ordinal == 0 || v.Ordinal == nth || v.Ordinal == nthFromEnd {
+					candidates = append(candidates, candidate)
+				}
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Before(candidates[j])
+	})
+	return candidates
+}
@@ -0,0 +1,218 @@
+package helpers
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Settlement amounts are handled in cents so that splitting never loses or
+// invents money through floating point rounding.
+
+const (
+	SPLIT_TYPE_EQUAL      = "EQUAL"
+	SPLIT_TYPE_PERCENTAGE = "PERCENTAGE"
+	SPLIT_TYPE_SHARES     = "SHARES"
+	SPLIT_TYPE_EXACT      = "EXACT"
+)
+
+type SettlementWeight struct {
+	User  primitive.ObjectID
+	Value float64
+}
+
+type SettlementTransfer struct {
+	From   primitive.ObjectID
+	To     primitive.ObjectID
+	Amount int64
+}
+
+type settlementEntry struct {
+	User   primitive.ObjectID
+	Amount int64
+}
+
+func SettlementCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func SettlementAmount(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// SettlementSplitEqually divides the amount between the users. Cents that do
+// not divide evenly go to the users with the lowest ids, so every request
+// sees the same split.
+func SettlementSplitEqually(amount int64, users []primitive.ObjectID) map[primitive.ObjectID]int64 {
+	shares := map[primitive.ObjectID]int64{}
+	if len(users) == 0 {
+		return shares
+	}
+
+	sorted := append([]primitive.ObjectID{}, users...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Hex() < sorted[j].Hex() })
+
+	share := amount / int64(len(sorted))
+	remainder := amount % int64(len(sorted))
+	for k, v := range sorted {
+		shares[v] += share
+		if int64(k) < remainder {
+			shares[v]++
+		}
+	}
+	return shares
+}
+
+// SettlementSplit divides the amount between the beneficiaries according to
+// the split type. Percentages and share weights are rounded to cents with the
+// largest remainder method, so the shares always add up to the amount.
+func SettlementSplit(amount int64, splitType string, weights []SettlementWeight) map[primitive.ObjectID]int64 {
+	switch splitType {
+	case SPLIT_TYPE_EXACT:
+		shares := map[primitive.ObjectID]int64{}
+		for _, v := range weights {
+			shares[v.User] += SettlementCents(v.Value)
+		}
+		return shares
+	case SPLIT_TYPE_PERCENTAGE, SPLIT_TYPE_SHARES:
+		return settlementSplitWeighted(amount, weights)
+	}
+
+	var users []primitive.ObjectID
+	for _, v := range weights {
+		users = append(users, v.User)
+	}
+	return SettlementSplitEqually(amount, users)
+}
+
+func settlementSplitWeighted(amount int64, weights []SettlementWeight) map[primitive.ObjectID]int64 {
+	shares := map[primitive.ObjectID]int64{}
+	var total float64
+	for _, v := range weights {
+		total += v.Value
+	}
+	if total <= 0 {
+		return shares
+	}
+
+	type remainder struct {
+		User     primitive.ObjectID
+		Fraction float64
+	}
+	var remainders []remainder
+	var assigned int64
+	for _, v := range weights {
+		exact := float64(amount) * v.Value / total
+		share := int64(math.Floor(exact))
+		shares[v.User] += share
+		assigned += share
+		remainders = append(remainders, remainder{User: v.User, Fraction: exact - float64(share)})
+	}
+
+	sort.Slice(remainders, func(i, j int) bool {
+		if remainders[i].Fraction != remainders[j].Fraction {
+			return remainders[i].Fraction > remainders[j].Fraction
+		}
+		return remainders[i].User.Hex() < remainders[j].User.Hex()
+	})
+	for k := 0; assigned < amount && len(remainders) > 0; k++ {
+		shares[remainders[k%len(remainders)].User]++
+		assigned++
+	}
+	return shares
+}
+
+// SettlementSplitValidate checks that the split values add up: percentages
+// to 100, exact amounts to the amount, and share weights to more than zero.
+func SettlementSplitValidate(amount float64, splitType string, weights []SettlementWeight) error {
+	if len(weights) == 0 {
+		return errors.New("at least one beneficiary is required")
+	}
+
+	seen := map[primitive.ObjectID]bool{}
+	var total float64
+	var totalCents int64
+	for _, v := range weights {
+		if seen[v.User] {
+			return errors.New("beneficiaries can only be listed once")
+		}
+		seen[v.User] = true
+		if v.Value < 0 {
+			return errors.New("split values cannot be negative")
+		}
+		total += v.Value
+		totalCents += SettlementCents(v.Value)
+	}
+
+	switch splitType {
+	case SPLIT_TYPE_PERCENTAGE:
+		if math.Abs(total-100) > 0.01 {
+			return errors.New("percentages add up to " + strconv.FormatFloat(total, 'f', -1, 64) + " instead of 100")
+		}
+	case SPLIT_TYPE_SHARES:
+		if total <= 0 {
+			return errors.New("share weights must add up to more than 0")
+		}
+	case SPLIT_TYPE_EXACT:
+		if totalCents != SettlementCents(amount) {
+			return errors.New("exact amounts add up to " + strconv.FormatFloat(SettlementAmount(totalCents), 'f', -1, 64) + " instead of " + strconv.FormatFloat(amount, 'f', -1, 64))
+		}
+	}
+	return nil
+}
+
+// SettlementTransfers returns the transfers that bring every balance to zero.
+// A positive balance is owed money, a negative one owes money. Debts that
+// exactly match a credit are settled directly first, then the largest debtor
+// pays the largest creditor, which needs at most one transfer fewer than
+// there are people with a balance.
+func SettlementTransfers(balances map[primitive.ObjectID]int64) []SettlementTransfer {
+	var debtors, creditors []settlementEntry
+	for k, v := range balances {
+		if v < 0 {
+			debtors = append(debtors, settlementEntry{User: k, Amount: -v})
+		} else if v > 0 {
+			creditors = append(creditors, settlementEntry{User: k, Amount: v})
+		}
+	}
+	settlementSort(debtors)
+	settlementSort(creditors)
+
+	transfers := []SettlementTransfer{}
+	for i := range debtors {
+		for j := range creditors {
+			if debtors[i].Amount > 0 && debtors[i].Amount == creditors[j].Amount {
+				transfers = append(transfers, SettlementTransfer{From: debtors[i].User, To: creditors[j].User, Amount: debtors[i].Amount})
+				debtors[i].Amount = 0
+				creditors[j].Amount = 0
+				break
+			}
+		}
+	}
+
+	for {
+		settlementSort(debtors)
+		settlementSort(creditors)
+		if len(debtors) == 0 || len(creditors) == 0 || debtors[0].Amount == 0 || creditors[0].Amount == 0 {
+			break
+		}
+
+		amount := min(debtors[0].Amount, creditors[0].Amount)
+		transfers = append(transfers, SettlementTransfer{From: debtors[0].User, To: creditors[0].User, Amount: amount})
+		debtors[0].Amount -= amount
+		creditors[0].Amount -= amount
+	}
+	return transfers
+}
+
+func settlementSort(entries []settlementEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Amount != entries[j].Amount {
+			return entries[i].Amount > entries[j].Amount
+		}
+		return entries[i].User.Hex() < entries[j].User.Hex()
+	})
+}
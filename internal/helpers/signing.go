@@ -2,8 +2,10 @@ package helpers
 
 import (
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha1"
 	"encoding/base64"
+	"encoding/hex"
 )
 
 func GetSignature(input string, key string) string {
@@ -12,3 +14,13 @@ func GetSignature(input string, key string) string {
 	h.Write([]byte(input))
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
+
+// RandomToken returns a hex encoded, cryptographically random token of size
+// bytes, suitable for secrets embedded in URLs.
+func RandomToken(size int) (string, error) {
+	token := make([]byte, size)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,249 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TRACK_FORMAT_GPX = "GPX"
+	TRACK_FORMAT_KML = "KML"
+
+	// trackElevationThreshold ignores elevation changes smaller than this
+	// many metres, so GPS noise does not inflate the ascent and descent.
+	trackElevationThreshold = 3.0
+	// trackMovingSpeed is the speed in m/s under which a timed segment is
+	// counted as a stop.
+	trackMovingSpeed = 0.3
+	// Naismith's rule: 5 km/h on the flat plus one hour per 600 m of ascent.
+	trackWalkingSpeed  = 5000.0 / 3600
+	trackClimbingSpeed = 600.0 / 3600
+)
+
+type TrackPoint struct {
+	Lat    float64
+	Lng    float64
+	Ele    float64
+	HasEle bool
+	Time   time.Time
+}
+
+type TrackStatistics struct {
+	Distance     float64
+	Ascent       float64
+	Descent      float64
+	MaxElevation float64
+	MovingTime   float64
+}
+
+// TrackParse reads the points of a GPX or KML document, returning the
+// detected format. GPX track and route points are read; for KML, LineString
+// coordinates and gx:Track coordinates are read.
+func TrackParse(data []byte) (string, []TrackPoint, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var format string
+	var points []TrackPoint
+	var whens []time.Time
+	var stack []string
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return format, nil, errors.New("invalid track file: " + err.Error())
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			name := element.Name.Local
+			if format == "" {
+				switch strings.ToLower(name) {
+				case "gpx":
+					format = TRACK_FORMAT_GPX
+				case "kml":
+					format = TRACK_FORMAT_KML
+				default:
+					return format, nil, errors.New("unsupported track file, upload a GPX or KML file")
+				}
+			}
+
+			if format == TRACK_FORMAT_GPX && (name == "trkpt" || name == "rtept") {
+				point, err := trackGpxPoint(decoder, element)
+				if err != nil {
+					return format, nil, err
+				}
+				points = append(points, point)
+				continue
+			}
+			stack = append(stack, name)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if format != TRACK_FORMAT_KML || len(stack) == 0 {
+				continue
+			}
+			name := stack[len(stack)-1]
+			parent := ""
+			if len(stack) > 1 {
+				parent = stack[len(stack)-2]
+			}
+
+			switch {
+			case name == "coordinates" && parent == "LineString":
+				for _, v := range strings.Fields(string(element)) {
+					point, ok := trackKmlPoint(strings.Split(v, ","))
+					if ok {
+						points = append(points, point)
+					}
+				}
+			case name == "coord" && parent == "Track":
+				point, ok := trackKmlPoint(strings.Fields(string(element)))
+				if ok {
+					points = append(points, point)
+				}
+			case name == "when" && parent == "Track":
+				when, err := time.Parse(time.RFC3339, strings.TrimSpace(string(element)))
+				if err == nil {
+					whens = append(whens, when)
+				}
+			}
+		}
+	}
+
+	if format == "" {
+		return format, nil, errors.New("unsupported track file, upload a GPX or KML file")
+	}
+	if len(whens) == len(points) {
+		for k := range points {
+			points[k].Time = whens[k]
+		}
+	}
+	if len(points) < 2 {
+		return format, nil, errors.New("the track must contain at least two points")
+	}
+	return format, points, nil
+}
+
+func trackGpxPoint(decoder *xml.Decoder, element xml.StartElement) (TrackPoint, error) {
+	var point TrackPoint
+	var value struct {
+		Lat  float64  `xml:"lat,attr"`
+		Lon  float64  `xml:"lon,attr"`
+		Ele  *float64 `xml:"ele"`
+		Time string   `xml:"time"`
+	}
+	err := decoder.DecodeElement(&value, &element)
+	if err != nil {
+		return point, errors.New("invalid track point: " + err.Error())
+	}
+
+	point.Lat = value.Lat
+	point.Lng = value.Lon
+	if value.Ele != nil {
+		point.Ele = *value.Ele
+		point.HasEle = true
+	}
+	if value.Time != "" {
+		point.Time, _ = time.Parse(time.RFC3339, strings.TrimSpace(value.Time))
+	}
+	return point, nil
+}
+
+// trackKmlPoint parses a "lng,lat[,ele]" tuple, already split.
+func trackKmlPoint(values []string) (TrackPoint, bool) {
+	var point TrackPoint
+	if len(values) < 2 {
+		return point, false
+	}
+	lng, lngErr := strconv.ParseFloat(values[0], 64)
+	lat, latErr := strconv.ParseFloat(values[1], 64)
+	if lngErr != nil || latErr != nil {
+		return point, false
+	}
+	point.Lat = lat
+	point.Lng = lng
+	if len(values) > 2 {
+		ele, err := strconv.ParseFloat(values[2], 64)
+		if err == nil {
+			point.Ele = ele
+			point.HasEle = true
+		}
+	}
+	return point, true
+}
+
+// TrackStatistic computes the distance and elevation statistics of a track,
+// in metres and seconds. The moving time is measured from the timestamps when
+// every point has one, and estimated with Naismith's rule otherwise.
+func TrackStatistic(points []TrackPoint) TrackStatistics {
+	var stats TrackStatistics
+	timed := true
+	hasEle := false
+	var reference float64
+
+	for k, v := range points {
+		if v.Time.IsZero() {
+			timed = false
+		}
+		if v.HasEle {
+			if !hasEle {
+				reference = v.Ele
+				stats.MaxElevation = v.Ele
+				hasEle = true
+			}
+			stats.MaxElevation = math.Max(stats.MaxElevation, v.Ele)
+
+			diff := v.Ele - reference
+			if diff >= trackElevationThreshold {
+				stats.Ascent += diff
+				reference = v.Ele
+			} else if diff <= -trackElevationThreshold {
+				stats.Descent -= diff
+				reference = v.Ele
+			}
+		}
+		if k == 0 {
+			continue
+		}
+
+		previous := points[k-1]
+		distance := Haversine(previous.Lat, previous.Lng, v.Lat, v.Lng) * 1000
+		stats.Distance += distance
+
+		if timed {
+			seconds := v.Time.Sub(previous.Time).Seconds()
+			if seconds > 0 && distance/seconds >= trackMovingSpeed {
+				stats.MovingTime += seconds
+			}
+		}
+	}
+
+	if !timed {
+		stats.MovingTime = stats.Distance/trackWalkingSpeed + stats.Ascent/trackClimbingSpeed
+	}
+	return stats
+}
+
+// TrackCoordinates returns the points as GeoJSON positions, [lng, lat] or
+// [lng, lat, ele].
+func TrackCoordinates(points []TrackPoint) [][]float64 {
+	coordinates := make([][]float64, 0, len(points))
+	for _, v := range points {
+		if v.HasEle {
+			coordinates = append(coordinates, []float64{v.Lng, v.Lat, v.Ele})
+		} else {
+			coordinates = append(coordinates, []float64{v.Lng, v.Lat})
+		}
+	}
+	return coordinates
+}
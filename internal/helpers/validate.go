@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"regexp"
 	"strconv"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/gabriel-vasile/mimetype"
@@ -163,3 +164,25 @@ func ValidateStringLength(stringTest string, stringLength int) (bool, string) {
 
 	return true, ""
 }
+
+// ValidateEmoji accepts a single emoji, including skin tone, flag and joined
+// sequences, and rejects text.
+func ValidateEmoji(emoji string) bool {
+	if emoji == "" || utf8.RuneCountInString(emoji) > 10 {
+		return false
+	}
+
+	hasSymbol := false
+	for _, r := range emoji {
+		switch {
+		case r < utf8.RuneSelf:
+			return false
+		case unicode.Is(unicode.So, r):
+			hasSymbol = true
+		case unicode.In(r, unicode.Sk, unicode.Mn, unicode.Me, unicode.Cf):
+		default:
+			return false
+		}
+	}
+	return hasSymbol
+}
@@ -0,0 +1,139 @@
+package helpers
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XlsxSheet is a worksheet of an XLSX export. Cells are written as numbers
+// when they are float64 or int, and as text otherwise.
+type XlsxSheet struct {
+	Name string
+	Rows [][]interface{}
+}
+
+// XlsxWrite writes a minimal Office Open XML workbook with one worksheet per
+// sheet. Strings are stored inline, so no shared string table or styles are
+// needed for spreadsheet applications to open it.
+func XlsxWrite(w io.Writer, sheets []XlsxSheet) error {
+	archive := zip.NewWriter(w)
+
+	var overrides, workbookSheets, relationships strings.Builder
+	for k, v := range sheets {
+		id := strconv.Itoa(k + 1)
+		overrides.WriteString(`<Override PartName="/xl/worksheets/sheet` + id + `.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`)
+		workbookSheets.WriteString(`<sheet name="` + xlsxEscape(xlsxSheetName(v.Name)) + `" sheetId="` + id + `" r:id="rId` + id + `"/>`)
+		relationships.WriteString(`<Relationship Id="rId` + id + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet` + id + `.xml"/>`)
+	}
+
+	files := []struct {
+		Name    string
+		Content string
+	}{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			overrides.String() + `</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + workbookSheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			relationships.String() + `</Relationships>`},
+	}
+	for k, v := range sheets {
+		files = append(files, struct {
+			Name    string
+			Content string
+		}{"xl/worksheets/sheet" + strconv.Itoa(k+1) + ".xml", xlsxWorksheet(v.Rows)})
+	}
+
+	for _, v := range files {
+		file, err := archive.Create(v.Name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(file, v.Content)
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func xlsxWorksheet(rows [][]interface{}) string {
+	var sheet strings.Builder
+	sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		rowId := strconv.Itoa(r + 1)
+		sheet.WriteString(`<row r="` + rowId + `">`)
+		for k, v := range row {
+			ref := xlsxColumn(k) + rowId
+			switch value := v.(type) {
+			case float64:
+				sheet.WriteString(`<c r="` + ref + `"><v>` + strconv.FormatFloat(value, 'f', -1, 64) + `</v></c>`)
+			case int:
+				sheet.WriteString(`<c r="` + ref + `"><v>` + strconv.Itoa(value) + `</v></c>`)
+			case nil:
+			default:
+				sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">` + xlsxEscape(XlsxText(value)) + `</t></is></c>`)
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+	return sheet.String()
+}
+
+// XlsxText formats a cell the way it is written to text exports.
+func XlsxText(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(value)
+	case interface{ String() string }:
+		return value.String()
+	}
+	return ""
+}
+
+// xlsxColumn returns the column letters of a zero based index: A, B, ... AA.
+func xlsxColumn(index int) string {
+	column := ""
+	for index >= 0 {
+		column = string(rune('A'+index%26)) + column
+		index = index/26 - 1
+	}
+	return column
+}
+
+// xlsxSheetName drops the characters Excel does not allow in sheet names and
+// keeps the 31 character limit.
+func xlsxSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, name)
+	if len([]rune(name)) > 31 {
+		name = string([]rune(name)[:31])
+	}
+	return name
+}
+
+func xlsxEscape(value string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(value))
+	return escaped.String()
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"oosa_rewild/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AuthAdminUserMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "AUTH-ADMIN-REWILDING01: Invalid user"})
+			c.Abort()
+			return
+		}
+
+		userDetail := user.(*models.Users)
+
+		if !userDetail.UsersIsAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"message": "AUTH-ADMIN-REWILDING02: Not an admin user"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"oosa_rewild/internal/auth"
 	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -46,6 +48,10 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if suspended(c, &user) {
+			return
+		}
+
 		c.Set("user", &user)
 		c.Next()
 	}
@@ -77,13 +83,25 @@ func CheckIfAuth(c *gin.Context) {
 }
 
 func ssoAuth(c *gin.Context) bool {
-	if _, ok := c.Get("user"); ok {
-		c.Next()
+	if user, ok := c.Get("user"); ok {
+		if !suspended(c, user.(*models.Users)) {
+			c.Next()
+		}
 		return true
 	}
 	return false
 }
 
+// suspended turns away suspended users from everything but reading.
+func suspended(c *gin.Context, user *models.Users) bool {
+	if c.Request.Method == http.MethodGet || !helpers.UserSuspended(*user) {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"message": "AUTH04-REWILDING: Your account is suspended until " + user.UsersSuspendedUntil.Time().Format(time.RFC3339)})
+	c.Abort()
+	return true
+}
+
 func ssoCheckIfAuth(c *gin.Context) bool {
 	if _, ok := c.Get("user"); ok {
 		return true
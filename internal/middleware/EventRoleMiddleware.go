@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventRoleMiddleware only lets users holding one of the roles in the event
+// identified by the :id route parameter through. It must run after
+// AuthMiddleware.
+func EventRoleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		eventId := helpers.StringToPrimitiveObjId(c.Param("id"))
+		checkEventRole(c, eventId, roles)
+	}
+}
+
+// EventApplicationRoleMiddleware guards approving or rejecting join requests
+// (?applied=true), where :id is the participant record rather than the event.
+func EventApplicationRoleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query("applied") != "true" {
+			return
+		}
+		var EventParticipants models.EventParticipants
+		participantId := helpers.StringToPrimitiveObjId(c.Param("id"))
+		err := config.DB.Collection("EventParticipants").FindOne(context.TODO(), bson.D{{Key: "_id", Value: participantId}}).Decode(&EventParticipants)
+		if err != nil {
+			helpers.ResultEmpty(c, err)
+			c.Abort()
+			return
+		}
+		checkEventRole(c, EventParticipants.EventParticipantsEvent, roles)
+	}
+}
+
+func checkEventRole(c *gin.Context, eventId primitive.ObjectID, roles []string) {
+	userDetail := helpers.GetAuthUser(c)
+	role := helpers.EventRole(eventId, userDetail.UsersId)
+	if !helpers.EventRoleIn(role, roles) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "ROLE01-EVENT: This action requires the role " + strings.Join(roles, " or ")})
+		c.Abort()
+		return
+	}
+	c.Set("event_role", role)
+}
+
+// EventVisibilityMiddleware hides events identified by the :id route
+// parameter from users who may not see them. It must run after
+// AuthMiddleware.
+func EventVisibilityMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var Events models.Events
+		eventId := helpers.StringToPrimitiveObjId(c.Param("id"))
+		err := config.DB.Collection("Events").FindOne(context.TODO(), bson.D{{Key: "_id", Value: eventId}}).Decode(&Events)
+		if err != nil {
+			helpers.ResultEmpty(c, err)
+			c.Abort()
+			return
+		}
+
+		userDetail := helpers.GetAuthUser(c)
+		if !helpers.EventVisible(Events, userDetail.UsersId) {
+			helpers.ResponseNotFound(c, "Event not found")
+			c.Abort()
+			return
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type CalendarTokens struct {
+	CalendarTokensId        primitive.ObjectID `bson:"_id,omitempty" json:"calendar_tokens_id"`
+	CalendarTokensUser      primitive.ObjectID `bson:"calendar_tokens_user,omitempty" json:"calendar_tokens_user"`
+	CalendarTokensToken     string             `bson:"calendar_tokens_token,omitempty" json:"calendar_tokens_token"`
+	CalendarTokensFeedUrl   string             `bson:"-" json:"calendar_tokens_feed_url"`
+	CalendarTokensCreatedAt primitive.DateTime `bson:"calendar_tokens_created_at,omitempty" json:"calendar_tokens_created_at"`
+}
@@ -3,14 +3,39 @@ package models
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type EventAccounting struct {
-	EventAccountingId            primitive.ObjectID `bson:"_id,omitempty" json:"event_accounting_id"`
-	EventAccountingEvent         primitive.ObjectID `bson:"event_accounting_event,omitempty" json:"event_accounting_event,omitempty"`
-	EventAccountingMessage       string             `bson:"event_accounting_message,omitempty" json:"event_accounting_message,omitempty"`
-	EventAccountingAmount        float64            `bson:"event_accounting_amount,omitempty" json:"event_accounting_amount,omitempty"`
-	EventAccountingPaidBy        primitive.ObjectID `bson:"event_accounting_paid_by,omitempty" json:"event_accounting_paid_by,omitempty"`
-	EventAccountingCreatedBy     primitive.ObjectID `bson:"event_accounting_created_by,omitempty" json:"event_accounting_created_by,omitempty"`
-	EventAccountingCreatedAt     primitive.DateTime `bson:"event_accounting_created_at,omitempty" json:"event_accounting_created_at,omitempty"`
-	EventAccountingUpdatedBy     primitive.ObjectID `bson:"event_accounting_updated_by,omitempty" json:"event_accounting_updated_by,omitempty"`
-	EventAccountingUpdatedAt     primitive.DateTime `bson:"event_accounting_updated_at,omitempty" json:"event_accounting_updated_at,omitempty"`
-	EventAccountingCreatedByUser *UsersAgg          `bson:"event_accounting_created_by_user,omitempty" json:"event_accounting_created_by_user,omitempty"`
+	EventAccountingId                 primitive.ObjectID        `bson:"_id,omitempty" json:"event_accounting_id"`
+	EventAccountingEvent              primitive.ObjectID        `bson:"event_accounting_event,omitempty" json:"event_accounting_event,omitempty"`
+	EventAccountingMessage            string                    `bson:"event_accounting_message,omitempty" json:"event_accounting_message,omitempty"`
+	EventAccountingCategory           string                    `bson:"event_accounting_category,omitempty" json:"event_accounting_category,omitempty"`
+	EventAccountingAmount             float64                   `bson:"event_accounting_amount,omitempty" json:"event_accounting_amount,omitempty"`
+	EventAccountingCurrency           string                    `bson:"event_accounting_currency,omitempty" json:"event_accounting_currency,omitempty"`
+	EventAccountingExchangeRate       float64                   `bson:"event_accounting_exchange_rate,omitempty" json:"event_accounting_exchange_rate,omitempty"`
+	EventAccountingExchangeRateSource string                    `bson:"event_accounting_exchange_rate_source,omitempty" json:"event_accounting_exchange_rate_source,omitempty"`
+	EventAccountingBaseAmount         float64                   `bson:"event_accounting_base_amount,omitempty" json:"event_accounting_base_amount,omitempty"`
+	EventAccountingPaidBy             primitive.ObjectID        `bson:"event_accounting_paid_by,omitempty" json:"event_accounting_paid_by,omitempty"`
+	EventAccountingSplitType          string                    `bson:"event_accounting_split_type" json:"event_accounting_split_type"`
+	EventAccountingSplits             []EventAccountingSplit    `bson:"event_accounting_splits" json:"event_accounting_splits"`
+	EventAccountingSplitAmounts       []EventAccountingSplit    `bson:"-" json:"event_accounting_split_amounts,omitempty"`
+	EventAccountingReceipts           []EventAccountingReceipts `bson:"event_accounting_receipts,omitempty" json:"event_accounting_receipts,omitempty"`
+	EventAccountingCreatedBy          primitive.ObjectID        `bson:"event_accounting_created_by,omitempty" json:"event_accounting_created_by,omitempty"`
+	EventAccountingCreatedAt          primitive.DateTime        `bson:"event_accounting_created_at,omitempty" json:"event_accounting_created_at,omitempty"`
+	EventAccountingUpdatedBy          primitive.ObjectID        `bson:"event_accounting_updated_by,omitempty" json:"event_accounting_updated_by,omitempty"`
+	EventAccountingUpdatedAt          primitive.DateTime        `bson:"event_accounting_updated_at,omitempty" json:"event_accounting_updated_at,omitempty"`
+	EventAccountingCreatedByUser      *UsersAgg                 `bson:"event_accounting_created_by_user,omitempty" json:"event_accounting_created_by_user,omitempty"`
+}
+
+// EventAccountingSplit is a beneficiary of an accounting record. Value is the
+// percentage, share weight or exact amount depending on the split type, and
+// is not used by equal splits.
+type EventAccountingSplit struct {
+	EventAccountingSplitUser  primitive.ObjectID `bson:"event_accounting_split_user" json:"event_accounting_split_user"`
+	EventAccountingSplitValue float64            `bson:"event_accounting_split_value" json:"event_accounting_split_value"`
+}
+
+// EventAccountingReceipts is a receipt photo attached to an accounting record.
+type EventAccountingReceipts struct {
+	EventAccountingReceiptsId        primitive.ObjectID `bson:"_id,omitempty" json:"event_accounting_receipts_id"`
+	EventAccountingReceiptsPath      string             `bson:"event_accounting_receipts_path,omitempty" json:"event_accounting_receipts_path"`
+	EventAccountingReceiptsCreatedBy primitive.ObjectID `bson:"event_accounting_receipts_created_by,omitempty" json:"event_accounting_receipts_created_by"`
+	EventAccountingReceiptsCreatedAt primitive.DateTime `bson:"event_accounting_receipts_created_at,omitempty" json:"event_accounting_receipts_created_at"`
 }
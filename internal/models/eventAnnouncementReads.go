@@ -0,0 +1,31 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// EventAnnouncementReads records when a user first read an announcement.
+type EventAnnouncementReads struct {
+	EventAnnouncementReadsId           primitive.ObjectID `bson:"_id,omitempty" json:"event_announcement_reads_id"`
+	EventAnnouncementReadsEvent        primitive.ObjectID `bson:"event_announcement_reads_event,omitempty" json:"event_announcement_reads_event"`
+	EventAnnouncementReadsAnnouncement primitive.ObjectID `bson:"event_announcement_reads_announcement,omitempty" json:"event_announcement_reads_announcement"`
+	EventAnnouncementReadsUser         primitive.ObjectID `bson:"event_announcement_reads_user,omitempty" json:"event_announcement_reads_user"`
+	EventAnnouncementReadsReadAt       primitive.DateTime `bson:"event_announcement_reads_read_at,omitempty" json:"event_announcement_reads_read_at"`
+}
+
+type EventAnnouncementReader struct {
+	User       primitive.ObjectID  `json:"user"`
+	UserDetail *UsersAgg           `json:"user_detail,omitempty"`
+	ReadAt     *primitive.DateTime `json:"read_at,omitempty"`
+}
+
+// EventAnnouncementReceipt tells the organizer who has read an announcement:
+// ReadCount of Total participants.
+type EventAnnouncementReceipt struct {
+	Announcement primitive.ObjectID        `json:"announcement"`
+	Category     string                    `json:"category"`
+	Message      string                    `json:"message"`
+	PublishedAt  primitive.DateTime        `json:"published_at"`
+	ReadCount    int                       `json:"read_count"`
+	Total        int                       `json:"total"`
+	Read         []EventAnnouncementReader `json:"read"`
+	Unread       []EventAnnouncementReader `json:"unread"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// EventBudgets is the estimated budget of an event, in the event currency.
+type EventBudgets struct {
+	EventBudgetsId               primitive.ObjectID `bson:"_id,omitempty" json:"event_budgets_id"`
+	EventBudgetsEvent            primitive.ObjectID `bson:"event_budgets_event,omitempty" json:"event_budgets_event"`
+	EventBudgetsItems            []EventBudgetsItem `bson:"event_budgets_items" json:"event_budgets_items"`
+	EventBudgetsDepositAmount    float64            `bson:"event_budgets_deposit_amount" json:"event_budgets_deposit_amount"`
+	EventBudgetsDepositCollector primitive.ObjectID `bson:"event_budgets_deposit_collector,omitempty" json:"event_budgets_deposit_collector"`
+	EventBudgetsCreatedBy        primitive.ObjectID `bson:"event_budgets_created_by,omitempty" json:"event_budgets_created_by"`
+	EventBudgetsCreatedAt        primitive.DateTime `bson:"event_budgets_created_at,omitempty" json:"event_budgets_created_at"`
+	EventBudgetsUpdatedBy        primitive.ObjectID `bson:"event_budgets_updated_by,omitempty" json:"event_budgets_updated_by,omitempty"`
+	EventBudgetsUpdatedAt        primitive.DateTime `bson:"event_budgets_updated_at,omitempty" json:"event_budgets_updated_at,omitempty"`
+}
+
+type EventBudgetsItem struct {
+	EventBudgetsItemCategory    string  `bson:"event_budgets_item_category" json:"event_budgets_item_category"`
+	EventBudgetsItemDescription string  `bson:"event_budgets_item_description,omitempty" json:"event_budgets_item_description,omitempty"`
+	EventBudgetsItemAmount      float64 `bson:"event_budgets_item_amount" json:"event_budgets_item_amount"`
+}
+
+// EventDeposits records a deposit paid by a participant to the collector.
+type EventDeposits struct {
+	EventDepositsId        primitive.ObjectID `bson:"_id,omitempty" json:"event_deposits_id"`
+	EventDepositsEvent     primitive.ObjectID `bson:"event_deposits_event,omitempty" json:"event_deposits_event"`
+	EventDepositsUser      primitive.ObjectID `bson:"event_deposits_user,omitempty" json:"event_deposits_user"`
+	EventDepositsCollector primitive.ObjectID `bson:"event_deposits_collector,omitempty" json:"event_deposits_collector"`
+	EventDepositsAmount    float64            `bson:"event_deposits_amount,omitempty" json:"event_deposits_amount"`
+	EventDepositsCreatedBy primitive.ObjectID `bson:"event_deposits_created_by,omitempty" json:"event_deposits_created_by"`
+	EventDepositsCreatedAt primitive.DateTime `bson:"event_deposits_created_at,omitempty" json:"event_deposits_created_at"`
+	EventDepositsUserAgg   *UsersAgg          `bson:"-" json:"event_deposits_user_agg,omitempty"`
+}
+
+type EventBudgetCategory struct {
+	Category  string  `json:"category"`
+	Estimated float64 `json:"estimated"`
+	Actual    float64 `json:"actual"`
+	Variance  float64 `json:"variance"`
+}
+
+type EventBudgetDeposit struct {
+	User        primitive.ObjectID `json:"user"`
+	UserDetail  *UsersAgg          `json:"user_detail,omitempty"`
+	Due         float64            `json:"due"`
+	Paid        float64            `json:"paid"`
+	Outstanding float64            `json:"outstanding"`
+}
+
+// EventBudget compares the budget with the accounting records. Variance is
+// the actual spend minus the estimate, so overspending is positive.
+type EventBudget struct {
+	Currency     string                `json:"currency"`
+	Budget       EventBudgets          `json:"budget"`
+	Categories   []EventBudgetCategory `json:"categories"`
+	Estimated    float64               `json:"estimated"`
+	Actual       float64               `json:"actual"`
+	Variance     float64               `json:"variance"`
+	PerPerson    float64               `json:"per_person"`
+	Deposits     []EventBudgetDeposit  `json:"deposits"`
+	DepositTotal float64               `json:"deposit_total"`
+	DepositPaid  float64               `json:"deposit_paid"`
+	Payments     []EventDeposits       `json:"payments"`
+}
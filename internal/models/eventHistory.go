@@ -0,0 +1,19 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type EventHistory struct {
+	EventHistoryId            primitive.ObjectID   `bson:"_id,omitempty" json:"event_history_id"`
+	EventHistoryEvent         primitive.ObjectID   `bson:"event_history_event,omitempty" json:"event_history_event"`
+	EventHistoryVersion       int                  `bson:"event_history_version,omitempty" json:"event_history_version"`
+	EventHistoryChanges       []EventHistoryChange `bson:"event_history_changes,omitempty" json:"event_history_changes"`
+	EventHistoryCreatedBy     primitive.ObjectID   `bson:"event_history_created_by,omitempty" json:"event_history_created_by"`
+	EventHistoryCreatedAt     primitive.DateTime   `bson:"event_history_created_at,omitempty" json:"event_history_created_at"`
+	EventHistoryCreatedByUser *UsersAgg            `bson:"event_history_created_by_user,omitempty" json:"event_history_created_by_user,omitempty"`
+}
+
+type EventHistoryChange struct {
+	Field string      `bson:"field" json:"field"`
+	From  interface{} `bson:"from" json:"from"`
+	To    interface{} `bson:"to" json:"to"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type EventInvitationLinks struct {
+	EventInvitationLinksId             primitive.ObjectID `bson:"_id,omitempty" json:"event_invitation_links_id"`
+	EventInvitationLinksEvent          primitive.ObjectID `bson:"event_invitation_links_event,omitempty" json:"event_invitation_links_event"`
+	EventInvitationLinksToken          string             `bson:"event_invitation_links_token,omitempty" json:"event_invitation_links_token"`
+	EventInvitationLinksBypassApproval bool               `bson:"event_invitation_links_bypass_approval" json:"event_invitation_links_bypass_approval"`
+	EventInvitationLinksMaxUses        int                `bson:"event_invitation_links_max_uses,omitempty" json:"event_invitation_links_max_uses"`
+	EventInvitationLinksUsageCount     int                `bson:"event_invitation_links_usage_count" json:"event_invitation_links_usage_count"`
+	EventInvitationLinksExpiresAt      primitive.DateTime `bson:"event_invitation_links_expires_at,omitempty" json:"event_invitation_links_expires_at,omitempty"`
+	EventInvitationLinksRevokedAt      primitive.DateTime `bson:"event_invitation_links_revoked_at,omitempty" json:"event_invitation_links_revoked_at,omitempty"`
+	EventInvitationLinksUrl            string             `bson:"-" json:"event_invitation_links_url,omitempty"`
+	EventInvitationLinksCreatedBy      primitive.ObjectID `bson:"event_invitation_links_created_by,omitempty" json:"event_invitation_links_created_by"`
+	EventInvitationLinksCreatedAt      primitive.DateTime `bson:"event_invitation_links_created_at,omitempty" json:"event_invitation_links_created_at"`
+}
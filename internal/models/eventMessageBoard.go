@@ -3,17 +3,57 @@ package models
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type EventMessageBoard struct {
-	EventMessageBoardId            primitive.ObjectID `bson:"_id,omitempty" json:"event_message_board_id"`
-	EventMessageBoardEvent         primitive.ObjectID `bson:"event_message_board_event,omitempty" json:"event_message_board_event"`
-	EventMessageBoardBaseMessage   string             `bson:"event_message_board_base_message,omitempty" json:"event_message_board_base_message,omitempty"`
-	EventMessageBoardStatus        int                `bson:"event_message_board_status,omitempty" json:"event_message_board_status,omitempty"`
-	EventMessageBoardCategory      string             `bson:"event_message_board_category,omitempty" json:"event_message_board_category,omitempty"`
-	EventMessageBoardAnnouncement  string             `bson:"event_message_board_announcement,omitempty" json:"event_message_board_announcement,omitempty"`
-	EventMessageBoardMessageId     primitive.ObjectID `bson:"event_message_board_message_id,omitempty" json:"event_message_board_message_id"`
-	EventMessageBoardCreatedBy     primitive.ObjectID `bson:"event_message_board_created_by,omitempty" json:"event_message_board_created_by"`
-	EventMessageBoardCreatedAt     primitive.DateTime `bson:"event_message_board_created_at,omitempty" json:"event_message_board_created_at"`
-	EventMessageBoardIsPinned      *int               `bson:"event_message_board_is_pinned,omitempty" json:"event_message_board_is_pinned"`
-	EventMessageBoardCreatedByUser *UsersAgg          `bson:"event_message_board_created_by_user,omitempty" json:"event_message_board_created_by_user,omitempty"`
+	EventMessageBoardId            primitive.ObjectID                 `bson:"_id,omitempty" json:"event_message_board_id"`
+	EventMessageBoardEvent         primitive.ObjectID                 `bson:"event_message_board_event,omitempty" json:"event_message_board_event"`
+	EventMessageBoardBaseMessage   string                             `bson:"event_message_board_base_message,omitempty" json:"event_message_board_base_message,omitempty"`
+	EventMessageBoardStatus        int                                `bson:"event_message_board_status,omitempty" json:"event_message_board_status,omitempty"`
+	EventMessageBoardCategory      string                             `bson:"event_message_board_category,omitempty" json:"event_message_board_category,omitempty"`
+	EventMessageBoardAnnouncement  string                             `bson:"event_message_board_announcement,omitempty" json:"event_message_board_announcement,omitempty"`
+	EventMessageBoardMessageId     primitive.ObjectID                 `bson:"event_message_board_message_id,omitempty" json:"event_message_board_message_id"`
+	EventMessageBoardCreatedBy     primitive.ObjectID                 `bson:"event_message_board_created_by,omitempty" json:"event_message_board_created_by"`
+	EventMessageBoardCreatedAt     primitive.DateTime                 `bson:"event_message_board_created_at,omitempty" json:"event_message_board_created_at"`
+	EventMessageBoardIsPinned      *int                               `bson:"event_message_board_is_pinned,omitempty" json:"event_message_board_is_pinned"`
+	EventMessageBoardCreatedByUser *UsersAgg                          `bson:"event_message_board_created_by_user,omitempty" json:"event_message_board_created_by_user,omitempty"`
+	EventMessageBoardParent        primitive.ObjectID                 `bson:"event_message_board_parent,omitempty" json:"event_message_board_parent,omitempty"`
+	EventMessageBoardReplyCount    int                                `bson:"event_message_board_reply_count,omitempty" json:"event_message_board_reply_count"`
+	EventMessageBoardLastActivity  primitive.DateTime                 `bson:"event_message_board_last_activity,omitempty" json:"event_message_board_last_activity,omitempty"`
+	EventMessageBoardReactions     []EventMessageBoardReactionSummary `bson:"-" json:"event_message_board_reactions,omitempty"`
+	EventMessageBoardMentions      []EventMention                     `bson:"event_message_board_mentions,omitempty" json:"event_message_board_mentions,omitempty"`
+	// Announcements can be published later and expire. Scheduled is set until
+	// the scheduler publishes the announcement.
+	EventMessageBoardPublishAt primitive.DateTime `bson:"event_message_board_publish_at,omitempty" json:"event_message_board_publish_at,omitempty"`
+	EventMessageBoardExpireAt  primitive.DateTime `bson:"event_message_board_expire_at,omitempty" json:"event_message_board_expire_at,omitempty"`
+	EventMessageBoardScheduled bool               `bson:"event_message_board_scheduled,omitempty" json:"event_message_board_scheduled,omitempty"`
+	// Set by a moderator; hidden messages are left out of every listing
+	EventMessageBoardHidden bool `bson:"event_message_board_hidden,omitempty" json:"event_message_board_hidden,omitempty"`
+}
+
+// EventMention is a participant mentioned with @username in a message. Offset
+// and Length locate the mention in the text, in characters.
+type EventMention struct {
+	EventMentionUser     primitive.ObjectID `bson:"event_mention_user" json:"event_mention_user"`
+	EventMentionUsername string             `bson:"event_mention_username" json:"event_mention_username"`
+	EventMentionOffset   int                `bson:"event_mention_offset" json:"event_mention_offset"`
+	EventMentionLength   int                `bson:"event_mention_length" json:"event_mention_length"`
+}
+
+// EventMessageBoardReactions is an emoji reaction of a user to a message.
+type EventMessageBoardReactions struct {
+	EventMessageBoardReactionsId        primitive.ObjectID `bson:"_id,omitempty" json:"event_message_board_reactions_id"`
+	EventMessageBoardReactionsEvent     primitive.ObjectID `bson:"event_message_board_reactions_event,omitempty" json:"event_message_board_reactions_event"`
+	EventMessageBoardReactionsMessage   primitive.ObjectID `bson:"event_message_board_reactions_message,omitempty" json:"event_message_board_reactions_message"`
+	EventMessageBoardReactionsUser      primitive.ObjectID `bson:"event_message_board_reactions_user,omitempty" json:"event_message_board_reactions_user"`
+	EventMessageBoardReactionsEmoji     string             `bson:"event_message_board_reactions_emoji,omitempty" json:"event_message_board_reactions_emoji"`
+	EventMessageBoardReactionsCreatedAt primitive.DateTime `bson:"event_message_board_reactions_created_at,omitempty" json:"event_message_board_reactions_created_at"`
+}
+
+// EventMessageBoardReactionSummary counts the users who reacted to a message
+// with the emoji. Reacted tells whether the current user is one of them.
+type EventMessageBoardReactionSummary struct {
+	Emoji   string               `json:"emoji"`
+	Count   int                  `json:"count"`
+	Users   []primitive.ObjectID `json:"users"`
+	Reacted bool                 `json:"reacted"`
 }
 type EventAnnouncement struct {
 	EventMessageBoardId            primitive.ObjectID `bson:"_id,omitempty" json:"event_announcement_id"`
@@ -27,4 +67,8 @@ type EventAnnouncement struct {
 	EventMessageBoardCreatedAt     primitive.DateTime `bson:"event_message_board_created_at,omitempty" json:"event_announcement_created_at"`
 	EventMessageBoardIsPinned      *int               `bson:"event_message_board_is_pinned,omitempty" json:"event_announcement_is_pinned"`
 	EventMessageBoardCreatedByUser *UsersAgg          `bson:"event_message_board_created_by_user,omitempty" json:"event_announcement_created_by_user,omitempty"`
+	EventMessageBoardMentions      []EventMention     `bson:"event_message_board_mentions,omitempty" json:"event_announcement_mentions,omitempty"`
+	EventMessageBoardPublishAt     primitive.DateTime `bson:"event_message_board_publish_at,omitempty" json:"event_announcement_publish_at,omitempty"`
+	EventMessageBoardExpireAt      primitive.DateTime `bson:"event_message_board_expire_at,omitempty" json:"event_announcement_expire_at,omitempty"`
+	EventMessageBoardScheduled     bool               `bson:"event_message_board_scheduled,omitempty" json:"event_announcement_scheduled,omitempty"`
 }
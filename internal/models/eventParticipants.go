@@ -8,13 +8,20 @@ type EventParticipants struct {
 	EventParticipantsUser                  primitive.ObjectID          `bson:"event_participants_user,omitempty" json:"event_participants_user"`
 	EventParticipantsStatus                int64                       `bson:"event_participants_status" json:"event_participants_status"`
 	EventParticipantsStatusLabel           string                      `bson:"event_participants_status_label,omitempty" json:"event_participants_status_label"`
+	EventParticipantsRole                  string                      `bson:"event_participants_role,omitempty" json:"event_participants_role,omitempty"`
 	EventParticipantsIsPaid                int64                       `bson:"event_participants_is_paid,omitempty" json:"event_participants_is_paid"`
 	EventParticipantsPaidAmount            float64                     `bson:"event_participants_paid_amount,omitempty" json:"event_participants_paid_amount"`
 	EventParticipantsPaidAt                string                      `bson:"event_participants_paid_at,omitempty" json:"event_participants_paid_at"`
 	EventParticipantsPaymentRequest        string                      `bson:"event_participants_payment_request,omitempty" json:"event_participants_payment_request"`
 	EventParticipantsPaymentResponse       string                      `bson:"event_participants_payment_response,omitempty" json:"event_participants_payment_response"`
+	EventParticipantsPaymentIntent         string                      `bson:"event_participants_payment_intent,omitempty" json:"event_participants_payment_intent,omitempty"`
+	EventParticipantsPaymentUrl            string                      `bson:"event_participants_payment_url,omitempty" json:"event_participants_payment_url,omitempty"`
+	EventParticipantsPaymentDeadline       primitive.DateTime          `bson:"event_participants_payment_deadline,omitempty" json:"event_participants_payment_deadline,omitempty"`
+	EventParticipantsRefundStatus          string                      `bson:"event_participants_refund_status,omitempty" json:"event_participants_refund_status,omitempty"`
 	EventParticipantsExperience            string                      `bson:"event_participants_experience,omitempty" json:"event_participants_experience"`
 	EventParticipantsRequestMessage        string                      `bson:"event_participants_request_message,omitempty" json:"event_participants_request_message"`
+	EventParticipantsInvitationLink        primitive.ObjectID          `bson:"event_participants_invitation_link,omitempty" json:"event_participants_invitation_link,omitempty"`
+	EventParticipantsWaitlistPosition      int                         `bson:"event_participants_waitlist_position,omitempty" json:"event_participants_waitlist_position,omitempty"`
 	EventParticipantsRandomCount           int                         `bson:"event_participants_random_count,omitempty" json:"event_participants_random_count"`
 	EventParticipantsPolaroidCount         int                         `bson:"event_participants_polaroid_count,omitempty" json:"event_participants_polaroid_count"`
 	EventParticipantsStarType              int                         `bson:"event_participants_star_type,omitempty" json:"event_participants_star_type"`
@@ -18,4 +18,31 @@ type EventPolaroids struct {
 	EventPolaroidsCreatedAt           primitive.DateTime `bson:"event_polaroids_created_at,omitempty" json:"event_polaroids_created_at"`
 	EventPolaroidsPhotoDate           primitive.DateTime `bson:"event_polaroids_photo_date,omitempty" json:"event_polaroids_photo_date"`
 	EventPolaroidsCreatedByUser       *UsersAgg          `bson:"event_polaroids_created_by_user,omitempty" json:"event_polaroids_created_by_user,omitempty"`
+	EventPolaroidsHidden              bool               `bson:"event_polaroids_hidden,omitempty" json:"event_polaroids_hidden,omitempty"`
+	EventPolaroidsHash                string             `bson:"event_polaroids_hash,omitempty" json:"-"`
+	EventPolaroidsHashChunks          []string           `bson:"event_polaroids_hash_chunks,omitempty" json:"-"`
+	EventPolaroidsFlags               []string           `bson:"event_polaroids_flags,omitempty" json:"event_polaroids_flags,omitempty"`
+	EventPolaroidsLikeCount           int                `bson:"-" json:"event_polaroids_like_count"`
+	EventPolaroidsCommentCount        int                `bson:"-" json:"event_polaroids_comment_count"`
+	EventPolaroidsLiked               bool               `bson:"-" json:"event_polaroids_liked"`
+}
+
+// EventPolaroidLikes is a participant's like of a polaroid.
+type EventPolaroidLikes struct {
+	EventPolaroidLikesId        primitive.ObjectID `bson:"_id,omitempty" json:"event_polaroid_likes_id"`
+	EventPolaroidLikesEvent     primitive.ObjectID `bson:"event_polaroid_likes_event,omitempty" json:"event_polaroid_likes_event"`
+	EventPolaroidLikesPolaroid  primitive.ObjectID `bson:"event_polaroid_likes_polaroid,omitempty" json:"event_polaroid_likes_polaroid"`
+	EventPolaroidLikesUser      primitive.ObjectID `bson:"event_polaroid_likes_user,omitempty" json:"event_polaroid_likes_user"`
+	EventPolaroidLikesCreatedAt primitive.DateTime `bson:"event_polaroid_likes_created_at,omitempty" json:"event_polaroid_likes_created_at"`
+}
+
+// EventPolaroidComments is a participant's short comment on a polaroid.
+type EventPolaroidComments struct {
+	EventPolaroidCommentsId            primitive.ObjectID `bson:"_id,omitempty" json:"event_polaroid_comments_id"`
+	EventPolaroidCommentsEvent         primitive.ObjectID `bson:"event_polaroid_comments_event,omitempty" json:"event_polaroid_comments_event"`
+	EventPolaroidCommentsPolaroid      primitive.ObjectID `bson:"event_polaroid_comments_polaroid,omitempty" json:"event_polaroid_comments_polaroid"`
+	EventPolaroidCommentsMessage       string             `bson:"event_polaroid_comments_message,omitempty" json:"event_polaroid_comments_message"`
+	EventPolaroidCommentsCreatedBy     primitive.ObjectID `bson:"event_polaroid_comments_created_by,omitempty" json:"event_polaroid_comments_created_by"`
+	EventPolaroidCommentsCreatedAt     primitive.DateTime `bson:"event_polaroid_comments_created_at,omitempty" json:"event_polaroid_comments_created_at"`
+	EventPolaroidCommentsCreatedByUser *UsersAgg          `bson:"event_polaroid_comments_created_by_user,omitempty" json:"event_polaroid_comments_created_by_user,omitempty"`
 }
@@ -0,0 +1,20 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type EventRoutes struct {
+	EventRoutesId        primitive.ObjectID `bson:"_id,omitempty" json:"event_routes_id"`
+	EventRoutesEvent     primitive.ObjectID `bson:"event_routes_event,omitempty" json:"event_routes_event"`
+	EventRoutesFormat    string             `bson:"event_routes_format,omitempty" json:"event_routes_format"`
+	EventRoutesFileName  string             `bson:"event_routes_file_name,omitempty" json:"event_routes_file_name"`
+	EventRoutesTrack     GeoJsonLineString  `bson:"event_routes_track" json:"event_routes_track"`
+	EventRoutesStatistic EventsRoute        `bson:"event_routes_statistic" json:"event_routes_statistic"`
+	EventRoutesCreatedBy primitive.ObjectID `bson:"event_routes_created_by,omitempty" json:"event_routes_created_by"`
+	EventRoutesCreatedAt primitive.DateTime `bson:"event_routes_created_at,omitempty" json:"event_routes_created_at"`
+}
+
+// GeoJsonLineString holds [lng, lat] or [lng, lat, ele] positions.
+type GeoJsonLineString struct {
+	Type        string      `bson:"type" json:"type"`
+	Coordinates [][]float64 `bson:"coordinates" json:"coordinates"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type EventSeries struct {
+	EventSeriesId        primitive.ObjectID `bson:"_id,omitempty" json:"event_series_id"`
+	EventSeriesTemplate  primitive.ObjectID `bson:"event_series_template,omitempty" json:"event_series_template"`
+	EventSeriesRule      string             `bson:"event_series_rule,omitempty" json:"event_series_rule"`
+	EventSeriesTimezone  string             `bson:"event_series_timezone,omitempty" json:"event_series_timezone"`
+	EventSeriesCreatedBy primitive.ObjectID `bson:"event_series_created_by,omitempty" json:"event_series_created_by"`
+	EventSeriesCreatedAt primitive.DateTime `bson:"event_series_created_at,omitempty" json:"event_series_created_at"`
+	EventSeriesUpdatedBy primitive.ObjectID `bson:"event_series_updated_by,omitempty" json:"event_series_updated_by,omitempty"`
+	EventSeriesUpdatedAt primitive.DateTime `bson:"event_series_updated_at,omitempty" json:"event_series_updated_at,omitempty"`
+	EventSeriesEvents    []Events           `bson:"event_series_events,omitempty" json:"event_series_events,omitempty"`
+}
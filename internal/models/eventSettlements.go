@@ -0,0 +1,45 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// EventSettlements records a transfer between two participants that has been
+// marked as settled.
+type EventSettlements struct {
+	EventSettlementsId        primitive.ObjectID `bson:"_id,omitempty" json:"event_settlements_id"`
+	EventSettlementsEvent     primitive.ObjectID `bson:"event_settlements_event,omitempty" json:"event_settlements_event"`
+	EventSettlementsFrom      primitive.ObjectID `bson:"event_settlements_from,omitempty" json:"event_settlements_from"`
+	EventSettlementsTo        primitive.ObjectID `bson:"event_settlements_to,omitempty" json:"event_settlements_to"`
+	EventSettlementsAmount    float64            `bson:"event_settlements_amount,omitempty" json:"event_settlements_amount"`
+	EventSettlementsCreatedBy primitive.ObjectID `bson:"event_settlements_created_by,omitempty" json:"event_settlements_created_by"`
+	EventSettlementsCreatedAt primitive.DateTime `bson:"event_settlements_created_at,omitempty" json:"event_settlements_created_at"`
+	EventSettlementsFromUser  *UsersAgg          `bson:"-" json:"event_settlements_from_user,omitempty"`
+	EventSettlementsToUser    *UsersAgg          `bson:"-" json:"event_settlements_to_user,omitempty"`
+}
+
+type EventSettlementBalance struct {
+	User       primitive.ObjectID `json:"user"`
+	UserDetail *UsersAgg          `json:"user_detail,omitempty"`
+	Paid       float64            `json:"paid"`
+	Share      float64            `json:"share"`
+	Settled    float64            `json:"settled"`
+	Deposit    float64            `json:"deposit"`
+	Balance    float64            `json:"balance"`
+}
+
+type EventSettlementTransfer struct {
+	From     primitive.ObjectID `json:"from"`
+	To       primitive.ObjectID `json:"to"`
+	Amount   float64            `json:"amount"`
+	FromUser *UsersAgg          `json:"from_user,omitempty"`
+	ToUser   *UsersAgg          `json:"to_user,omitempty"`
+}
+
+// EventSettlement amounts are in the event currency.
+type EventSettlement struct {
+	Currency  string                    `json:"currency"`
+	Total     float64                   `json:"total"`
+	Balances  []EventSettlementBalance  `json:"balances"`
+	Transfers []EventSettlementTransfer `json:"transfers"`
+	Settled   []EventSettlements        `json:"settled"`
+	Deposits  []EventDeposits           `json:"deposits"`
+}
@@ -21,10 +21,13 @@ type Events struct {
 	EventsParticipantLimit             *int                 `bson:"events_participant_limit,omitempty" json:"events_participant_limit"`
 	EventsPaymentRequired              int                  `bson:"events_payment_required,omitempty" json:"events_payment_required"`
 	EventsPaymentFee                   *float64             `bson:"events_payment_fee,omitempty" json:"events_payment_fee"`
+	EventsCurrency                     string               `bson:"events_currency,omitempty" json:"events_currency"`
 	EventsRequiresApproval             *int                 `bson:"events_requires_approval,omitempty" json:"events_requires_approval"`
 	EventsQuestionnaireLink            string               `bson:"events_questionnaire_link,omitempty" json:"events_questionnaire_link"`
+	EventsVisibility                   string               `bson:"events_visibility,omitempty" json:"events_visibility"`
 	EventsLat                          float64              `bson:"events_lat,omitempty" json:"events_lat"`
 	EventsLng                          float64              `bson:"events_lng,omitempty" json:"events_lng"`
+	EventsLocation                     *GeoJsonPoint        `bson:"events_location,omitempty" json:"-"`
 	EventsCountryCode                  string               `bson:"events_country_code,omitempty" json:"events_country_code"`
 	EventsMeetingPointLat              float64              `bson:"events_meeting_point_lat,omitempty" json:"events_meeting_point_lat"`
 	EventsMeetingPointLng              float64              `bson:"events_meeting_point_lng,omitempty" json:"events_meeting_point_lng"`
@@ -33,12 +36,23 @@ type Events struct {
 	EventsStatisticDistance            float64              `bson:"events_statistic_distance,omitempty" json:"events_statistic_distance"`
 	EventsStatisticMemberCount         int                  `bson:"events_statistic_member_count,omitempty" json:"events_statistic_member_count"`
 	EventsPhoto                        string               `bson:"events_photo,omitempty" json:"events_photo"`
+	EventsSeries                       primitive.ObjectID   `bson:"events_series,omitempty" json:"events_series,omitempty"`
+	EventsSeriesIndex                  int                  `bson:"events_series_index,omitempty" json:"events_series_index,omitempty"`
+	EventsSeriesDetached               *bool                `bson:"events_series_detached,omitempty" json:"events_series_detached,omitempty"`
+	EventsRoute                        *EventsRoute         `bson:"events_route,omitempty" json:"events_route,omitempty"`
+	EventsCancelled                    *bool                `bson:"events_cancelled,omitempty" json:"events_cancelled,omitempty"`
+	EventsCancelledReason              string               `bson:"events_cancelled_reason,omitempty" json:"events_cancelled_reason,omitempty"`
+	EventsCancelledBy                  primitive.ObjectID   `bson:"events_cancelled_by,omitempty" json:"events_cancelled_by,omitempty"`
+	EventsCancelledAt                  primitive.DateTime   `bson:"events_cancelled_at,omitempty" json:"events_cancelled_at,omitempty"`
 	EventsDeleted                      *int                 `bson:"events_deleted,omitempty" json:"events_deleted,omitempty"`
 	EventsDeletedAt                    primitive.DateTime   `bson:"events_deleted_at,omitempty" json:"events_deleted_at,omitempty"`
 	EventsCreatedBy                    primitive.ObjectID   `bson:"events_created_by,omitempty" json:"events_created_by"`
 	EventsCreatedAt                    primitive.DateTime   `bson:"events_created_at,omitempty" json:"events_created_at"`
 	EventsUpdatedBy                    primitive.ObjectID   `bson:"events_updated_by,omitempty" json:"events_updated_by"`
 	EventsUpdatedAt                    primitive.DateTime   `bson:"events_updated_at,omitempty" json:"events_updated_at"`
+	EventsDistance                     *float64             `bson:"events_distance,omitempty" json:"events_distance,omitempty"`
+	EventsAcceptedCount                *int                 `bson:"events_accepted_count,omitempty" json:"events_accepted_count,omitempty"`
+	EventsSeatsRemaining               *int                 `bson:"events_seats_remaining,omitempty" json:"events_seats_remaining,omitempty"`
 	EventsParticipants                 *EventParticipantObj `bson:"events_participants,omitempty" json:"events_participants,omitempty"`
 	EventsCreatedByUser                *UsersAgg            `bson:"events_created_by_user,omitempty" json:"events_created_by_user,omitempty"`
 	EventsRewildingDetail              *RewildingDetail     `bson:"events_rewilding_detail,omitempty" json:"events_rewilding_detail,omitempty"`
@@ -55,6 +69,34 @@ type EventParticipantObj struct {
 	RemainNumber   int         `json:"remain_number"`
 }
 
+// GeoJsonPoint is stored as [lng, lat] for the 2dsphere index.
+type GeoJsonPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// EventsRoute summarises the uploaded track, in metres and seconds.
+type EventsRoute struct {
+	EventsRouteDistance     float64 `bson:"events_route_distance" json:"events_route_distance"`
+	EventsRouteAscent       float64 `bson:"events_route_ascent" json:"events_route_ascent"`
+	EventsRouteDescent      float64 `bson:"events_route_descent" json:"events_route_descent"`
+	EventsRouteMaxElevation float64 `bson:"events_route_max_elevation" json:"events_route_max_elevation"`
+	EventsRouteMovingTime   float64 `bson:"events_route_moving_time" json:"events_route_moving_time"`
+}
+
+type EventsFacetCount struct {
+	Value interface{} `bson:"_id" json:"value"`
+	Count int         `bson:"count" json:"count"`
+}
+
+type EventsFacets struct {
+	EventsType              []EventsFacetCount `bson:"events_type" json:"events_type"`
+	EventsCountryCode       []EventsFacetCount `bson:"events_country_code" json:"events_country_code"`
+	EventsPaymentRequired   []EventsFacetCount `bson:"events_payment_required" json:"events_payment_required"`
+	EventsRequiresApproval  []EventsFacetCount `bson:"events_requires_approval" json:"events_requires_approval"`
+	EventsSeatsAvailability []EventsFacetCount `bson:"events_seats_availability" json:"events_seats_availability"`
+}
+
 type EventsCountryCount struct {
 	EventsCountryCode  string `bson:"_id,omitempty" json:"events_country_code"`
 	EventsCountryCount int    `bson:"events_country_count,omitempty" json:"events_country_count"`
@@ -0,0 +1,45 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Reports is a user's report of a message board post, polaroid, rewilding
+// photo or rewilding entry. Reports stay open until a moderator acts on the
+// content.
+type Reports struct {
+	ReportsId          primitive.ObjectID  `bson:"_id,omitempty" json:"reports_id"`
+	ReportsType        string              `bson:"reports_type,omitempty" json:"reports_type"`
+	ReportsContent     primitive.ObjectID  `bson:"reports_content,omitempty" json:"reports_content"`
+	ReportsContentUser primitive.ObjectID  `bson:"reports_content_user,omitempty" json:"reports_content_user"`
+	ReportsReason      string              `bson:"reports_reason,omitempty" json:"reports_reason"`
+	ReportsMessage     string              `bson:"reports_message,omitempty" json:"reports_message,omitempty"`
+	ReportsStatus      string              `bson:"reports_status,omitempty" json:"reports_status"`
+	ReportsCreatedBy   primitive.ObjectID  `bson:"reports_created_by,omitempty" json:"reports_created_by"`
+	ReportsCreatedAt   primitive.DateTime  `bson:"reports_created_at,omitempty" json:"reports_created_at"`
+	ReportsResolvedBy  *primitive.ObjectID `bson:"reports_resolved_by,omitempty" json:"reports_resolved_by,omitempty"`
+	ReportsResolvedAt  *primitive.DateTime `bson:"reports_resolved_at,omitempty" json:"reports_resolved_at,omitempty"`
+	ReportsAction      string              `bson:"reports_action,omitempty" json:"reports_action,omitempty"`
+}
+
+// ModerationActions logs what the moderators did to content and users.
+type ModerationActions struct {
+	ModerationActionsId             primitive.ObjectID `bson:"_id,omitempty" json:"moderation_actions_id"`
+	ModerationActionsAction         string             `bson:"moderation_actions_action,omitempty" json:"moderation_actions_action"`
+	ModerationActionsType           string             `bson:"moderation_actions_type,omitempty" json:"moderation_actions_type,omitempty"`
+	ModerationActionsContent        primitive.ObjectID `bson:"moderation_actions_content,omitempty" json:"moderation_actions_content,omitempty"`
+	ModerationActionsUser           primitive.ObjectID `bson:"moderation_actions_user,omitempty" json:"moderation_actions_user"`
+	ModerationActionsMessage        string             `bson:"moderation_actions_message,omitempty" json:"moderation_actions_message,omitempty"`
+	ModerationActionsSuspendedUntil primitive.DateTime `bson:"moderation_actions_suspended_until,omitempty" json:"moderation_actions_suspended_until,omitempty"`
+	ModerationActionsCreatedBy      primitive.ObjectID `bson:"moderation_actions_created_by,omitempty" json:"moderation_actions_created_by"`
+	ModerationActionsCreatedAt      primitive.DateTime `bson:"moderation_actions_created_at,omitempty" json:"moderation_actions_created_at"`
+}
+
+// ModerationQueue gathers the reports of one piece of content.
+type ModerationQueue struct {
+	Type           string             `json:"moderation_queue_type"`
+	Content        primitive.ObjectID `json:"moderation_queue_content"`
+	ContentUser    primitive.ObjectID `json:"moderation_queue_content_user"`
+	ReportCount    int                `json:"moderation_queue_report_count"`
+	Reasons        map[string]int     `json:"moderation_queue_reasons"`
+	LastReportedAt primitive.DateTime `json:"moderation_queue_last_reported_at"`
+	Reports        []Reports          `json:"moderation_queue_reports"`
+}
@@ -26,11 +26,13 @@ type Rewilding struct {
 	RewildingDeletedBy         *primitive.ObjectID       `bson:"rewilding_deleted_by,omitempty" json:"rewilding_deleted_by,omitempty"`
 	RewildingDeletedAt         *primitive.DateTime       `bson:"rewilding_deleted_at,omitempty" json:"rewilding_deleted_at,omitempty"`
 	RewildingCreatedByUser     *UsersAgg                 `bson:"rewilding_created_by_user,omitempty" json:"rewilding_created_by_user,omitempty"`
+	RewildingHidden            bool                      `bson:"rewilding_hidden,omitempty" json:"rewilding_hidden,omitempty"`
 }
 
 type RewildingPhotos struct {
-	RewildingPhotosID   primitive.ObjectID `bson:"_id,omitempty" json:"rewilding_photos_id"`
-	RewildingPhotosPath string             `bson:"rewilding_photos_path,omitempty" json:"rewilding_photos_path,omitempty"`
+	RewildingPhotosID     primitive.ObjectID `bson:"_id,omitempty" json:"rewilding_photos_id"`
+	RewildingPhotosPath   string             `bson:"rewilding_photos_path,omitempty" json:"rewilding_photos_path,omitempty"`
+	RewildingPhotosHidden bool               `bson:"rewilding_photos_hidden,omitempty" json:"rewilding_photos_hidden,omitempty"`
 }
 
 type RewildingDetail struct {
@@ -31,6 +31,8 @@ type Users struct {
 	UsersFollowerCount                    int                `bson:"users_follower_count,omitempty" json:"users_follower_count"`
 	UsersFriendsCount                     int                `bson:"users_friends_count,omitempty" json:"users_friends_count"`
 	UsersFollowings                       *UserFollowings    `bson:"users_followings,omitempty" json:"users_followings"`
+	UsersIsAdmin                          bool               `bson:"users_is_admin,omitempty" json:"users_is_admin,omitempty"`
+	UsersSuspendedUntil                   primitive.DateTime `bson:"users_suspended_until,omitempty" json:"users_suspended_until,omitempty"`
 }
 
 type UsersAgg struct {
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"oosa_rewild/internal/config"
+	"oosa_rewild/pkg/repository"
 	"oosa_rewild/routes"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -19,6 +20,9 @@ var db *mongo.Database
 func main() {
 	config.InitialiseConfig()
 	db = config.ConnectDatabase()
+	config.EnsureIndexes()
+	go repository.EventAnnouncementSchedulerRepository{}.Run()
+	go repository.EventPaymentExpiryRepository{}.Run()
 
 	appPort := config.APP.AppPort
 	fmt.Println("Starting app on port: ", appPort)
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CalendarRepository serves iCalendar feeds. Calendar apps cannot send the
+// Authorization header, so feeds are authorised by a per-user secret token
+// passed as ?token= in the URL.
+type CalendarRepository struct{}
+
+// Read returns the user's calendar token, creating one on first use.
+func (r CalendarRepository) Read(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var CalendarTokens models.CalendarTokens
+	err := config.DB.Collection("CalendarTokens").FindOne(context.TODO(), bson.D{{Key: "calendar_tokens_user", Value: userDetail.UsersId}}).Decode(&CalendarTokens)
+	if err == mongo.ErrNoDocuments {
+		CalendarTokens, err = r.Generate(userDetail.UsersId)
+	}
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	CalendarTokens.CalendarTokensFeedUrl = r.FeedUrl(CalendarTokens.CalendarTokensToken)
+	c.JSON(http.StatusOK, CalendarTokens)
+}
+
+// Update replaces the user's calendar token. Subscriptions using the old
+// token stop working.
+func (r CalendarRepository) Update(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	CalendarTokens, err := r.Generate(userDetail.UsersId)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	CalendarTokens.CalendarTokensFeedUrl = r.FeedUrl(CalendarTokens.CalendarTokensToken)
+	c.JSON(http.StatusOK, CalendarTokens)
+}
+
+func (r CalendarRepository) Generate(userId primitive.ObjectID) (models.CalendarTokens, error) {
+	var CalendarTokens models.CalendarTokens
+	token, err := helpers.RandomToken(32)
+	if err != nil {
+		return CalendarTokens, err
+	}
+
+	filters := bson.D{{Key: "calendar_tokens_user", Value: userId}}
+	upd := bson.D{{Key: "$set", Value: bson.M{
+		"calendar_tokens_token":      token,
+		"calendar_tokens_created_at": primitive.NewDateTimeFromTime(time.Now()),
+	}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err = config.DB.Collection("CalendarTokens").FindOneAndUpdate(context.TODO(), filters, upd, opts).Decode(&CalendarTokens)
+	return CalendarTokens, err
+}
+
+func (r CalendarRepository) FeedUrl(token string) string {
+	return config.APP.BaseUrl + "my/event/calendar.ics?token=" + token
+}
+
+// Authenticate resolves the ?token= query to its user and stores it on the
+// context like the auth middleware does.
+func (r CalendarRepository) Authenticate(c *gin.Context) (models.Users, error) {
+	var User models.Users
+	var CalendarTokens models.CalendarTokens
+	token := c.Query("token")
+	if token == "" {
+		return User, errors.New("missing token")
+	}
+
+	err := config.DB.Collection("CalendarTokens").FindOne(context.TODO(), bson.D{{Key: "calendar_tokens_token", Value: token}}).Decode(&CalendarTokens)
+	if err != nil {
+		return User, errors.New("invalid token")
+	}
+
+	err = config.DB.Collection("Users").FindOne(context.TODO(), bson.D{{Key: "_id", Value: CalendarTokens.CalendarTokensUser}}).Decode(&User)
+	if err != nil {
+		return User, errors.New("invalid token")
+	}
+	c.Set("user", &User)
+	return User, nil
+}
+
+// Feed is the user's subscribable calendar of upcoming events they joined.
+func (r CalendarRepository) Feed(c *gin.Context) {
+	User, err := r.Authenticate(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "AUTH01-CALENDAR: " + err.Error()})
+		return
+	}
+
+	var Events []models.Events
+	err = UserEventRepository{}.GetEventByUserId(c, User.UsersId, &Events)
+	if err != nil {
+		return
+	}
+
+	var CalendarEvents []helpers.CalendarEvent
+	for _, v := range Events {
+		CalendarEvents = append(CalendarEvents, r.CalendarEvent(v))
+	}
+	r.Render(c, "OOSA - "+User.UsersName, CalendarEvents)
+}
+
+func (r CalendarRepository) EventFeed(c *gin.Context) {
+	_, err := r.Authenticate(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "AUTH01-CALENDAR: " + err.Error()})
+		return
+	}
+
+	var Event models.Events
+	err = EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	if Event.EventsDeleted != nil || !helpers.EventVisible(Event, helpers.GetAuthUser(c).UsersId) {
+		helpers.ResponseNotFound(c, "Event not found")
+		return
+	}
+	r.Render(c, Event.EventsName, []helpers.CalendarEvent{r.CalendarEvent(Event)})
+}
+
+func (r CalendarRepository) Render(c *gin.Context, name string, events []helpers.CalendarEvent) {
+	c.Header("Content-Disposition", "inline; filename=\"calendar.ics\"")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(helpers.CalendarRender(name, events)))
+}
+
+// CalendarEvent maps an event to a VEVENT, listing its schedule items in the
+// description.
+func (r CalendarRepository) CalendarEvent(Event models.Events) helpers.CalendarEvent {
+	location, err := time.LoadLocation(defaultEventTimezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	var EventSchedules []models.EventSchedules
+	filter := bson.D{{Key: "event_schedules_event", Value: Event.EventsId}}
+	opts := options.Find().SetSort(bson.D{{Key: "event_schedules_datetime", Value: 1}})
+	cursor, _ := config.DB.Collection("EventSchedules").Find(context.TODO(), filter, opts)
+	cursor.All(context.TODO(), &EventSchedules)
+
+	var description []string
+	for _, v := range EventSchedules {
+		description = append(description, v.EventSchedulesDatetime.Time().In(location).Format("2006-01-02 15:04")+" "+v.EventSchedulesDescription)
+	}
+
+	updatedAt := Event.EventsUpdatedAt
+	if updatedAt == 0 {
+		updatedAt = Event.EventsCreatedAt
+	}
+
+	CalendarEvent := helpers.CalendarEvent{
+		Uid:         Event.EventsId.Hex() + "@oosa_rewild",
+		Start:       Event.EventsDate.Time(),
+		Summary:     Event.EventsName,
+		Description: strings.Join(description, "\n"),
+		Location:    Event.EventsMeetingPointName,
+		Lat:         Event.EventsMeetingPointLat,
+		Lng:         Event.EventsMeetingPointLng,
+		UpdatedAt:   updatedAt.Time(),
+	}
+	if Event.EventsDateEnd != 0 {
+		CalendarEvent.End = Event.EventsDateEnd.Time()
+	}
+	return CalendarEvent
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CollaborativeLogPolaroidCommentRepository struct{}
+type CollaborativeLogPolaroidCommentRequest struct {
+	EventPolaroidCommentsMessage string `json:"event_polaroid_comments_message" validate:"required"`
+}
+
+// Retrieve lists the comments of the polaroid, the oldest first.
+func (r CollaborativeLogPolaroidCommentRepository) Retrieve(c *gin.Context) {
+	var Events models.Events
+	var EventPolaroids models.EventPolaroids
+	err := CollaborativeLogPolaroidRepository{}.ReadMember(c, &Events, &EventPolaroids)
+	if err != nil {
+		return
+	}
+
+	results := r.Aggregate(bson.M{"event_polaroid_comments_polaroid": EventPolaroids.EventPolaroidsId})
+	if len(results) == 0 {
+		helpers.ResponseNoData(c, "No Data")
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// Create comments on the polaroid and notifies its uploader.
+func (r CollaborativeLogPolaroidCommentRepository) Create(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var payload CollaborativeLogPolaroidCommentRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	match, errMessage := helpers.ValidateStringLength(payload.EventPolaroidCommentsMessage, r.Length())
+	if !match {
+		helpers.ResponseBadRequestError(c, "Comment exceeds the "+errMessage)
+		return
+	}
+
+	var Events models.Events
+	var EventPolaroids models.EventPolaroids
+	err := CollaborativeLogPolaroidRepository{}.ReadMember(c, &Events, &EventPolaroids)
+	if err != nil {
+		return
+	}
+
+	insert := models.EventPolaroidComments{
+		EventPolaroidCommentsEvent:     Events.EventsId,
+		EventPolaroidCommentsPolaroid:  EventPolaroids.EventPolaroidsId,
+		EventPolaroidCommentsMessage:   payload.EventPolaroidCommentsMessage,
+		EventPolaroidCommentsCreatedBy: userDetail.UsersId,
+		EventPolaroidCommentsCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+	result, err := config.DB.Collection("EventPolaroidComments").InsertOne(context.TODO(), insert)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	CollaborativeLogPolaroidRepository{}.NotifyUploader(c, helpers.NOTIFICATION_COLOG_PHOTO_COMMENTED, "{0}在{1}留言了你的拍立得", Events, EventPolaroids)
+
+	results := r.Aggregate(bson.M{"_id": result.InsertedID})
+	if len(results) == 0 {
+		helpers.ResponseNoData(c, "No Data")
+		return
+	}
+	c.JSON(http.StatusOK, results[0])
+}
+
+// Length is the longest comment allowed, 100 characters when
+// LENGTH_EVENT_POLAROID_COMMENT is not set.
+func (r CollaborativeLogPolaroidCommentRepository) Length() int {
+	if config.APP_LIMIT.LengthEventPolaroidComment <= 0 {
+		return 100
+	}
+	return int(config.APP_LIMIT.LengthEventPolaroidComment)
+}
+
+// Delete removes a comment. Commenters can delete their own comments and
+// organizers any comment of the event.
+func (r CollaborativeLogPolaroidCommentRepository) Delete(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Events models.Events
+	var EventPolaroids models.EventPolaroids
+	err := CollaborativeLogPolaroidRepository{}.ReadMember(c, &Events, &EventPolaroids)
+	if err != nil {
+		return
+	}
+
+	var EventPolaroidComments models.EventPolaroidComments
+	filter := bson.D{
+		{Key: "_id", Value: helpers.StringToPrimitiveObjId(c.Param("commentId"))},
+		{Key: "event_polaroid_comments_polaroid", Value: EventPolaroids.EventPolaroidsId},
+	}
+	err = config.DB.Collection("EventPolaroidComments").FindOne(context.TODO(), filter).Decode(&EventPolaroidComments)
+	if err != nil {
+		helpers.ResultEmpty(c, err)
+		return
+	}
+	if EventPolaroidComments.EventPolaroidCommentsCreatedBy != userDetail.UsersId && !(EventRepository{}).IsOrganizer(c, Events) {
+		helpers.ResponseBadRequestError(c, "Only the commenter or an organizer can delete this comment")
+		return
+	}
+
+	config.DB.Collection("EventPolaroidComments").DeleteOne(context.TODO(), filter)
+	helpers.ResultMessageSuccess(c, "Comment deleted")
+}
+
+// Aggregate finds the comments matching the filter with their authors.
+func (r CollaborativeLogPolaroidCommentRepository) Aggregate(match bson.M) []models.EventPolaroidComments {
+	var results []models.EventPolaroidComments
+	agg := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: match}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "event_polaroid_comments_created_at", Value: 1}}}},
+		bson.D{{
+			Key: "$lookup", Value: bson.M{
+				"from":         "Users",
+				"localField":   "event_polaroid_comments_created_by",
+				"foreignField": "_id",
+				"as":           "event_polaroid_comments_created_by_user",
+			},
+		}},
+		bson.D{{
+			Key: "$unwind", Value: "$event_polaroid_comments_created_by_user",
+		}},
+	}
+	cursor, err := config.DB.Collection("EventPolaroidComments").Aggregate(context.TODO(), agg)
+	if err != nil {
+		return results
+	}
+	cursor.All(context.TODO(), &results)
+	return results
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CollaborativeLogPolaroidLikeRepository struct{}
+
+// Create likes the polaroid. Liking it twice has no further effect, and the
+// uploader is only notified of the first like.
+func (r CollaborativeLogPolaroidLikeRepository) Create(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Events models.Events
+	var EventPolaroids models.EventPolaroids
+	err := CollaborativeLogPolaroidRepository{}.ReadMember(c, &Events, &EventPolaroids)
+	if err != nil {
+		return
+	}
+
+	filter := bson.D{
+		{Key: "event_polaroid_likes_polaroid", Value: EventPolaroids.EventPolaroidsId},
+		{Key: "event_polaroid_likes_user", Value: userDetail.UsersId},
+	}
+	upd := bson.D{{Key: "$setOnInsert", Value: models.EventPolaroidLikes{
+		EventPolaroidLikesEvent:     Events.EventsId,
+		EventPolaroidLikesPolaroid:  EventPolaroids.EventPolaroidsId,
+		EventPolaroidLikesUser:      userDetail.UsersId,
+		EventPolaroidLikesCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}}}
+	result, err := config.DB.Collection("EventPolaroidLikes").UpdateOne(context.TODO(), filter, upd, options.Update().SetUpsert(true))
+	// A concurrent like of the same user won the unique index
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	if err == nil && result.UpsertedCount > 0 {
+		CollaborativeLogPolaroidRepository{}.NotifyUploader(c, helpers.NOTIFICATION_COLOG_PHOTO_LIKED, "{0}在{1}按讚了你的拍立得", Events, EventPolaroids)
+	}
+
+	r.Response(c, EventPolaroids)
+}
+
+// Delete takes back the user's like of the polaroid.
+func (r CollaborativeLogPolaroidLikeRepository) Delete(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Events models.Events
+	var EventPolaroids models.EventPolaroids
+	err := CollaborativeLogPolaroidRepository{}.ReadMember(c, &Events, &EventPolaroids)
+	if err != nil {
+		return
+	}
+
+	filter := bson.D{
+		{Key: "event_polaroid_likes_polaroid", Value: EventPolaroids.EventPolaroidsId},
+		{Key: "event_polaroid_likes_user", Value: userDetail.UsersId},
+	}
+	result, _ := config.DB.Collection("EventPolaroidLikes").DeleteOne(context.TODO(), filter)
+	if result == nil || result.DeletedCount == 0 {
+		helpers.ResponseNotFound(c, "Like not found")
+		return
+	}
+
+	r.Response(c, EventPolaroids)
+}
+
+// Response returns the polaroid with its like and comment counts.
+func (r CollaborativeLogPolaroidLikeRepository) Response(c *gin.Context, EventPolaroids models.EventPolaroids) {
+	results := []models.EventPolaroids{EventPolaroids}
+	CollaborativeLogPolaroidRepository{}.FillCounts(c, results)
+	c.JSON(http.StatusOK, results[0])
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"oosa_rewild/internal/helpers"
 	"oosa_rewild/internal/models"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -18,6 +20,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type CollaborativeLogPolaroidRepository struct{}
@@ -25,6 +28,10 @@ type CollaborativeLogPolaroidRequest struct {
 	EventPolaroidsMessage string `form:"event_polaroids_message"`
 	EventPolaroidsTag     string `form:"event_polaroids_tag"`
 }
+type CollaborativeLogPolaroidUpdateRequest struct {
+	EventPolaroidsMessage string `json:"event_polaroids_message" form:"event_polaroids_message"`
+	EventPolaroidsTag     string `json:"event_polaroids_tag" form:"event_polaroids_tag"`
+}
 
 func (r CollaborativeLogPolaroidRepository) Retrieve(c *gin.Context) {
 	var Events models.Events
@@ -37,7 +44,8 @@ func (r CollaborativeLogPolaroidRepository) Retrieve(c *gin.Context) {
 	agg := mongo.Pipeline{
 		bson.D{{
 			Key: "$match", Value: bson.M{
-				"event_polaroids_event": Events.EventsId,
+				"event_polaroids_event":  Events.EventsId,
+				"event_polaroids_hidden": bson.M{"$ne": true},
 			},
 		}},
 		bson.D{{
@@ -68,6 +76,7 @@ func (r CollaborativeLogPolaroidRepository) Retrieve(c *gin.Context) {
 			EventPolaroids[i].EventPolaroidsPhotoDate = Events.EventsDate
 		}
 	}
+	r.FillCounts(c, EventPolaroids)
 	c.JSON(http.StatusOK, EventPolaroids)
 }
 
@@ -112,11 +121,9 @@ func (r CollaborativeLogPolaroidRepository) Create(c *gin.Context) {
 		}
 	}
 
-	file, fileErr := c.FormFile("event_polaroids_file")
-	if fileErr != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"message": "No file is received",
-		})
+	// Only the formats the duplicate check can decode are accepted
+	file, err := helpers.ValidatePhotoRequest(c, "event_polaroids_file", true)
+	if file == nil || err != nil {
 		return
 	}
 
@@ -140,6 +147,7 @@ func (r CollaborativeLogPolaroidRepository) Create(c *gin.Context) {
 	var photoTime time.Time
 	x, err := exif.Decode(reader)
 	if err != nil {
+		x = nil
 		tm = time.Now()
 		photoTime = Events.EventsDate.Time()
 		//helpers.ResponseBadRequestError(c, "EXIF: "+err.Error())
@@ -149,6 +157,17 @@ func (r CollaborativeLogPolaroidRepository) Create(c *gin.Context) {
 		photoTime = tm
 	}
 
+	flags := helpers.PhotoExifFlags(x, Events.EventsDate.Time(), Events.EventsDateEnd.Time(), r.ExifTolerance())
+	hash, err := helpers.PhotoHash(b)
+	if err != nil {
+		helpers.ResponseBadRequestError(c, "Unable to check this photo: "+err.Error())
+		return
+	}
+	if r.IsDuplicate(hash) {
+		helpers.ResponseBadRequestError(c, "This photo has already been uploaded")
+		return
+	}
+
 	fileName := ""
 	if !isCheck {
 		cloudflare := CloudflareRepository{}
@@ -161,15 +180,20 @@ func (r CollaborativeLogPolaroidRepository) Create(c *gin.Context) {
 	}
 
 	insert := models.EventPolaroids{
-		EventPolaroidsEvent:     Events.EventsId,
-		EventPolaroidsUrl:       fileName,
-		EventPolaroidsLat:       lat,
-		EventPolaroidsLng:       lng,
-		EventPolaroidsMessage:   payload.EventPolaroidsMessage,
-		EventPolaroidsTag:       payload.EventPolaroidsTag,
-		EventPolaroidsCreatedBy: userDetail.UsersId,
-		EventPolaroidsCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
-		EventPolaroidsPhotoDate: primitive.NewDateTimeFromTime(photoTime),
+		EventPolaroidsEvent:      Events.EventsId,
+		EventPolaroidsUrl:        fileName,
+		EventPolaroidsLat:        lat,
+		EventPolaroidsLng:        lng,
+		EventPolaroidsMessage:    payload.EventPolaroidsMessage,
+		EventPolaroidsTag:        payload.EventPolaroidsTag,
+		EventPolaroidsCreatedBy:  userDetail.UsersId,
+		EventPolaroidsCreatedAt:  primitive.NewDateTimeFromTime(time.Now()),
+		EventPolaroidsPhotoDate:  primitive.NewDateTimeFromTime(photoTime),
+		EventPolaroidsHash:       helpers.PhotoHashString(hash),
+		EventPolaroidsHashChunks: helpers.PhotoHashChunks(hash),
+	}
+	if len(flags) > 0 {
+		insert.EventPolaroidsFlags = flags
 	}
 
 	radius := helpers.Haversine(lat, lng, Events.EventsLat, Events.EventsLng) * 1000
@@ -199,6 +223,11 @@ func (r CollaborativeLogPolaroidRepository) Create(c *gin.Context) {
 		eligibleAchievement = true
 	}
 
+	// Flagged photos only count for 2 stars until a moderator approves them
+	if len(flags) > 0 {
+		starType = 2
+	}
+
 	insert.EventPolaroidsIsEventPeriod = &isEventPeriod
 	insert.EventPolaroidsRadiusFromEvent = &radius
 	insert.EventPolaroidsAchievementEligible = &eligibleAchievement
@@ -214,6 +243,10 @@ func (r CollaborativeLogPolaroidRepository) Create(c *gin.Context) {
 		r.CountUploadPolaroidByParticipant(c, Events.EventsId, userDetail.UsersId)
 		var EventPolaroids models.EventPolaroids
 		config.DB.Collection("EventPolaroids").FindOne(context.TODO(), bson.D{{Key: "_id", Value: result.InsertedID}}).Decode(&EventPolaroids)
+		helpers.EventStreamPublish(Events.EventsId, helpers.EVENT_STREAM_POLAROID_CREATED, EventPolaroids)
+		if len(flags) > 0 {
+			ModerationRepository{}.Queue(helpers.REPORT_TYPE_POLAROID, EventPolaroids.EventPolaroidsId, userDetail.UsersId, helpers.REPORT_REASON_SUSPICIOUS_PHOTO, strings.Join(flags, ","))
+		}
 
 		helpers.BadgeAllocate(c, "P1", helpers.BADGE_EVENTS, Events.EventsId, primitive.NilObjectID)
 		r.EventAchievementEligibility(c, Events)
@@ -223,6 +256,326 @@ func (r CollaborativeLogPolaroidRepository) Create(c *gin.Context) {
 	}
 }
 
+// ExifTolerance is how far from the event period the EXIF time of a photo
+// may be before the photo is flagged.
+func (r CollaborativeLogPolaroidRepository) ExifTolerance() time.Duration {
+	hours := config.APP_LIMIT.PolaroidExifToleranceHours
+	if hours <= 0 {
+		hours = 24
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// IsDuplicate tells whether a polaroid of any event looks like the photo.
+// Near-duplicates share at least one hash chunk, so only those are compared.
+func (r CollaborativeLogPolaroidRepository) IsDuplicate(hash uint64) bool {
+	distance := int(config.APP_LIMIT.PolaroidDuplicateDistance)
+	if distance <= 0 {
+		distance = 5
+	}
+	distance = min(distance, helpers.PhotoHashChunkCount-1)
+
+	var EventPolaroids []models.EventPolaroids
+	filter := bson.D{{Key: "event_polaroids_hash_chunks", Value: bson.M{"$in": helpers.PhotoHashChunks(hash)}}}
+	opts := options.Find().SetProjection(bson.M{"event_polaroids_hash": 1})
+	cursor, err := config.DB.Collection("EventPolaroids").Find(context.TODO(), filter, opts)
+	if err != nil {
+		return false
+	}
+	cursor.All(context.TODO(), &EventPolaroids)
+
+	for _, v := range EventPolaroids {
+		other, err := helpers.PhotoHashParse(v.EventPolaroidsHash)
+		if err == nil && helpers.PhotoHashDistance(hash, other) <= distance {
+			return true
+		}
+	}
+	return false
+}
+
+// Approve clears the flags of a polaroid a moderator found genuine and gives
+// it the star its location and time earn.
+func (r CollaborativeLogPolaroidRepository) Approve(c *gin.Context, EventPolaroids models.EventPolaroids) {
+	if len(EventPolaroids.EventPolaroidsFlags) == 0 {
+		return
+	}
+
+	starType := 2
+	isEventPeriod := EventPolaroids.EventPolaroidsIsEventPeriod != nil && *EventPolaroids.EventPolaroidsIsEventPeriod
+	radius := EventPolaroids.EventPolaroidsRadiusFromEvent
+	if radius != nil && *radius <= config.APP_LIMIT.PolaroidAchievementRadius && isEventPeriod {
+		starType = 1
+	}
+
+	upd := bson.D{
+		{Key: "$set", Value: bson.M{"event_polaroids_star_type": starType}},
+		{Key: "$unset", Value: bson.M{"event_polaroids_flags": ""}},
+	}
+	config.DB.Collection("EventPolaroids").UpdateOne(context.TODO(), bson.D{{Key: "_id", Value: EventPolaroids.EventPolaroidsId}}, upd)
+
+	var Events models.Events
+	config.DB.Collection("Events").FindOne(context.TODO(), bson.D{{Key: "_id", Value: EventPolaroids.EventPolaroidsEvent}}).Decode(&Events)
+	r.RecomputeAchievement(c, Events)
+}
+
+// ReadOne finds the polaroid of the event identified by the :polaroidId route
+// parameter.
+func (r CollaborativeLogPolaroidRepository) ReadOne(c *gin.Context, Events models.Events, EventPolaroids *models.EventPolaroids) error {
+	filter := bson.D{
+		{Key: "_id", Value: helpers.StringToPrimitiveObjId(c.Param("polaroidId"))},
+		{Key: "event_polaroids_event", Value: Events.EventsId},
+		{Key: "event_polaroids_hidden", Value: bson.M{"$ne": true}},
+	}
+	err := config.DB.Collection("EventPolaroids").FindOne(context.TODO(), filter).Decode(EventPolaroids)
+	if err != nil {
+		helpers.ResultEmpty(c, err)
+	}
+	return err
+}
+
+// ReadMember finds the polaroid identified by the route for a member of its
+// event: the owner or an accepted participant.
+func (r CollaborativeLogPolaroidRepository) ReadMember(c *gin.Context, Events *models.Events, EventPolaroids *models.EventPolaroids) error {
+	userDetail := helpers.GetAuthUser(c)
+	err := CollaborativeLogRepository{}.ReadOne(c, Events)
+	if err != nil {
+		return err
+	}
+	if helpers.EventRole(Events.EventsId, userDetail.UsersId) == "" {
+		helpers.ResponseBadRequestError(c, "You are not a participant of this event")
+		return errors.New("not a participant")
+	}
+	return r.ReadOne(c, *Events, EventPolaroids)
+}
+
+// NotifyUploader tells the uploader of the polaroid what another participant
+// did to it.
+func (r CollaborativeLogPolaroidRepository) NotifyUploader(c *gin.Context, code string, message string, Events models.Events, EventPolaroids models.EventPolaroids) {
+	userDetail := helpers.GetAuthUser(c)
+	if EventPolaroids.EventPolaroidsCreatedBy == userDetail.UsersId {
+		return
+	}
+
+	NotificationMessage := models.NotificationMessage{
+		Message: message,
+		Data: []map[string]interface{}{
+			helpers.NotificationFormatUser(userDetail),
+			helpers.NotificationFormatEvent(Events),
+			{"event_polaroids_id": EventPolaroids.EventPolaroidsId},
+		},
+	}
+	helpers.NotificationsCreate(c, code, EventPolaroids.EventPolaroidsCreatedBy, NotificationMessage, Events.EventsId)
+}
+
+// FillCounts sets the like and comment counts of the polaroids and whether
+// the user liked them.
+func (r CollaborativeLogPolaroidRepository) FillCounts(c *gin.Context, results []models.EventPolaroids) {
+	userDetail := helpers.GetAuthUser(c)
+	var polaroidIds []primitive.ObjectID
+	for _, v := range results {
+		polaroidIds = append(polaroidIds, v.EventPolaroidsId)
+	}
+	if len(polaroidIds) == 0 {
+		return
+	}
+
+	likes := r.Count("EventPolaroidLikes", "event_polaroid_likes_polaroid", polaroidIds)
+	comments := r.Count("EventPolaroidComments", "event_polaroid_comments_polaroid", polaroidIds)
+
+	var Likes []models.EventPolaroidLikes
+	filter := bson.D{
+		{Key: "event_polaroid_likes_polaroid", Value: bson.M{"$in": polaroidIds}},
+		{Key: "event_polaroid_likes_user", Value: userDetail.UsersId},
+	}
+	cursor, _ := config.DB.Collection("EventPolaroidLikes").Find(context.TODO(), filter)
+	cursor.All(context.TODO(), &Likes)
+	liked := map[primitive.ObjectID]bool{}
+	for _, v := range Likes {
+		liked[v.EventPolaroidLikesPolaroid] = true
+	}
+
+	for k, v := range results {
+		results[k].EventPolaroidsLikeCount = likes[v.EventPolaroidsId]
+		results[k].EventPolaroidsCommentCount = comments[v.EventPolaroidsId]
+		results[k].EventPolaroidsLiked = liked[v.EventPolaroidsId]
+	}
+}
+
+// Count counts the documents of the collection per polaroid, field being
+// where they keep the polaroid.
+func (r CollaborativeLogPolaroidRepository) Count(collection string, field string, polaroidIds []primitive.ObjectID) map[primitive.ObjectID]int {
+	counts := map[primitive.ObjectID]int{}
+	agg := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{field: bson.M{"$in": polaroidIds}}}},
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := config.DB.Collection(collection).Aggregate(context.TODO(), agg)
+	if err != nil {
+		return counts
+	}
+
+	var results []struct {
+		Id    primitive.ObjectID `bson:"_id"`
+		Count int                `bson:"count"`
+	}
+	cursor.All(context.TODO(), &results)
+	for _, v := range results {
+		counts[v.Id] = v.Count
+	}
+	return counts
+}
+
+// Update changes the message and tag of the user's own polaroid. The photo
+// and its location stay, so the achievements are not affected.
+func (r CollaborativeLogPolaroidRepository) Update(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Events models.Events
+	err := CollaborativeLogRepository{}.ReadOne(c, &Events)
+	if err != nil {
+		return
+	}
+
+	var payload CollaborativeLogPolaroidUpdateRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	match, errMessage := helpers.ValidateStringLength(payload.EventPolaroidsMessage, int(config.APP_LIMIT.LengthEventPolaroidMessage))
+	if !match {
+		helpers.ResponseBadRequestError(c, errMessage)
+		return
+	}
+
+	var EventPolaroids models.EventPolaroids
+	err = r.ReadOne(c, Events, &EventPolaroids)
+	if err != nil {
+		return
+	}
+	if EventPolaroids.EventPolaroidsCreatedBy != userDetail.UsersId {
+		helpers.ResponseBadRequestError(c, "Only the uploader can edit this polaroid")
+		return
+	}
+
+	EventPolaroids.EventPolaroidsMessage = payload.EventPolaroidsMessage
+	EventPolaroids.EventPolaroidsTag = payload.EventPolaroidsTag
+	filters := bson.D{{Key: "_id", Value: EventPolaroids.EventPolaroidsId}}
+	upd := bson.D{{Key: "$set", Value: bson.M{
+		"event_polaroids_message": payload.EventPolaroidsMessage,
+		"event_polaroids_tag":     payload.EventPolaroidsTag,
+	}}}
+	config.DB.Collection("EventPolaroids").UpdateOne(context.TODO(), filters, upd)
+
+	helpers.EventStreamPublish(Events.EventsId, helpers.EVENT_STREAM_POLAROID_UPDATED, EventPolaroids)
+	c.JSON(http.StatusOK, EventPolaroids)
+}
+
+// Delete removes a polaroid. Uploaders can delete their own polaroids and
+// organizers any polaroid of the event.
+func (r CollaborativeLogPolaroidRepository) Delete(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Events models.Events
+	err := CollaborativeLogRepository{}.ReadOne(c, &Events)
+	if err != nil {
+		return
+	}
+
+	var EventPolaroids models.EventPolaroids
+	err = r.ReadOne(c, Events, &EventPolaroids)
+	if err != nil {
+		return
+	}
+	if EventPolaroids.EventPolaroidsCreatedBy != userDetail.UsersId && !(EventRepository{}).IsOrganizer(c, Events) {
+		helpers.ResponseBadRequestError(c, "Only the uploader or an organizer can delete this polaroid")
+		return
+	}
+
+	r.Remove(c, Events, EventPolaroids)
+	helpers.ResultMessageSuccess(c, "Polaroid deleted")
+}
+
+// Remove deletes the polaroid and undoes what it earned: the uploader's
+// polaroid count, the stars of the event and the P1, N4 and R1 badges that
+// no longer have a polaroid behind them.
+func (r CollaborativeLogPolaroidRepository) Remove(c *gin.Context, Events models.Events, EventPolaroids models.EventPolaroids) {
+	config.DB.Collection("EventPolaroids").DeleteOne(context.TODO(), bson.D{{Key: "_id", Value: EventPolaroids.EventPolaroidsId}})
+	config.DB.Collection("EventPolaroidLikes").DeleteMany(context.TODO(), bson.D{{Key: "event_polaroid_likes_polaroid", Value: EventPolaroids.EventPolaroidsId}})
+	config.DB.Collection("EventPolaroidComments").DeleteMany(context.TODO(), bson.D{{Key: "event_polaroid_comments_polaroid", Value: EventPolaroids.EventPolaroidsId}})
+
+	r.Reconcile(c, Events, EventPolaroids.EventPolaroidsCreatedBy)
+	helpers.EventStreamPublish(Events.EventsId, helpers.EVENT_STREAM_POLAROID_DELETED, gin.H{"event_polaroids_id": EventPolaroids.EventPolaroidsId})
+}
+
+// Reconcile brings the polaroid count, the P1 and N4 badges and the stars of
+// the event up to date after a polaroid of the user was removed, hidden or
+// restored. Badges taken away are earned again with the next upload.
+func (r CollaborativeLogPolaroidRepository) Reconcile(c *gin.Context, Events models.Events, userId primitive.ObjectID) {
+	if r.CountPolaroids(Events.EventsId, userId) == 0 {
+		r.ReconcileBadge("P1", "user_badges_events", Events.EventsId, userId)
+		r.ReconcileBadge("N4", "user_badges_rewilding", Events.EventsId, userId)
+	}
+	r.RecomputeAchievement(c, Events)
+}
+
+// Visible narrows a polaroid filter to the polaroids not hidden by a
+// moderator, the only ones that count towards achievements and badges.
+func (r CollaborativeLogPolaroidRepository) Visible(filter bson.D) bson.D {
+	return append(filter, bson.E{Key: "event_polaroids_hidden", Value: bson.M{"$ne": true}})
+}
+
+// RecomputeAchievement assigns the stars of the event again from the
+// polaroids that are left. Without eligible polaroids the event and its
+// participants lose the achievement and the R1 badges earned with it.
+func (r CollaborativeLogPolaroidRepository) RecomputeAchievement(c *gin.Context, Event models.Events) {
+	filter := r.Visible(bson.D{{Key: "event_polaroids_event", Value: Event.EventsId}, {Key: "event_polaroids_achievement_eligible", Value: true}})
+	count, _ := config.DB.Collection("EventPolaroids").CountDocuments(context.TODO(), filter)
+	if count == 0 {
+		filterParticipants := bson.D{{Key: "event_participants_event", Value: Event.EventsId}}
+		resetParticipants := bson.D{{Key: "$unset", Value: bson.M{
+			"event_participants_star_type":               "",
+			"event_participants_achievement_eligible":    "",
+			"event_participants_achievement_unlocked_at": "",
+		}}}
+		config.DB.Collection("EventParticipants").UpdateMany(context.TODO(), filterParticipants, resetParticipants)
+
+		eventUpd := bson.D{{Key: "$unset", Value: bson.M{"events_rewilding_achievement_eligible": ""}}}
+		config.DB.Collection("Events").UpdateOne(context.TODO(), bson.D{{Key: "_id", Value: Event.EventsId}}, eventUpd)
+
+		badgeFilter := bson.D{
+			{Key: "user_badges_badge", Value: helpers.BadgeDetail("R1").BadgesId},
+			{Key: "user_badges_events", Value: Event.EventsId},
+		}
+		config.DB.Collection("UserBadges").DeleteMany(context.TODO(), badgeFilter)
+		return
+	}
+	r.EventAchievementEligibility(c, Event)
+}
+
+// ReconcileBadge moves the user's badge earned with the event's polaroids to
+// another event the user still has polaroids in, or takes it away when there
+// is none. field is where the badge keeps the event.
+func (r CollaborativeLogPolaroidRepository) ReconcileBadge(badgeCode string, field string, eventId primitive.ObjectID, userId primitive.ObjectID) {
+	badgeFilter := bson.D{
+		{Key: "user_badges_user", Value: userId},
+		{Key: "user_badges_badge", Value: helpers.BadgeDetail(badgeCode).BadgesId},
+		{Key: field, Value: eventId},
+	}
+
+	var Other models.EventPolaroids
+	otherFilter := r.Visible(bson.D{
+		{Key: "event_polaroids_created_by", Value: userId},
+		{Key: "event_polaroids_event", Value: bson.M{"$ne": eventId}},
+	})
+	err := config.DB.Collection("EventPolaroids").FindOne(context.TODO(), otherFilter).Decode(&Other)
+	if err != nil {
+		config.DB.Collection("UserBadges").DeleteMany(context.TODO(), badgeFilter)
+		return
+	}
+
+	upd := bson.D{{Key: "$set", Value: bson.M{field: Other.EventPolaroidsEvent}}}
+	config.DB.Collection("UserBadges").UpdateMany(context.TODO(), badgeFilter, upd)
+}
+
 func (r CollaborativeLogPolaroidRepository) CountTotalPolaroids(eventId primitive.ObjectID) int64 {
 	filter := bson.D{{Key: "event_polaroids_event", Value: eventId}}
 	count, err := config.DB.Collection("EventPolaroids").CountDocuments(context.TODO(), filter)
@@ -235,7 +588,7 @@ func (r CollaborativeLogPolaroidRepository) CountTotalPolaroids(eventId primitiv
 func (r CollaborativeLogPolaroidRepository) EventAchievementEligibility(c *gin.Context, Event models.Events) {
 	var EventPolaroids []models.EventPolaroids
 	var oneStarUsers []primitive.ObjectID
-	filter := bson.D{{Key: "event_polaroids_event", Value: Event.EventsId}, {Key: "event_polaroids_achievement_eligible", Value: true}}
+	filter := r.Visible(bson.D{{Key: "event_polaroids_event", Value: Event.EventsId}, {Key: "event_polaroids_achievement_eligible", Value: true}})
 	count, _ := config.DB.Collection("EventPolaroids").CountDocuments(context.TODO(), filter)
 
 	if count > 0 {
@@ -246,11 +599,8 @@ func (r CollaborativeLogPolaroidRepository) EventAchievementEligibility(c *gin.C
 		}}}
 		config.DB.Collection("Events").UpdateOne(context.TODO(), filterUpd, eventUpd)
 
-		filterType2 := bson.D{
-			{Key: "event_polaroids_event", Value: Event.EventsId},
-			{Key: "event_polaroids_achievement_eligible", Value: true},
-		}
-		cursor, _ := config.DB.Collection("EventPolaroids").Find(context.TODO(), filterType2)
+		opts := options.Find().SetSort(bson.D{{Key: "event_polaroids_created_at", Value: 1}})
+		cursor, _ := config.DB.Collection("EventPolaroids").Find(context.TODO(), filter, opts)
 		cursor.All(context.TODO(), &EventPolaroids)
 
 		if len(EventPolaroids) > 0 {
@@ -278,6 +628,19 @@ func (r CollaborativeLogPolaroidRepository) EventAchievementEligibility(c *gin.C
 				}}}
 				config.DB.Collection("EventParticipants").UpdateMany(context.TODO(), filterOneStars, updOneStarType)
 
+				// Uploaders who are no longer accepted do not keep a star
+				filterLeft := bson.D{
+					{Key: "event_participants_event", Value: Event.EventsId},
+					{Key: "event_participants_user", Value: bson.M{"$in": oneStarUsers}},
+					{Key: "event_participants_status", Value: bson.M{"$ne": GetEventParticipantStatus("ACCEPTED")}},
+				}
+				updLeft := bson.D{{Key: "$unset", Value: bson.M{
+					"event_participants_star_type":               "",
+					"event_participants_achievement_eligible":    "",
+					"event_participants_achievement_unlocked_at": "",
+				}}}
+				config.DB.Collection("EventParticipants").UpdateMany(context.TODO(), filterLeft, updLeft)
+
 				filterTwoStars = append(filterTwoStars, primitive.E{Key: "event_participants_user", Value: bson.M{"$nin": oneStarUsers}})
 			}
 
@@ -363,6 +726,13 @@ func (r CollaborativeLogPolaroidRepository) HandleBadges(c *gin.Context, Event m
 }
 
 func (r CollaborativeLogPolaroidRepository) CountUploadPolaroidByParticipant(c *gin.Context, eventId primitive.ObjectID, userId primitive.ObjectID) {
+	r.CountPolaroids(eventId, userId)
+	helpers.BadgeAllocate(c, "N4", helpers.BADGE_REWILDING, eventId, userId)
+}
+
+// CountPolaroids stores the number of polaroids the participant uploaded to
+// the event in event_participants_polaroid_count and returns it.
+func (r CollaborativeLogPolaroidRepository) CountPolaroids(eventId primitive.ObjectID, userId primitive.ObjectID) int64 {
 	var EventParticipants models.EventParticipants
 	filter := bson.D{
 		{Key: "event_participants_event", Value: eventId},
@@ -370,7 +740,7 @@ func (r CollaborativeLogPolaroidRepository) CountUploadPolaroidByParticipant(c *
 	}
 	config.DB.Collection("EventParticipants").FindOne(context.TODO(), filter).Decode(&EventParticipants)
 
-	countFilter := bson.D{{Key: "event_polaroids_event", Value: eventId}, {Key: "event_polaroids_created_by", Value: userId}}
+	countFilter := r.Visible(bson.D{{Key: "event_polaroids_event", Value: eventId}, {Key: "event_polaroids_created_by", Value: userId}})
 	count, _ := config.DB.Collection("EventPolaroids").CountDocuments(context.TODO(), countFilter)
 
 	filterUpd := bson.D{{Key: "_id", Value: EventParticipants.EventParticipantsId}}
@@ -378,5 +748,5 @@ func (r CollaborativeLogPolaroidRepository) CountUploadPolaroidByParticipant(c *
 		"event_participants_polaroid_count": count,
 	}}}
 	config.DB.Collection("EventParticipants").UpdateOne(context.TODO(), filterUpd, eventParticipantUpd)
-	helpers.BadgeAllocate(c, "N4", helpers.BADGE_REWILDING, eventId, userId)
+	return count
 }
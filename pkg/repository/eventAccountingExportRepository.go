@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EventAccountingExportRepository exports the accounting of an event with its
+// splits, balances and transfers, as the paper trail for reimbursements.
+type EventAccountingExportRepository struct{}
+
+const (
+	EXPORT_FORMAT_CSV  = "csv"
+	EXPORT_FORMAT_XLSX = "xlsx"
+)
+
+func (r EventAccountingExportRepository) Retrieve(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", EXPORT_FORMAT_CSV))
+	if format != EXPORT_FORMAT_CSV && format != EXPORT_FORMAT_XLSX {
+		helpers.ResponseBadRequestError(c, "Format must be csv or xlsx")
+		return
+	}
+
+	sheets := r.Sheets(Event)
+	fileName := "event-accounting-" + Event.EventsId.Hex() + "." + format
+	var buffer bytes.Buffer
+	contentType := "text/csv; charset=utf-8"
+	if format == EXPORT_FORMAT_XLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		err = helpers.XlsxWrite(&buffer, sheets)
+	} else {
+		err = r.Csv(&buffer, sheets)
+	}
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\""+fileName+"\"")
+	c.Data(http.StatusOK, contentType, buffer.Bytes())
+}
+
+// Csv writes the sheets one after another, each under its name and separated
+// by an empty line. The byte order mark lets spreadsheet applications detect
+// UTF-8 for the Chinese descriptions. Text that a spreadsheet would run as a
+// formula is prefixed with a quote.
+func (r EventAccountingExportRepository) Csv(buffer *bytes.Buffer, sheets []helpers.XlsxSheet) error {
+	buffer.WriteString("\uFEFF")
+	writer := csv.NewWriter(buffer)
+	for k, sheet := range sheets {
+		if k > 0 {
+			writer.Write([]string{})
+		}
+		writer.Write([]string{sheet.Name})
+		for _, row := range sheet.Rows {
+			var record []string
+			for _, v := range row {
+				record = append(record, r.CsvText(v))
+			}
+			writer.Write(record)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// CsvText formats a cell, escaping user text such as descriptions and names
+// that starts like a formula.
+func (r EventAccountingExportRepository) CsvText(v interface{}) string {
+	text := helpers.XlsxText(v)
+	if _, ok := v.(string); ok && text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+func (r EventAccountingExportRepository) Sheets(Event models.Events) []helpers.XlsxSheet {
+	var EventAccounting []models.EventAccounting
+	opts := options.Find().SetSort(bson.D{{Key: "event_accounting_created_at", Value: 1}})
+	cursor, _ := config.DB.Collection("EventAccounting").Find(context.TODO(), bson.D{{Key: "event_accounting_event", Value: Event.EventsId}}, opts)
+	cursor.All(context.TODO(), &EventAccounting)
+
+	Settlement, _ := EventSettlementRepository{}.Calculate(Event.EventsId)
+	participants := EventAccountingRepository{}.Participants(Event.EventsId)
+	currency := Settlement.Currency
+
+	var userIds []primitive.ObjectID
+	for _, v := range EventAccounting {
+		userIds = append(userIds, v.EventAccountingPaidBy, v.EventAccountingCreatedBy)
+		for _, split := range v.EventAccountingSplits {
+			userIds = append(userIds, split.EventAccountingSplitUser)
+		}
+	}
+	userIds = append(userIds, participants...)
+	for _, v := range Settlement.Balances {
+		userIds = append(userIds, v.User)
+	}
+	UsersDetail := EventSettlementRepository{}.Users(userIds)
+	userName := func(userId primitive.ObjectID) string {
+		if user, ok := UsersDetail[userId]; ok && user.UsersName != "" {
+			return user.UsersName
+		}
+		return userId.Hex()
+	}
+
+	entries := [][]interface{}{{
+		"ID", "Date", "Description", "Category", "Paid by", "Amount", "Currency", "Exchange rate", "Exchange rate source", "Amount (" + currency + ")", "Split type", "Created by", "Receipts",
+	}}
+	splits := [][]interface{}{{"Entry ID", "Description", "Beneficiary", "Share (" + currency + ")"}}
+	for _, v := range EventAccounting {
+		entryCurrency := v.EventAccountingCurrency
+		rate := v.EventAccountingExchangeRate
+		if entryCurrency == "" {
+			entryCurrency = currency
+			rate = 1
+		}
+		splitType := v.EventAccountingSplitType
+		if splitType == "" {
+			splitType = helpers.SPLIT_TYPE_EQUAL
+		}
+
+		var receipts []string
+		for _, receipt := range v.EventAccountingReceipts {
+			receipts = append(receipts, receipt.EventAccountingReceiptsPath)
+		}
+
+		entries = append(entries, []interface{}{
+			v.EventAccountingId.Hex(),
+			v.EventAccountingCreatedAt.Time().Format(time.RFC3339),
+			v.EventAccountingMessage,
+			v.EventAccountingCategory,
+			userName(v.EventAccountingPaidBy),
+			v.EventAccountingAmount,
+			entryCurrency,
+			rate,
+			v.EventAccountingExchangeRateSource,
+			EventAccountingRepository{}.BaseAmount(v),
+			splitType,
+			userName(v.EventAccountingCreatedBy),
+			strings.Join(receipts, "\n"),
+		})
+
+		for _, split := range (EventAccountingRepository{}).SplitAmounts(v, participants) {
+			splits = append(splits, []interface{}{
+				v.EventAccountingId.Hex(),
+				v.EventAccountingMessage,
+				userName(split.EventAccountingSplitUser),
+				split.EventAccountingSplitValue,
+			})
+		}
+	}
+
+	balances := [][]interface{}{{"Participant", "Paid (" + currency + ")", "Share (" + currency + ")", "Settled (" + currency + ")", "Deposit (" + currency + ")", "Balance (" + currency + ")"}}
+	for _, v := range Settlement.Balances {
+		balances = append(balances, []interface{}{userName(v.User), v.Paid, v.Share, v.Settled, v.Deposit, v.Balance})
+	}
+
+	transfers := [][]interface{}{{"From", "To", "Amount (" + currency + ")", "Status", "Settled at"}}
+	for _, v := range Settlement.Settled {
+		transfers = append(transfers, []interface{}{
+			userName(v.EventSettlementsFrom),
+			userName(v.EventSettlementsTo),
+			v.EventSettlementsAmount,
+			"SETTLED",
+			v.EventSettlementsCreatedAt.Time().Format(time.RFC3339),
+		})
+	}
+	for _, v := range Settlement.Deposits {
+		transfers = append(transfers, []interface{}{
+			userName(v.EventDepositsUser),
+			userName(v.EventDepositsCollector),
+			v.EventDepositsAmount,
+			"DEPOSIT",
+			v.EventDepositsCreatedAt.Time().Format(time.RFC3339),
+		})
+	}
+	for _, v := range Settlement.Transfers {
+		transfers = append(transfers, []interface{}{userName(v.From), userName(v.To), v.Amount, "OUTSTANDING", nil})
+	}
+
+	return []helpers.XlsxSheet{
+		{Name: "Entries", Rows: entries},
+		{Name: "Splits", Rows: splits},
+		{Name: "Balances", Rows: balances},
+		{Name: "Transfers", Rows: transfers},
+	}
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventAccountingReceiptRepository attaches receipt photos to accounting
+// records. Any participant of the event can attach one; the uploader or an
+// organizer can remove it.
+type EventAccountingReceiptRepository struct{}
+
+func (r EventAccountingReceiptRepository) Create(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	var EventAccounting models.EventAccounting
+	err = EventAccountingRepository{}.ReadOne(c, &EventAccounting)
+	if err != nil {
+		return
+	}
+
+	if len(EventAccounting.EventAccountingReceipts) >= r.Limit() {
+		helpers.ResponseBadRequestError(c, "Unable to attach receipt. Maximum allowed: "+strconv.Itoa(r.Limit()))
+		return
+	}
+
+	file, err := helpers.ValidatePhotoRequest(c, "event_accounting_receipt", true)
+	if file == nil || err != nil {
+		return
+	}
+
+	cloudflare := CloudflareRepository{}
+	cloudflareResponse, postErr := cloudflare.Post(c, file)
+	if postErr != nil {
+		helpers.ResponseBadRequestError(c, postErr.Error())
+		return
+	}
+
+	EventAccountingReceipts := models.EventAccountingReceipts{
+		EventAccountingReceiptsId:        primitive.NewObjectID(),
+		EventAccountingReceiptsPath:      cloudflare.ImageDelivery(cloudflareResponse.Result.Id, "public"),
+		EventAccountingReceiptsCreatedBy: userDetail.UsersId,
+		EventAccountingReceiptsCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	filters := bson.D{{Key: "_id", Value: EventAccounting.EventAccountingId}}
+	upd := bson.D{{Key: "$push", Value: bson.M{"event_accounting_receipts": EventAccountingReceipts}}}
+	_, err = config.DB.Collection("EventAccounting").UpdateOne(context.TODO(), filters, upd)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, EventAccountingReceipts)
+}
+
+func (r EventAccountingReceiptRepository) Delete(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	var EventAccounting models.EventAccounting
+	err = EventAccountingRepository{}.ReadOne(c, &EventAccounting)
+	if err != nil {
+		return
+	}
+
+	receiptId := helpers.StringToPrimitiveObjId(c.Param("receiptId"))
+	var EventAccountingReceipts *models.EventAccountingReceipts
+	for k, v := range EventAccounting.EventAccountingReceipts {
+		if v.EventAccountingReceiptsId == receiptId {
+			EventAccountingReceipts = &EventAccounting.EventAccountingReceipts[k]
+		}
+	}
+	if EventAccountingReceipts == nil {
+		helpers.ResponseNotFound(c, "Receipt not found")
+		return
+	}
+
+	if EventAccountingReceipts.EventAccountingReceiptsCreatedBy != userDetail.UsersId && !(EventRepository{}).IsOrganizer(c, Event) {
+		helpers.ResponseBadRequestError(c, "Only the uploader or the event organizer can remove this receipt")
+		return
+	}
+
+	filters := bson.D{{Key: "_id", Value: EventAccounting.EventAccountingId}}
+	upd := bson.D{{Key: "$pull", Value: bson.M{"event_accounting_receipts": bson.M{"_id": receiptId}}}}
+	config.DB.Collection("EventAccounting").UpdateOne(context.TODO(), filters, upd)
+	helpers.ResultMessageSuccess(c, "Receipt removed")
+}
+
+// Limit is the number of receipts a record can have, 5 when
+// EVENT_ACCOUNTING_RECEIPT_LIMIT is not set.
+func (r EventAccountingReceiptRepository) Limit() int {
+	if config.APP_LIMIT.EventAccountingReceiptLimit <= 0 {
+		return 5
+	}
+	return int(config.APP_LIMIT.EventAccountingReceiptLimit)
+}
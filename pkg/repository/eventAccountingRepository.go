@@ -2,11 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"oosa_rewild/internal/config"
 	"oosa_rewild/internal/helpers"
 	"oosa_rewild/internal/models"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -19,9 +21,25 @@ import (
 
 type EventAccountingRepository struct{}
 type EventAccountingRequest struct {
+	// Keeps the id and receipts of an existing record when the records are
+	// replaced in bulk
+	EventAccountingId      string  `json:"event_accounting_id"`
 	EventAccountingMessage string  `json:"event_accounting_message" validate:"required"`
 	EventAccountingAmount  float64 `json:"event_accounting_amount" validate:"required,max=999999"`
 	EventAccountingPaidBy  string  `json:"event_accounting_paid_by" validate:"required"`
+	// Budget category the record counts towards, OTHER when not given
+	EventAccountingCategory string `json:"event_accounting_category" validate:"omitempty,oneof=TRANSPORT LODGING PERMITS FOOD GEAR OTHER"`
+	// Defaults to the event currency, or the record's own currency when it is
+	// updated. The exchange rate to the event currency is looked up unless given
+	EventAccountingCurrency     string  `json:"event_accounting_currency" validate:"omitempty,iso4217"`
+	EventAccountingExchangeRate float64 `json:"event_accounting_exchange_rate" validate:"omitempty,gt=0"`
+	// Without splits, the record is split equally between all participants
+	EventAccountingSplitType string                        `json:"event_accounting_split_type" validate:"omitempty,oneof=EQUAL PERCENTAGE SHARES EXACT"`
+	EventAccountingSplits    []EventAccountingSplitRequest `json:"event_accounting_splits" validate:"omitempty,dive"`
+}
+type EventAccountingSplitRequest struct {
+	EventAccountingSplitUser  string  `json:"event_accounting_split_user" validate:"required"`
+	EventAccountingSplitValue float64 `json:"event_accounting_split_value" validate:"gte=0"`
 }
 type EventAccountingRequestBulkRequestValidate struct {
 	Data []EventAccountingRequest `json:"data" validate:"required,dive"`
@@ -63,12 +81,18 @@ func (r EventAccountingRepository) Retrieve(c *gin.Context) {
 		helpers.ResponseNoData(c, "No Data")
 		return
 	}
+
+	participants := r.Participants(eventId)
+	for k, v := range results {
+		results[k].EventAccountingSplitAmounts = r.SplitAmounts(v, participants)
+	}
 	c.JSON(http.StatusOK, results)
 }
 
 func (r EventAccountingRepository) Create(c *gin.Context) {
 	eventId := helpers.StringToPrimitiveObjId(c.Param("id"))
-	err := EventRepository{}.ReadOne(c, &models.Events{})
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
 	if err != nil {
 		return
 	}
@@ -98,6 +122,14 @@ func (r EventAccountingRepository) Create(c *gin.Context) {
 		return
 	}
 
+	var Existing []models.EventAccounting
+	cursor, _ := config.DB.Collection("EventAccounting").Find(context.TODO(), bson.D{{Key: "event_accounting_event", Value: eventId}})
+	cursor.All(context.TODO(), &Existing)
+	existingAccounting := map[string]models.EventAccounting{}
+	for _, v := range Existing {
+		existingAccounting[v.EventAccountingId.Hex()] = v
+	}
+
 	var strLenErr []string
 	var insertAccounting []interface{}
 	for k, v := range payload {
@@ -118,14 +150,34 @@ func (r EventAccountingRepository) Create(c *gin.Context) {
 				continue
 			}
 
-			insertAccounting = append(insertAccounting, models.EventAccounting{
+			splitErr := r.ValidateSplits(eventId, v)
+			if splitErr != nil {
+				strLenErr = append(strLenErr, "Split at index "+strconv.Itoa(k)+": "+splitErr.Error())
+				continue
+			}
+
+			EventAccounting := models.EventAccounting{
 				EventAccountingEvent:     eventId,
 				EventAccountingMessage:   v.EventAccountingMessage,
+				EventAccountingCategory:  r.Category(v),
 				EventAccountingAmount:    v.EventAccountingAmount,
 				EventAccountingPaidBy:    helpers.StringToPrimitiveObjId(v.EventAccountingPaidBy),
+				EventAccountingSplitType: r.SplitType(v),
+				EventAccountingSplits:    r.Splits(v),
 				EventAccountingCreatedBy: userDetail.UsersId,
 				EventAccountingCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
-			})
+			}
+			if previous, ok := existingAccounting[v.EventAccountingId]; ok {
+				EventAccounting.EventAccountingId = previous.EventAccountingId
+				EventAccounting.EventAccountingReceipts = previous.EventAccountingReceipts
+				delete(existingAccounting, v.EventAccountingId)
+			}
+			rateErr := r.Convert(Event, &EventAccounting, v.EventAccountingCurrency, v.EventAccountingExchangeRate)
+			if rateErr != nil {
+				strLenErr = append(strLenErr, "Currency at index "+strconv.Itoa(k)+": "+rateErr.Error())
+				continue
+			}
+			insertAccounting = append(insertAccounting, EventAccounting)
 		}
 	}
 
@@ -152,6 +204,7 @@ func (r EventAccountingRepository) Read(c *gin.Context) {
 	var EventAccounting models.EventAccounting
 	errMb := r.ReadOne(c, &EventAccounting)
 	if errMb == nil {
+		EventAccounting.EventAccountingSplitAmounts = r.SplitAmounts(EventAccounting, r.Participants(EventAccounting.EventAccountingEvent))
 		c.JSON(http.StatusOK, EventAccounting)
 	}
 }
@@ -168,7 +221,8 @@ func (r EventAccountingRepository) ReadOne(c *gin.Context, EventAccounting *mode
 }
 
 func (r EventAccountingRepository) Update(c *gin.Context) {
-	err := EventRepository{}.ReadOne(c, &models.Events{})
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
 	if err != nil {
 		return
 	}
@@ -188,7 +242,18 @@ func (r EventAccountingRepository) Update(c *gin.Context) {
 	var EventAccounting models.EventAccounting
 	errMb := r.ReadOne(c, &EventAccounting)
 	if errMb == nil {
+		splitErr := r.ValidateSplits(EventAccounting.EventAccountingEvent, payload)
+		if splitErr != nil {
+			helpers.ResponseBadRequestError(c, splitErr.Error())
+			return
+		}
+
 		r.ProcessData(c, &EventAccounting, payload)
+		rateErr := r.Convert(Event, &EventAccounting, payload.EventAccountingCurrency, payload.EventAccountingExchangeRate)
+		if rateErr != nil {
+			helpers.ResponseBadRequestError(c, rateErr.Error())
+			return
+		}
 		filters := bson.D{{Key: "_id", Value: EventAccounting.EventAccountingId}, {Key: "event_accounting_event", Value: EventAccounting.EventAccountingEvent}}
 		upd := bson.D{{Key: "$set", Value: EventAccounting}}
 		config.DB.Collection("EventAccounting").UpdateOne(context.TODO(), filters, upd)
@@ -215,6 +280,235 @@ func (r EventAccountingRepository) Delete(c *gin.Context) {
 func (r EventAccountingRepository) ProcessData(c *gin.Context, EventAccounting *models.EventAccounting, payload EventAccountingRequest) {
 	paidBy := helpers.StringToPrimitiveObjId(payload.EventAccountingPaidBy)
 	EventAccounting.EventAccountingMessage = payload.EventAccountingMessage
+	EventAccounting.EventAccountingCategory = r.Category(payload)
 	EventAccounting.EventAccountingAmount = payload.EventAccountingAmount
 	EventAccounting.EventAccountingPaidBy = paidBy
+	EventAccounting.EventAccountingSplitType = r.SplitType(payload)
+	EventAccounting.EventAccountingSplits = r.Splits(payload)
+}
+
+func (r EventAccountingRepository) Category(payload EventAccountingRequest) string {
+	if payload.EventAccountingCategory == "" {
+		return helpers.BUDGET_CATEGORY_OTHER
+	}
+	return payload.EventAccountingCategory
+}
+
+func (r EventAccountingRepository) SplitType(payload EventAccountingRequest) string {
+	if len(payload.EventAccountingSplits) == 0 || payload.EventAccountingSplitType == "" {
+		return helpers.SPLIT_TYPE_EQUAL
+	}
+	return payload.EventAccountingSplitType
+}
+
+func (r EventAccountingRepository) Splits(payload EventAccountingRequest) []models.EventAccountingSplit {
+	var splits []models.EventAccountingSplit
+	for _, v := range payload.EventAccountingSplits {
+		splits = append(splits, models.EventAccountingSplit{
+			EventAccountingSplitUser:  helpers.StringToPrimitiveObjId(v.EventAccountingSplitUser),
+			EventAccountingSplitValue: v.EventAccountingSplitValue,
+		})
+	}
+	return splits
+}
+
+// ValidateSplits checks that the beneficiaries are accepted participants of
+// the event and that the split values add up.
+func (r EventAccountingRepository) ValidateSplits(eventId primitive.ObjectID, payload EventAccountingRequest) error {
+	if len(payload.EventAccountingSplits) == 0 {
+		return nil
+	}
+
+	splits := r.Splits(payload)
+	var users []primitive.ObjectID
+	for _, v := range splits {
+		users = append(users, v.EventAccountingSplitUser)
+	}
+	filter := bson.D{
+		{Key: "event_participants_event", Value: eventId},
+		{Key: "event_participants_user", Value: bson.M{"$in": users}},
+		{Key: "event_participants_status", Value: GetEventParticipantStatus("ACCEPTED")},
+	}
+	var EventParticipants []models.EventParticipants
+	cursor, err := config.DB.Collection("EventParticipants").Find(context.TODO(), filter)
+	if err != nil {
+		return err
+	}
+	cursor.All(context.TODO(), &EventParticipants)
+	accepted := map[primitive.ObjectID]bool{}
+	for _, v := range EventParticipants {
+		accepted[v.EventParticipantsUser] = true
+	}
+
+	var weights []helpers.SettlementWeight
+	for _, v := range splits {
+		if !accepted[v.EventAccountingSplitUser] {
+			return errors.New("beneficiary " + v.EventAccountingSplitUser.Hex() + " is not an accepted event participant")
+		}
+		weights = append(weights, helpers.SettlementWeight{User: v.EventAccountingSplitUser, Value: v.EventAccountingSplitValue})
+	}
+	return helpers.SettlementSplitValidate(payload.EventAccountingAmount, r.SplitType(payload), weights)
+}
+
+// Participants are the users a record without splits is shared between.
+func (r EventAccountingRepository) Participants(eventId primitive.ObjectID) []primitive.ObjectID {
+	var participants []primitive.ObjectID
+	for _, v := range (EventParticipantsRepository{}).ActiveParticipants(eventId) {
+		participants = append(participants, v.EventParticipantsUser)
+	}
+	return participants
+}
+
+// Convert sets the currency of the record and its amount in the event
+// currency. A given exchange rate overrides the configured rate source.
+// Without a currency the record keeps its own, along with its manual rate.
+func (r EventAccountingRepository) Convert(Event models.Events, EventAccounting *models.EventAccounting, currency string, rate float64) error {
+	base := r.Currency(Event)
+	if currency == "" {
+		currency = EventAccounting.EventAccountingCurrency
+	}
+	if currency == "" {
+		currency = base
+	}
+	if rate == 0 && currency == EventAccounting.EventAccountingCurrency && EventAccounting.EventAccountingExchangeRateSource == helpers.EXCHANGE_RATE_SOURCE_MANUAL {
+		rate = EventAccounting.EventAccountingExchangeRate
+	}
+
+	source := helpers.EXCHANGE_RATE_SOURCE_MANUAL
+	if rate == 0 {
+		rates, err := helpers.ExchangeRates()
+		if err != nil {
+			return err
+		}
+		rate, err = rates.Rate(currency, base)
+		if err != nil {
+			return errors.New(err.Error() + ", provide event_accounting_exchange_rate")
+		}
+		source = rates.Name()
+	}
+
+	EventAccounting.EventAccountingCurrency = currency
+	EventAccounting.EventAccountingExchangeRate = rate
+	EventAccounting.EventAccountingExchangeRateSource = source
+	EventAccounting.EventAccountingBaseAmount = helpers.ExchangeConvert(EventAccounting.EventAccountingAmount, rate)
+	return nil
+}
+
+// Rebase converts every record of the event again when its currency changes
+// from that of Previous. Manual rates were to the previous currency, so they
+// are chained with the rate from the previous to the new currency. When a
+// record cannot be converted nothing is changed and the error lists it.
+func (r EventAccountingRepository) Rebase(Previous models.Events, Event models.Events) error {
+	var EventAccounting []models.EventAccounting
+	cursor, err := config.DB.Collection("EventAccounting").Find(context.TODO(), bson.D{{Key: "event_accounting_event", Value: Event.EventsId}})
+	if err != nil {
+		return err
+	}
+	cursor.All(context.TODO(), &EventAccounting)
+
+	rates, err := helpers.ExchangeRates()
+	if err != nil {
+		return err
+	}
+	previousBase := r.Currency(Previous)
+	base := r.Currency(Event)
+
+	var rebaseErr []string
+	for k, v := range EventAccounting {
+		// Records from before currencies were supported are in the previous
+		// event currency
+		currency := v.EventAccountingCurrency
+		if currency == "" {
+			currency = previousBase
+		}
+
+		rate := 0.0
+		if v.EventAccountingExchangeRateSource == helpers.EXCHANGE_RATE_SOURCE_MANUAL && currency != base {
+			link, linkErr := rates.Rate(previousBase, base)
+			if linkErr != nil {
+				rebaseErr = append(rebaseErr, v.EventAccountingMessage+": "+linkErr.Error())
+				continue
+			}
+			rate = v.EventAccountingExchangeRate * link
+		}
+		v.EventAccountingCurrency = ""
+		v.EventAccountingExchangeRateSource = ""
+		convertErr := r.Convert(Event, &v, currency, rate)
+		if convertErr != nil {
+			rebaseErr = append(rebaseErr, v.EventAccountingMessage+": no exchange rate from "+currency+" to "+base)
+			continue
+		}
+		EventAccounting[k] = v
+	}
+	if len(rebaseErr) > 0 {
+		return errors.New(strings.Join(rebaseErr, ", "))
+	}
+
+	for _, v := range EventAccounting {
+		filters := bson.D{{Key: "_id", Value: v.EventAccountingId}}
+		upd := bson.D{{Key: "$set", Value: bson.M{
+			"event_accounting_currency":             v.EventAccountingCurrency,
+			"event_accounting_exchange_rate":        v.EventAccountingExchangeRate,
+			"event_accounting_exchange_rate_source": v.EventAccountingExchangeRateSource,
+			"event_accounting_base_amount":          v.EventAccountingBaseAmount,
+		}}}
+		config.DB.Collection("EventAccounting").UpdateOne(context.TODO(), filters, upd)
+	}
+	return nil
+}
+
+func (r EventAccountingRepository) Currency(Event models.Events) string {
+	if Event.EventsCurrency == "" {
+		return helpers.DEFAULT_CURRENCY
+	}
+	return Event.EventsCurrency
+}
+
+// BaseAmount is the record amount in the event currency. Records from before
+// currencies were supported are already in it.
+func (r EventAccountingRepository) BaseAmount(EventAccounting models.EventAccounting) float64 {
+	if EventAccounting.EventAccountingExchangeRate == 0 {
+		return EventAccounting.EventAccountingAmount
+	}
+	return EventAccounting.EventAccountingBaseAmount
+}
+
+// Shares returns how much of the record each beneficiary owes, in cents of
+// the event currency. Exact amounts are in the record currency, so they are
+// applied as weights of the converted amount.
+func (r EventAccountingRepository) Shares(EventAccounting models.EventAccounting, participants []primitive.ObjectID) map[primitive.ObjectID]int64 {
+	amount := helpers.SettlementCents(r.BaseAmount(EventAccounting))
+	if len(EventAccounting.EventAccountingSplits) == 0 {
+		return helpers.SettlementSplitEqually(amount, participants)
+	}
+
+	splitType := EventAccounting.EventAccountingSplitType
+	if splitType == helpers.SPLIT_TYPE_EXACT {
+		splitType = helpers.SPLIT_TYPE_SHARES
+	}
+
+	var weights []helpers.SettlementWeight
+	for _, v := range EventAccounting.EventAccountingSplits {
+		weights = append(weights, helpers.SettlementWeight{User: v.EventAccountingSplitUser, Value: v.EventAccountingSplitValue})
+	}
+	return helpers.SettlementSplit(amount, splitType, weights)
+}
+
+// SplitAmounts lists the resolved share of each beneficiary of the record.
+func (r EventAccountingRepository) SplitAmounts(EventAccounting models.EventAccounting, participants []primitive.ObjectID) []models.EventAccountingSplit {
+	shares := r.Shares(EventAccounting, participants)
+	var users []primitive.ObjectID
+	for user := range shares {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Hex() < users[j].Hex() })
+
+	var splitAmounts []models.EventAccountingSplit
+	for _, user := range users {
+		splitAmounts = append(splitAmounts, models.EventAccountingSplit{
+			EventAccountingSplitUser:  user,
+			EventAccountingSplitValue: helpers.SettlementAmount(shares[user]),
+		})
+	}
+	return splitAmounts
 }
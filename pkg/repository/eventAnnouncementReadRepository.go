@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/arwoosa/notifaction/helper"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EventAnnouncementReadRepository tracks which participants have read each
+// announcement. Saving the announcements again keeps the id of those that did
+// not change, so they stay read.
+type EventAnnouncementReadRepository struct{}
+
+type EventAnnouncementReadRequest struct {
+	EventAnnouncementIds []string `json:"event_announcement_ids" validate:"required,min=1"`
+}
+
+// Create marks the given announcements as read by the user, for clients that
+// show announcements received from the event stream.
+func (r EventAnnouncementReadRepository) Create(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	var payload EventAnnouncementReadRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	var ids []primitive.ObjectID
+	for _, v := range payload.EventAnnouncementIds {
+		ids = append(ids, helpers.StringToPrimitiveObjId(v))
+	}
+	// Only the announcements the participants can see are read
+	filter := bson.D{
+		{Key: "_id", Value: bson.M{"$in": ids}},
+		{Key: "event_message_board_event", Value: Event.EventsId},
+		{Key: "event_message_board_announcement", Value: bson.M{"$exists": true}},
+	}
+	var Announcements []models.EventMessageBoard
+	cursor, _ := config.DB.Collection("EventMessageBoard").Find(context.TODO(), EventAnnouncementRepository{}.Visible(filter, time.Now()))
+	cursor.All(context.TODO(), &Announcements)
+	if len(Announcements) == 0 {
+		helpers.ResponseBadRequestError(c, "Announcements not found")
+		return
+	}
+
+	r.Mark(Event.EventsId, userDetail.UsersId, Announcements)
+	helpers.ResponseSuccessMessage(c, "Announcements marked as read")
+}
+
+// Mark records that the user has read the announcements, keeping the time
+// each was first read.
+func (r EventAnnouncementReadRepository) Mark(eventId primitive.ObjectID, userId primitive.ObjectID, announcements []models.EventMessageBoard) {
+	if helpers.MongoZeroID(userId) {
+		return
+	}
+
+	readAt := primitive.NewDateTimeFromTime(time.Now())
+	for _, v := range announcements {
+		filters := bson.D{
+			{Key: "event_announcement_reads_announcement", Value: v.EventMessageBoardId},
+			{Key: "event_announcement_reads_user", Value: userId},
+		}
+		upd := bson.D{{Key: "$setOnInsert", Value: bson.M{
+			"event_announcement_reads_event":   eventId,
+			"event_announcement_reads_read_at": readAt,
+		}}}
+		config.DB.Collection("EventAnnouncementReads").UpdateOne(context.TODO(), filters, upd, options.Update().SetUpsert(true))
+	}
+}
+
+// Forget removes the reads of the announcements, which are unread again when
+// they are changed.
+func (r EventAnnouncementReadRepository) Forget(announcementIds []primitive.ObjectID) {
+	if len(announcementIds) == 0 {
+		return
+	}
+	filters := bson.D{{Key: "event_announcement_reads_announcement", Value: bson.M{"$in": announcementIds}}}
+	config.DB.Collection("EventAnnouncementReads").DeleteMany(context.TODO(), filters)
+}
+
+// Retrieve returns the read receipts of each published announcement.
+func (r EventAnnouncementReadRepository) Retrieve(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	Receipts := r.Receipts(Event.EventsId)
+	if len(Receipts) == 0 {
+		helpers.ResponseNoData(c, "No Data")
+		return
+	}
+	c.JSON(http.StatusOK, Receipts)
+}
+
+// Remind notifies the participants who have not read one of the published
+// announcements yet.
+func (r EventAnnouncementReadRepository) Remind(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	Receipts := r.Receipts(Event.EventsId)
+	if len(Receipts) == 0 {
+		helpers.ResponseBadRequestError(c, "This event has no announcements")
+		return
+	}
+	var unread []primitive.ObjectID
+	seen := map[primitive.ObjectID]bool{}
+	for _, receipt := range Receipts {
+		for _, v := range receipt.Unread {
+			if !seen[v.User] {
+				seen[v.User] = true
+				unread = append(unread, v.User)
+			}
+		}
+	}
+	if len(unread) == 0 {
+		helpers.ResponseSuccessMessage(c, "Everyone has read the announcements")
+		return
+	}
+
+	notifyData := map[string]string{
+		"events_name": Event.EventsName,
+	}
+	var notifyMsg helper.NotifyMsg
+	for _, user := range unread {
+		NotificationMessage := models.NotificationMessage{
+			Message: "{0}有尚未閱讀的公告",
+			Data:    []map[string]interface{}{helpers.NotificationFormatEvent(Event)},
+		}
+		helpers.NotificationsCreate(c, helpers.NOTIFICATION_EVENT_ANNOUNCEMENT, user, NotificationMessage, Event.EventsId)
+
+		if notifyMsg == nil {
+			notifyMsg, err = helper.NewNotifyMsg(
+				helpers.NOTIFICATION_EVENT_ANNOUNCEMENT,
+				userDetail.UsersId, user,
+				notifyData, helpers.FindUserSourceId)
+			if err != nil {
+				fmt.Println("new notify msg err: " + err.Error())
+			}
+		} else {
+			notifyMsg.AddTo(user)
+		}
+	}
+
+	if notifyMsg != nil {
+		notifyMsg.WriteToHeader(c, config.APP.NotificationHeaderName)
+	}
+	helpers.ResponseSuccessMessage(c, "Reminder sent to "+strconv.Itoa(len(unread))+" participants")
+}
+
+// Receipts splits the accepted participants, other than the author of each
+// published announcement, into those who have read it and those who have
+// not.
+func (r EventAnnouncementReadRepository) Receipts(eventId primitive.ObjectID) []models.EventAnnouncementReceipt {
+	Receipts := []models.EventAnnouncementReceipt{}
+
+	filter := bson.D{
+		{Key: "event_message_board_event", Value: eventId},
+		{Key: "event_message_board_announcement", Value: bson.M{"$exists": true}},
+	}
+	var Announcements []models.EventMessageBoard
+	cursor, _ := config.DB.Collection("EventMessageBoard").Find(context.TODO(), EventAnnouncementRepository{}.Visible(filter, time.Now()))
+	cursor.All(context.TODO(), &Announcements)
+	if len(Announcements) == 0 {
+		return Receipts
+	}
+
+	var Reads []models.EventAnnouncementReads
+	readFilter := bson.D{{Key: "event_announcement_reads_announcement", Value: bson.M{"$in": EventAnnouncementSchedulerRepository{}.Ids(Announcements)}}}
+	cursor, _ = config.DB.Collection("EventAnnouncementReads").Find(context.TODO(), readFilter)
+	cursor.All(context.TODO(), &Reads)
+	readAt := map[primitive.ObjectID]map[primitive.ObjectID]primitive.DateTime{}
+	for _, v := range Reads {
+		if readAt[v.EventAnnouncementReadsAnnouncement] == nil {
+			readAt[v.EventAnnouncementReadsAnnouncement] = map[primitive.ObjectID]primitive.DateTime{}
+		}
+		readAt[v.EventAnnouncementReadsAnnouncement][v.EventAnnouncementReadsUser] = v.EventAnnouncementReadsReadAt
+	}
+
+	var userIds []primitive.ObjectID
+	for _, v := range (EventParticipantsRepository{}).ActiveParticipants(eventId) {
+		userIds = append(userIds, v.EventParticipantsUser)
+	}
+	sort.Slice(userIds, func(i, j int) bool { return userIds[i].Hex() < userIds[j].Hex() })
+	UsersDetail := EventSettlementRepository{}.Users(userIds)
+
+	for _, announcement := range Announcements {
+		Receipt := models.EventAnnouncementReceipt{
+			Announcement: announcement.EventMessageBoardId,
+			Category:     announcement.EventMessageBoardCategory,
+			Message:      announcement.EventMessageBoardAnnouncement,
+			PublishedAt:  EventAnnouncementRepository{}.PublishedAt(announcement),
+			Read:         []models.EventAnnouncementReader{},
+			Unread:       []models.EventAnnouncementReader{},
+		}
+		for _, user := range userIds {
+			if user == announcement.EventMessageBoardCreatedBy {
+				continue
+			}
+			Reader := models.EventAnnouncementReader{User: user, UserDetail: UsersDetail[user]}
+			if read, ok := readAt[announcement.EventMessageBoardId][user]; ok {
+				Reader.ReadAt = &read
+				Receipt.Read = append(Receipt.Read, Reader)
+			} else {
+				Receipt.Unread = append(Receipt.Unread, Reader)
+			}
+		}
+		Receipt.ReadCount = len(Receipt.Read)
+		Receipt.Total = len(Receipt.Read) + len(Receipt.Unread)
+		Receipts = append(Receipts, Receipt)
+	}
+	return Receipts
+}
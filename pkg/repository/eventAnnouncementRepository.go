@@ -28,6 +28,10 @@ type EventAnnouncementRequestBulkRequestValidate struct {
 type EventAnnouncementBulkRequest struct {
 	EventMessageBoardBaseMessage []string `json:"event_announcement_message" validate:"required,dive"`
 	EventMessageBoardCategory    string   `json:"event_announcement_category"  validate:"required"`
+	// Optional, RFC3339. Announcements with a future publish time are held
+	// back until the scheduler publishes them.
+	EventAnnouncementPublishAt string `json:"event_announcement_publish_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	EventAnnouncementExpireAt  string `json:"event_announcement_expire_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
 }
 
 type EventAnnouncementResponseObject struct {
@@ -38,12 +42,20 @@ type EventAnnouncementResponseObject struct {
 type EventAnnouncementResponse struct {
 	EventAnnouncementMessage  []string `json:"event_announcement_message"`
 	EventAnnouncementCategory string   `json:"event_announcement_category"`
+	// The id of each message, in the order of the messages, to mark them read
+	EventAnnouncementIds []primitive.ObjectID `json:"event_announcement_ids"`
+	// The mentions of each message, in the order of the messages
+	EventAnnouncementMentions  [][]models.EventMention `json:"event_announcement_mentions"`
+	EventAnnouncementPublishAt primitive.DateTime      `json:"event_announcement_publish_at,omitempty"`
+	EventAnnouncementExpireAt  primitive.DateTime      `json:"event_announcement_expire_at,omitempty"`
+	EventAnnouncementStatus    string                  `json:"event_announcement_status"`
 }
 
 func (r EventAnnouncementRepository) Retrieve(c *gin.Context) {
 	messageCategory := c.Query("category")
 	eventId := helpers.StringToPrimitiveObjId(c.Param("id"))
-	err := EventRepository{}.ReadOne(c, &models.Events{})
+	var Events models.Events
+	err := EventRepository{}.ReadOne(c, &Events)
 	if err != nil {
 		return
 	}
@@ -57,6 +69,12 @@ func (r EventAnnouncementRepository) Retrieve(c *gin.Context) {
 		match = append(match, bson.E{Key: "event_message_board_category", Value: messageCategory})
 	}
 
+	// Organizers also see the scheduled and expired announcements, which they
+	// have to send again when replacing the announcements
+	if !(EventRepository{}).IsOrganizer(c, Events) {
+		match = r.Visible(match, time.Now())
+	}
+
 	criteria := bson.D{{
 		Key: "$match", Value: match,
 	}}
@@ -89,6 +107,34 @@ func (r EventAnnouncementRepository) Retrieve(c *gin.Context) {
 		return
 	}
 
+	EventAnnouncementResponseObject := []EventAnnouncementResponseObject{{
+		EventAnnouncement:          r.Group(results, time.Now()),
+		EventAnnouncementCreatedAt: results[0].EventMessageBoardCreatedAt,
+		EventAnnouncementEventId:   results[0].EventMessageBoardEvent.Hex(),
+	}}
+
+	EventAnnouncementReadRepository{}.Mark(eventId, helpers.GetAuthUser(c).UsersId, r.Published(results, time.Now()))
+	c.JSON(http.StatusOK, EventAnnouncementResponseObject)
+}
+
+// NotifyParticipants tells the participants, other than the author, that
+// announcements were published, whether now or by the scheduler.
+func (r EventAnnouncementRepository) NotifyParticipants(Events models.Events, author primitive.ObjectID) {
+	for _, v := range (EventParticipantsRepository{}).ActiveParticipants(Events.EventsId) {
+		if v.EventParticipantsUser == author {
+			continue
+		}
+		NotificationMessage := models.NotificationMessage{
+			Message: "{0}發布了新公告",
+			Data:    []map[string]interface{}{helpers.NotificationFormatEvent(Events)},
+		}
+		helpers.NotificationsInsert(helpers.NOTIFICATION_EVENT_ANNOUNCEMENT, v.EventParticipantsUser, NotificationMessage, Events.EventsId, author)
+	}
+}
+
+// Group collects the announcements by category, in the order the categories
+// first appear.
+func (r EventAnnouncementRepository) Group(results []models.EventMessageBoard, now time.Time) []EventAnnouncementResponse {
 	var announcementCategory []string
 	var EventAnnouncement []EventAnnouncementResponse
 	for _, v := range results {
@@ -96,26 +142,83 @@ func (r EventAnnouncementRepository) Retrieve(c *gin.Context) {
 		if idx == -1 {
 			announcementCategory = append(announcementCategory, v.EventMessageBoardCategory)
 			EventAnnouncement = append(EventAnnouncement, EventAnnouncementResponse{
-				EventAnnouncementMessage:  []string{v.EventMessageBoardAnnouncement},
-				EventAnnouncementCategory: v.EventMessageBoardCategory,
+				EventAnnouncementMessage:   []string{v.EventMessageBoardAnnouncement},
+				EventAnnouncementCategory:  v.EventMessageBoardCategory,
+				EventAnnouncementIds:       []primitive.ObjectID{v.EventMessageBoardId},
+				EventAnnouncementMentions:  [][]models.EventMention{r.Mentions(v)},
+				EventAnnouncementPublishAt: v.EventMessageBoardPublishAt,
+				EventAnnouncementExpireAt:  v.EventMessageBoardExpireAt,
+				EventAnnouncementStatus:    r.Status(v, now),
 			})
 		} else {
 			EventAnnouncement[idx].EventAnnouncementMessage = append(EventAnnouncement[idx].EventAnnouncementMessage, v.EventMessageBoardAnnouncement)
+			EventAnnouncement[idx].EventAnnouncementIds = append(EventAnnouncement[idx].EventAnnouncementIds, v.EventMessageBoardId)
+			EventAnnouncement[idx].EventAnnouncementMentions = append(EventAnnouncement[idx].EventAnnouncementMentions, r.Mentions(v))
 		}
 	}
+	return EventAnnouncement
+}
 
-	EventAnnouncementResponseObject := []EventAnnouncementResponseObject{{
-		EventAnnouncement:          EventAnnouncement,
-		EventAnnouncementCreatedAt: results[0].EventMessageBoardCreatedAt,
-		EventAnnouncementEventId:   results[0].EventMessageBoardEvent.Hex(),
-	}}
+// Published leaves out the scheduled and expired announcements, which only
+// the organizers see.
+func (r EventAnnouncementRepository) Published(results []models.EventMessageBoard, now time.Time) []models.EventMessageBoard {
+	var published []models.EventMessageBoard
+	for _, v := range results {
+		if r.Status(v, now) == helpers.ANNOUNCEMENT_STATUS_PUBLISHED {
+			published = append(published, v)
+		}
+	}
+	return published
+}
 
-	c.JSON(http.StatusOK, EventAnnouncementResponseObject)
+// Visible narrows the filter to the announcements the participants can see:
+// published and not expired.
+func (r EventAnnouncementRepository) Visible(match bson.D, now time.Time) bson.D {
+	return append(match,
+		bson.E{Key: "event_message_board_scheduled", Value: bson.M{"$ne": true}},
+		bson.E{Key: "$or", Value: bson.A{
+			bson.M{"event_message_board_expire_at": bson.M{"$exists": false}},
+			bson.M{"event_message_board_expire_at": bson.M{"$gt": primitive.NewDateTimeFromTime(now)}},
+		}},
+	)
+}
+
+func (r EventAnnouncementRepository) Status(EventMessageBoard models.EventMessageBoard, now time.Time) string {
+	if EventMessageBoard.EventMessageBoardScheduled {
+		return helpers.ANNOUNCEMENT_STATUS_SCHEDULED
+	}
+	expireAt := EventMessageBoard.EventMessageBoardExpireAt
+	if expireAt != 0 && !expireAt.Time().After(now) {
+		return helpers.ANNOUNCEMENT_STATUS_EXPIRED
+	}
+	return helpers.ANNOUNCEMENT_STATUS_PUBLISHED
+}
+
+// PublishedAt is when the announcement went out: its publish time when it was
+// scheduled, otherwise when it was created.
+func (r EventAnnouncementRepository) PublishedAt(EventMessageBoard models.EventMessageBoard) primitive.DateTime {
+	if EventMessageBoard.EventMessageBoardPublishAt > EventMessageBoard.EventMessageBoardCreatedAt {
+		return EventMessageBoard.EventMessageBoardPublishAt
+	}
+	return EventMessageBoard.EventMessageBoardCreatedAt
+}
+
+func (r EventAnnouncementRepository) Mentions(EventMessageBoard models.EventMessageBoard) []models.EventMention {
+	if EventMessageBoard.EventMessageBoardMentions == nil {
+		return []models.EventMention{}
+	}
+	return EventMessageBoard.EventMessageBoardMentions
+}
+
+// Key identifies an announcement by its content when they are replaced.
+func (r EventAnnouncementRepository) Key(category string, message string) string {
+	return category + "\x00" + message
 }
 
 func (r EventAnnouncementRepository) Create(c *gin.Context) {
 	eventId := helpers.StringToPrimitiveObjId(c.Param("id"))
-	err := EventRepository{}.ReadOne(c, &models.Events{})
+	var Events models.Events
+	err := EventRepository{}.ReadOne(c, &Events)
 	if err != nil {
 		return
 	}
@@ -137,9 +240,45 @@ func (r EventAnnouncementRepository) Create(c *gin.Context) {
 		return
 	}
 
+	filterDelete := bson.D{
+		{Key: "event_message_board_event", Value: eventId},
+		{Key: "event_message_board_announcement", Value: bson.M{"$exists": true}},
+	}
+
+	// Announcements are replaced as a whole, so only users who were not
+	// mentioned in the previous ones are notified, and the ones that did not
+	// change keep their id and creation time and stay read
+	var Previous []models.EventMessageBoard
+	cursor, _ := config.DB.Collection("EventMessageBoard").Find(context.TODO(), filterDelete)
+	cursor.All(context.TODO(), &Previous)
+	var previousMentions []models.EventMention
+	previousByKey := map[string][]models.EventMessageBoard{}
+	for _, v := range Previous {
+		if !v.EventMessageBoardScheduled {
+			previousMentions = append(previousMentions, v.EventMessageBoardMentions...)
+		}
+		key := r.Key(v.EventMessageBoardCategory, v.EventMessageBoardAnnouncement)
+		previousByKey[key] = append(previousByKey[key], v)
+	}
+
+	now := time.Now()
 	var strLenErr []string
 	var insertAnnouncement []interface{}
+	var mentions []models.EventMention
+	var published []models.EventMessageBoard
 	for _, v := range payload {
+		var publishAt, expireAt primitive.DateTime
+		if v.EventAnnouncementPublishAt != "" {
+			publishAt = helpers.StringToPrimitiveDateTime(v.EventAnnouncementPublishAt)
+		}
+		if v.EventAnnouncementExpireAt != "" {
+			expireAt = helpers.StringToPrimitiveDateTime(v.EventAnnouncementExpireAt)
+			if !expireAt.Time().After(now) || !expireAt.Time().After(publishAt.Time()) {
+				strLenErr = append(strLenErr, "Category '"+v.EventMessageBoardCategory+"' has to expire after it is published")
+			}
+		}
+		scheduled := publishAt.Time().After(now)
+
 		if len(v.EventMessageBoardBaseMessage) > int(config.APP_LIMIT.EventAnnouncementLimit) {
 			strLenErr = append(strLenErr, "Category '"+v.EventMessageBoardCategory+"' can only contain "+strconv.Itoa(int(config.APP_LIMIT.EventAnnouncementLimit))+" announcements")
 		}
@@ -148,14 +287,35 @@ func (r EventAnnouncementRepository) Create(c *gin.Context) {
 			match, errMessage := helpers.ValidateStringLength(message, int(config.APP_LIMIT.LengthEventMessageBoardMessage))
 			if !match {
 				strLenErr = append(strLenErr, "Message board can only contain "+errMessage)
-			} else {
-				insertAnnouncement = append(insertAnnouncement, models.EventMessageBoard{
-					EventMessageBoardEvent:        eventId,
-					EventMessageBoardAnnouncement: message,
-					EventMessageBoardCategory:     v.EventMessageBoardCategory,
-					EventMessageBoardCreatedBy:    userDetail.UsersId,
-					EventMessageBoardCreatedAt:    primitive.NewDateTimeFromTime(time.Now()),
-				})
+				continue
+			}
+
+			messageMentions := EventMentionRepository{}.Parse(eventId, message)
+			id := primitive.NewObjectID()
+			createdAt := primitive.NewDateTimeFromTime(time.Now())
+			key := r.Key(v.EventMessageBoardCategory, message)
+			if len(previousByKey[key]) > 0 {
+				id = previousByKey[key][0].EventMessageBoardId
+				createdAt = previousByKey[key][0].EventMessageBoardCreatedAt
+				previousByKey[key] = previousByKey[key][1:]
+			}
+			insert := models.EventMessageBoard{
+				EventMessageBoardId:           id,
+				EventMessageBoardEvent:        eventId,
+				EventMessageBoardAnnouncement: message,
+				EventMessageBoardCategory:     v.EventMessageBoardCategory,
+				EventMessageBoardCreatedBy:    userDetail.UsersId,
+				EventMessageBoardCreatedAt:    createdAt,
+				EventMessageBoardMentions:     messageMentions,
+				EventMessageBoardPublishAt:    publishAt,
+				EventMessageBoardExpireAt:     expireAt,
+				EventMessageBoardScheduled:    scheduled,
+			}
+			insertAnnouncement = append(insertAnnouncement, insert)
+			// Scheduled announcements are notified when they are published
+			if !scheduled {
+				mentions = append(mentions, messageMentions...)
+				published = append(published, insert)
 			}
 		}
 	}
@@ -165,12 +325,19 @@ func (r EventAnnouncementRepository) Create(c *gin.Context) {
 		return
 	}
 
-	filterDelete := bson.D{
-		{Key: "event_message_board_event", Value: eventId},
-		{Key: "event_message_board_announcement", Value: bson.M{"$exists": true}},
-	}
 	config.DB.Collection("EventMessageBoard").DeleteMany(context.TODO(), filterDelete)
 	config.DB.Collection("EventMessageBoard").InsertMany(context.TODO(), insertAnnouncement)
+	var removed []primitive.ObjectID
+	for _, v := range previousByKey {
+		removed = append(removed, EventAnnouncementSchedulerRepository{}.Ids(v)...)
+	}
+	EventAnnouncementReadRepository{}.Forget(removed)
+
+	EventMentionRepository{}.Notify(c, Events, mentions, previousMentions)
+	if len(published) > 0 {
+		r.NotifyParticipants(Events, userDetail.UsersId)
+		helpers.EventStreamPublish(eventId, helpers.EVENT_STREAM_ANNOUNCEMENT_CREATED, r.Group(published, now))
+	}
 
 	r.Retrieve(c)
 
@@ -213,17 +380,32 @@ func (r EventAnnouncementRepository) Create(c *gin.Context) {
 	c.JSON(http.StatusOK, EventMessageBoard)*/
 }
 
+// Read returns one announcement. Participants other than the organizers only
+// see it while it is published.
 func (r EventAnnouncementRepository) Read(c *gin.Context) {
-	err := EventRepository{}.ReadOne(c, &models.Events{})
+	var Events models.Events
+	err := EventRepository{}.ReadOne(c, &Events)
 	if err != nil {
 		return
 	}
 
+	filter := bson.D{
+		{Key: "_id", Value: helpers.StringToPrimitiveObjId(c.Param("messageBoardId"))},
+		{Key: "event_message_board_event", Value: Events.EventsId},
+	}
+	if !(EventRepository{}).IsOrganizer(c, Events) {
+		filter = r.Visible(filter, time.Now())
+	}
 	var EventMessageBoard models.EventMessageBoard
-	errMb := r.ReadOne(c, &EventMessageBoard)
-	if errMb == nil {
-		c.JSON(http.StatusOK, EventMessageBoard)
+	err = config.DB.Collection("EventMessageBoard").FindOne(context.TODO(), filter).Decode(&EventMessageBoard)
+	if err != nil {
+		helpers.ResultEmpty(c, err)
+		return
 	}
+	if EventMessageBoard.EventMessageBoardAnnouncement != "" {
+		EventAnnouncementReadRepository{}.Mark(Events.EventsId, helpers.GetAuthUser(c).UsersId, r.Published([]models.EventMessageBoard{EventMessageBoard}, time.Now()))
+	}
+	c.JSON(http.StatusOK, EventMessageBoard)
 }
 
 func (r EventAnnouncementRepository) ReadOne(c *gin.Context, EventMessageBoard *models.EventMessageBoard) error {
@@ -238,7 +420,8 @@ func (r EventAnnouncementRepository) ReadOne(c *gin.Context, EventMessageBoard *
 }
 
 func (r EventAnnouncementRepository) Update(c *gin.Context) {
-	err := EventRepository{}.ReadOne(c, &models.Events{})
+	var Events models.Events
+	err := EventRepository{}.ReadOne(c, &Events)
 	if err != nil {
 		return
 	}
@@ -258,10 +441,27 @@ func (r EventAnnouncementRepository) Update(c *gin.Context) {
 	var EventMessageBoard models.EventMessageBoard
 	errMb := r.ReadOne(c, &EventMessageBoard)
 	if errMb == nil {
+		mentions := EventMentionRepository{}.Parse(Events.EventsId, payload.EventMessageBoardAnnouncement)
+
+		// Only users who were not mentioned before are notified of the edit
+		previous := EventMessageBoard.EventMessageBoardMentions
+		changed := EventMessageBoard.EventMessageBoardAnnouncement != payload.EventMessageBoardAnnouncement
 		r.ProcessData(c, &EventMessageBoard, payload)
+		EventMessageBoard.EventMessageBoardMentions = mentions
 		filters := bson.D{{Key: "_id", Value: EventMessageBoard.EventMessageBoardId}, {Key: "event_message_board_event", Value: EventMessageBoard.EventMessageBoardEvent}}
 		upd := bson.D{{Key: "$set", Value: EventMessageBoard}}
+		if len(mentions) == 0 {
+			upd = append(upd, bson.E{Key: "$unset", Value: bson.M{"event_message_board_mentions": ""}})
+		}
 		config.DB.Collection("EventMessageBoard").UpdateOne(context.TODO(), filters, upd)
+		// A changed announcement has to be read again
+		if changed {
+			EventAnnouncementReadRepository{}.Forget([]primitive.ObjectID{EventMessageBoard.EventMessageBoardId})
+		}
+		// Scheduled announcements are notified when they are published
+		if !EventMessageBoard.EventMessageBoardScheduled {
+			EventMentionRepository{}.Notify(c, Events, mentions, previous)
+		}
 		c.JSON(http.StatusOK, EventMessageBoard)
 	}
 }
@@ -277,6 +477,7 @@ func (r EventAnnouncementRepository) Delete(c *gin.Context) {
 	if errMb == nil {
 		filters := bson.D{{Key: "_id", Value: EventMessageBoard.EventMessageBoardId}}
 		config.DB.Collection("EventMessageBoard").DeleteOne(context.TODO(), filters)
+		EventAnnouncementReadRepository{}.Forget([]primitive.ObjectID{EventMessageBoard.EventMessageBoardId})
 		helpers.ResultMessageSuccess(c, "Message board record deleted")
 	}
 }
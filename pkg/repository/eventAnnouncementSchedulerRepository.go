@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventAnnouncementSchedulerRepository publishes the scheduled announcements
+// once their publish time has passed. It runs in the background of every
+// instance; an announcement is claimed before it is published, so each one
+// is only notified once.
+type EventAnnouncementSchedulerRepository struct{}
+
+const eventAnnouncementSchedulerInterval = time.Minute
+
+// Run publishes the due announcements on every tick until the process exits.
+func (r EventAnnouncementSchedulerRepository) Run() {
+	interval := time.Duration(config.APP_LIMIT.AnnouncementSchedulerSeconds) * time.Second
+	if interval <= 0 {
+		interval = eventAnnouncementSchedulerInterval
+	}
+
+	for {
+		r.Tick(time.Now())
+		time.Sleep(interval)
+	}
+}
+
+// Tick runs one pass, recovering from a panic so the next tick still runs.
+func (r EventAnnouncementSchedulerRepository) Tick(now time.Time) {
+	defer func() {
+		if err := recover(); err != nil {
+			fmt.Println("announcement scheduler panic:", err)
+		}
+	}()
+	r.Publish(now)
+}
+
+// Publish publishes the scheduled announcements due at now, then notifies the
+// participants of each event once.
+func (r EventAnnouncementSchedulerRepository) Publish(now time.Time) {
+	filter := bson.D{
+		{Key: "event_message_board_scheduled", Value: true},
+		{Key: "event_message_board_publish_at", Value: bson.M{"$lte": primitive.NewDateTimeFromTime(now)}},
+	}
+	var Due []models.EventMessageBoard
+	cursor, err := config.DB.Collection("EventMessageBoard").Find(context.TODO(), filter)
+	if err != nil {
+		fmt.Println("announcement scheduler err: " + err.Error())
+		return
+	}
+	cursor.All(context.TODO(), &Due)
+
+	var eventIds []primitive.ObjectID
+	published := map[primitive.ObjectID][]models.EventMessageBoard{}
+	for _, v := range Due {
+		// Another instance may have published it in the meantime
+		claim := bson.D{
+			{Key: "_id", Value: v.EventMessageBoardId},
+			{Key: "event_message_board_scheduled", Value: true},
+		}
+		upd := bson.D{{Key: "$unset", Value: bson.M{"event_message_board_scheduled": ""}}}
+		result, err := config.DB.Collection("EventMessageBoard").UpdateOne(context.TODO(), claim, upd)
+		if err != nil || result.ModifiedCount == 0 {
+			continue
+		}
+
+		v.EventMessageBoardScheduled = false
+		if _, ok := published[v.EventMessageBoardEvent]; !ok {
+			eventIds = append(eventIds, v.EventMessageBoardEvent)
+		}
+		published[v.EventMessageBoardEvent] = append(published[v.EventMessageBoardEvent], v)
+	}
+
+	for _, eventId := range eventIds {
+		r.Notify(eventId, published[eventId], now)
+	}
+}
+
+// Notify tells the participants, other than the author, that announcements
+// were published, notifies their mentions and pushes the announcements to the
+// event stream. Push notifications are written to a response header, so a
+// background job can only create the in-app notifications.
+func (r EventAnnouncementSchedulerRepository) Notify(eventId primitive.ObjectID, announcements []models.EventMessageBoard, now time.Time) {
+	var visible []models.EventMessageBoard
+	for _, v := range announcements {
+		if (EventAnnouncementRepository{}).Status(v, now) == helpers.ANNOUNCEMENT_STATUS_PUBLISHED {
+			visible = append(visible, v)
+		}
+	}
+	// Announcements that expired while waiting are published silently
+	if len(visible) == 0 {
+		return
+	}
+
+	var Event models.Events
+	err := config.DB.Collection("Events").FindOne(context.TODO(), bson.D{{Key: "_id", Value: eventId}}).Decode(&Event)
+	if err != nil {
+		return
+	}
+
+	EventAnnouncementRepository{}.NotifyParticipants(Event, visible[0].EventMessageBoardCreatedBy)
+
+	// Users mentioned in announcements published before were already notified
+	var Previous []models.EventMessageBoard
+	previousFilter := bson.D{
+		{Key: "event_message_board_event", Value: eventId},
+		{Key: "event_message_board_announcement", Value: bson.M{"$exists": true}},
+		{Key: "event_message_board_scheduled", Value: bson.M{"$ne": true}},
+		{Key: "_id", Value: bson.M{"$nin": r.Ids(announcements)}},
+	}
+	previousCursor, _ := config.DB.Collection("EventMessageBoard").Find(context.TODO(), previousFilter)
+	previousCursor.All(context.TODO(), &Previous)
+	var mentions, previousMentions []models.EventMention
+	for _, v := range visible {
+		mentions = append(mentions, v.EventMessageBoardMentions...)
+	}
+	for _, v := range Previous {
+		previousMentions = append(previousMentions, v.EventMessageBoardMentions...)
+	}
+	var Author models.Users
+	config.DB.Collection("Users").FindOne(context.TODO(), bson.D{{Key: "_id", Value: visible[0].EventMessageBoardCreatedBy}}).Decode(&Author)
+	EventMentionRepository{}.NotifyAs(Author, Event, mentions, previousMentions)
+
+	// Clients replace their announcements with the streamed ones, so the
+	// announcements published earlier are sent along
+	filter := bson.D{
+		{Key: "event_message_board_event", Value: eventId},
+		{Key: "event_message_board_announcement", Value: bson.M{"$exists": true}},
+	}
+	var Announcements []models.EventMessageBoard
+	cursor, _ := config.DB.Collection("EventMessageBoard").Find(context.TODO(), EventAnnouncementRepository{}.Visible(filter, now))
+	cursor.All(context.TODO(), &Announcements)
+	helpers.EventStreamPublish(eventId, helpers.EVENT_STREAM_ANNOUNCEMENT_CREATED, EventAnnouncementRepository{}.Group(Announcements, now))
+}
+
+func (r EventAnnouncementSchedulerRepository) Ids(announcements []models.EventMessageBoard) []primitive.ObjectID {
+	ids := []primitive.ObjectID{}
+	for _, v := range announcements {
+		ids = append(ids, v.EventMessageBoardId)
+	}
+	return ids
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EventBudgetRepository handles the estimated budget of an event and the
+// per person deposit. Deposits are paid to the collector, the event owner
+// unless set, and count as settled transfers in the accounting settlement.
+type EventBudgetRepository struct{}
+type EventBudgetRequest struct {
+	EventBudgetsItems            []EventBudgetItemRequest `json:"event_budgets_items" validate:"omitempty,dive"`
+	EventBudgetsDepositAmount    float64                  `json:"event_budgets_deposit_amount" validate:"gte=0,max=999999"`
+	EventBudgetsDepositCollector string                   `json:"event_budgets_deposit_collector"`
+}
+type EventBudgetItemRequest struct {
+	EventBudgetsItemCategory    string  `json:"event_budgets_item_category" validate:"required,oneof=TRANSPORT LODGING PERMITS FOOD GEAR OTHER"`
+	EventBudgetsItemDescription string  `json:"event_budgets_item_description"`
+	EventBudgetsItemAmount      float64 `json:"event_budgets_item_amount" validate:"gte=0,max=999999"`
+}
+type EventDepositRequest struct {
+	EventDepositsUser   string  `json:"event_deposits_user" validate:"required"`
+	EventDepositsAmount float64 `json:"event_deposits_amount" validate:"omitempty,gt=0"`
+}
+
+// Read returns the budget next to the actual spend and the deposits.
+func (r EventBudgetRepository) Read(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	var EventBudgets models.EventBudgets
+	err = r.ReadOne(Event.EventsId, &EventBudgets)
+	if err != nil {
+		helpers.ResponseNotFound(c, "Budget not found")
+		return
+	}
+	c.JSON(http.StatusOK, r.Calculate(Event, EventBudgets))
+}
+
+func (r EventBudgetRepository) ReadOne(eventId primitive.ObjectID, EventBudgets *models.EventBudgets) error {
+	return config.DB.Collection("EventBudgets").FindOne(context.TODO(), bson.D{{Key: "event_budgets_event", Value: eventId}}).Decode(EventBudgets)
+}
+
+// Update publishes the budget, replacing the previous items.
+func (r EventBudgetRepository) Update(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	var payload EventBudgetRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	collector := Event.EventsCreatedBy
+	if payload.EventBudgetsDepositCollector != "" {
+		collector = helpers.StringToPrimitiveObjId(payload.EventBudgetsDepositCollector)
+		role := helpers.EventRole(Event.EventsId, collector)
+		if !helpers.EventRoleIn(role, []string{helpers.EVENT_ROLE_OWNER, helpers.EVENT_ROLE_COHOST}) {
+			helpers.ResponseBadRequestError(c, "Deposit collector must be an event organizer")
+			return
+		}
+	}
+
+	items := []models.EventBudgetsItem{}
+	for _, v := range payload.EventBudgetsItems {
+		items = append(items, models.EventBudgetsItem{
+			EventBudgetsItemCategory:    v.EventBudgetsItemCategory,
+			EventBudgetsItemDescription: v.EventBudgetsItemDescription,
+			EventBudgetsItemAmount:      v.EventBudgetsItemAmount,
+		})
+	}
+
+	var EventBudgets models.EventBudgets
+	err = r.ReadOne(Event.EventsId, &EventBudgets)
+	if err != nil {
+		EventBudgets = models.EventBudgets{
+			EventBudgetsEvent:     Event.EventsId,
+			EventBudgetsCreatedBy: userDetail.UsersId,
+			EventBudgetsCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+		}
+	} else {
+		EventBudgets.EventBudgetsUpdatedBy = userDetail.UsersId
+		EventBudgets.EventBudgetsUpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+	}
+	EventBudgets.EventBudgetsItems = items
+	EventBudgets.EventBudgetsDepositAmount = payload.EventBudgetsDepositAmount
+	EventBudgets.EventBudgetsDepositCollector = collector
+
+	filters := bson.D{{Key: "event_budgets_event", Value: Event.EventsId}}
+	upd := bson.D{{Key: "$set", Value: EventBudgets}}
+	_, err = config.DB.Collection("EventBudgets").UpdateOne(context.TODO(), filters, upd, options.Update().SetUpsert(true))
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	r.Read(c)
+}
+
+// Delete removes the budget. Deposits already paid are kept, since the money
+// has changed hands and still needs to be settled.
+func (r EventBudgetRepository) Delete(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	result, _ := config.DB.Collection("EventBudgets").DeleteOne(context.TODO(), bson.D{{Key: "event_budgets_event", Value: Event.EventsId}})
+	if result == nil || result.DeletedCount == 0 {
+		helpers.ResponseNotFound(c, "Budget not found")
+		return
+	}
+	helpers.ResultMessageSuccess(c, "Budget deleted")
+}
+
+// CreateDeposit marks a participant's deposit as paid to the collector, for
+// the outstanding deposit or part of it.
+func (r EventBudgetRepository) CreateDeposit(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	var EventBudgets models.EventBudgets
+	err = r.ReadOne(Event.EventsId, &EventBudgets)
+	if err != nil || EventBudgets.EventBudgetsDepositAmount <= 0 {
+		helpers.ResponseBadRequestError(c, "This event does not collect a deposit")
+		return
+	}
+
+	var payload EventDepositRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	user := helpers.StringToPrimitiveObjId(payload.EventDepositsUser)
+	if helpers.EventRole(Event.EventsId, user) == "" {
+		helpers.ResponseBadRequestError(c, "User is not an event participant")
+		return
+	}
+	if user == EventBudgets.EventBudgetsDepositCollector {
+		helpers.ResponseBadRequestError(c, "The deposit collector does not pay a deposit")
+		return
+	}
+
+	paid := r.Paid(Event.EventsId)
+	outstanding := helpers.SettlementCents(EventBudgets.EventBudgetsDepositAmount) - paid[user]
+	if outstanding <= 0 {
+		helpers.ResponseBadRequestError(c, "Deposit is already paid")
+		return
+	}
+
+	amount := outstanding
+	if payload.EventDepositsAmount > 0 {
+		amount = helpers.SettlementCents(payload.EventDepositsAmount)
+	}
+	if amount > outstanding {
+		helpers.ResponseBadRequestError(c, "Amount is more than the outstanding deposit")
+		return
+	}
+
+	insert := models.EventDeposits{
+		EventDepositsEvent:     Event.EventsId,
+		EventDepositsUser:      user,
+		EventDepositsCollector: EventBudgets.EventBudgetsDepositCollector,
+		EventDepositsAmount:    helpers.SettlementAmount(amount),
+		EventDepositsCreatedBy: userDetail.UsersId,
+		EventDepositsCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+	_, err = config.DB.Collection("EventDeposits").InsertOne(context.TODO(), insert)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	r.Read(c)
+}
+
+// DeleteDeposit reverts a deposit that was marked by mistake.
+func (r EventBudgetRepository) DeleteDeposit(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	filter := bson.D{
+		{Key: "_id", Value: helpers.StringToPrimitiveObjId(c.Param("depositId"))},
+		{Key: "event_deposits_event", Value: Event.EventsId},
+	}
+	result, _ := config.DB.Collection("EventDeposits").DeleteOne(context.TODO(), filter)
+	if result == nil || result.DeletedCount == 0 {
+		helpers.ResponseNotFound(c, "Deposit not found")
+		return
+	}
+	r.Read(c)
+}
+
+func (r EventBudgetRepository) Deposits(eventId primitive.ObjectID) []models.EventDeposits {
+	EventDeposits := []models.EventDeposits{}
+	opts := options.Find().SetSort(bson.D{{Key: "event_deposits_created_at", Value: 1}})
+	cursor, _ := config.DB.Collection("EventDeposits").Find(context.TODO(), bson.D{{Key: "event_deposits_event", Value: eventId}}, opts)
+	cursor.All(context.TODO(), &EventDeposits)
+	return EventDeposits
+}
+
+// Paid returns the deposits paid by each user, in cents.
+func (r EventBudgetRepository) Paid(eventId primitive.ObjectID) map[primitive.ObjectID]int64 {
+	paid := map[primitive.ObjectID]int64{}
+	for _, v := range r.Deposits(eventId) {
+		paid[v.EventDepositsUser] += helpers.SettlementCents(v.EventDepositsAmount)
+	}
+	return paid
+}
+
+func (r EventBudgetRepository) Calculate(Event models.Events, EventBudgets models.EventBudgets) models.EventBudget {
+	Budget := models.EventBudget{
+		Currency:   EventAccountingRepository{}.Currency(Event),
+		Budget:     EventBudgets,
+		Categories: []models.EventBudgetCategory{},
+		Deposits:   []models.EventBudgetDeposit{},
+		Payments:   r.Deposits(Event.EventsId),
+	}
+
+	estimated := map[string]int64{}
+	actual := map[string]int64{}
+	for _, v := range EventBudgets.EventBudgetsItems {
+		estimated[v.EventBudgetsItemCategory] += helpers.SettlementCents(v.EventBudgetsItemAmount)
+	}
+
+	var EventAccounting []models.EventAccounting
+	cursor, _ := config.DB.Collection("EventAccounting").Find(context.TODO(), bson.D{{Key: "event_accounting_event", Value: Event.EventsId}})
+	cursor.All(context.TODO(), &EventAccounting)
+	for _, v := range EventAccounting {
+		category := v.EventAccountingCategory
+		if category == "" {
+			category = helpers.BUDGET_CATEGORY_OTHER
+		}
+		actual[category] += helpers.SettlementCents(EventAccountingRepository{}.BaseAmount(v))
+	}
+
+	var totalEstimated, totalActual int64
+	for _, category := range helpers.BudgetCategories {
+		if estimated[category] == 0 && actual[category] == 0 {
+			continue
+		}
+		totalEstimated += estimated[category]
+		totalActual += actual[category]
+		Budget.Categories = append(Budget.Categories, models.EventBudgetCategory{
+			Category:  category,
+			Estimated: helpers.SettlementAmount(estimated[category]),
+			Actual:    helpers.SettlementAmount(actual[category]),
+			Variance:  helpers.SettlementAmount(actual[category] - estimated[category]),
+		})
+	}
+	Budget.Estimated = helpers.SettlementAmount(totalEstimated)
+	Budget.Actual = helpers.SettlementAmount(totalActual)
+	Budget.Variance = helpers.SettlementAmount(totalActual - totalEstimated)
+
+	participants := EventAccountingRepository{}.Participants(Event.EventsId)
+	if len(participants) > 0 {
+		Budget.PerPerson = helpers.SettlementAmount(totalEstimated / int64(len(participants)))
+	}
+
+	paid := r.Paid(Event.EventsId)
+	users := map[primitive.ObjectID]bool{}
+	for _, v := range participants {
+		if v != EventBudgets.EventBudgetsDepositCollector {
+			users[v] = true
+		}
+	}
+	for user := range paid {
+		users[user] = true
+	}
+
+	var userIds []primitive.ObjectID
+	for user := range users {
+		userIds = append(userIds, user)
+	}
+	sort.Slice(userIds, func(i, j int) bool { return userIds[i].Hex() < userIds[j].Hex() })
+	UsersDetail := EventSettlementRepository{}.Users(userIds)
+
+	due := helpers.SettlementCents(EventBudgets.EventBudgetsDepositAmount)
+	var totalDue, totalPaid int64
+	for _, user := range userIds {
+		totalDue += due
+		totalPaid += paid[user]
+		Budget.Deposits = append(Budget.Deposits, models.EventBudgetDeposit{
+			User:        user,
+			UserDetail:  UsersDetail[user],
+			Due:         helpers.SettlementAmount(due),
+			Paid:        helpers.SettlementAmount(paid[user]),
+			Outstanding: helpers.SettlementAmount(max(due-paid[user], 0)),
+		})
+	}
+	for k, v := range Budget.Payments {
+		Budget.Payments[k].EventDepositsUserAgg = UsersDetail[v.EventDepositsUser]
+	}
+	Budget.DepositTotal = helpers.SettlementAmount(totalDue)
+	Budget.DepositPaid = helpers.SettlementAmount(totalPaid)
+	return Budget
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/arwoosa/notifaction/helper"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const refundStatusPending = "PENDING"
+
+type EventCancellationRepository struct{}
+type EventCancellationRequest struct {
+	EventsCancelledReason string `json:"events_cancelled_reason" validate:"required"`
+}
+
+// Create cancels the event. Unlike deleting, a cancelled event stays in the
+// participants' history, and paid participants are flagged for a refund.
+func (r EventCancellationRepository) Create(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Events models.Events
+	err := EventRepository{}.ReadOne(c, &Events)
+	if err != nil {
+		return
+	}
+
+	var payload EventCancellationRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	if r.IsCancelled(Events) {
+		helpers.ResponseBadRequestError(c, "This event has already been cancelled")
+		return
+	}
+
+	match, errMessage := helpers.ValidateStringLength(payload.EventsCancelledReason, int(config.APP_LIMIT.LengthEventParticipantMessage))
+	if !match {
+		helpers.ResponseBadRequestError(c, "Reason can only contain "+errMessage)
+		return
+	}
+
+	cancelled := true
+	Events.EventsCancelled = &cancelled
+	Events.EventsCancelledReason = payload.EventsCancelledReason
+	Events.EventsCancelledBy = userDetail.UsersId
+	Events.EventsCancelledAt = primitive.NewDateTimeFromTime(time.Now())
+
+	filters := bson.D{{Key: "_id", Value: Events.EventsId}}
+	upd := bson.D{{Key: "$set", Value: bson.M{
+		"events_cancelled":        Events.EventsCancelled,
+		"events_cancelled_reason": Events.EventsCancelledReason,
+		"events_cancelled_by":     Events.EventsCancelledBy,
+		"events_cancelled_at":     Events.EventsCancelledAt,
+	}}}
+	config.DB.Collection("Events").UpdateOne(context.TODO(), filters, upd)
+
+	refundFilter := bson.D{
+		{Key: "event_participants_event", Value: Events.EventsId},
+		{Key: "event_participants_is_paid", Value: 1},
+	}
+	refundUpd := bson.D{{Key: "$set", Value: bson.M{"event_participants_refund_status": refundStatusPending}}}
+	config.DB.Collection("EventParticipants").UpdateMany(context.TODO(), refundFilter, refundUpd)
+
+	NotificationMessage := models.NotificationMessage{
+		Message: "團主已取消{0}: " + Events.EventsCancelledReason,
+		Data:    []map[string]interface{}{helpers.NotificationFormatEvent(Events)},
+	}
+	r.Notify(c, Events, helpers.NOTIFICATION_EVENT_CANCELLED, NotificationMessage)
+
+	c.JSON(http.StatusOK, Events)
+}
+
+// GracePeriod is how long a cancellation can be undone, 24 hours when
+// EVENT_CANCEL_GRACE_PERIOD_HOURS is not set.
+func (r EventCancellationRepository) GracePeriod() time.Duration {
+	if config.APP_LIMIT.EventCancelGracePeriodHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(config.APP_LIMIT.EventCancelGracePeriodHours) * time.Hour
+}
+
+// Delete restores a cancelled event, provided the grace period since the
+// cancellation has not passed.
+func (r EventCancellationRepository) Delete(c *gin.Context) {
+	var Events models.Events
+	err := EventRepository{}.ReadOne(c, &Events)
+	if err != nil {
+		return
+	}
+
+	if !r.IsCancelled(Events) {
+		helpers.ResponseBadRequestError(c, "This event has not been cancelled")
+		return
+	}
+
+	gracePeriod := r.GracePeriod()
+	if time.Since(Events.EventsCancelledAt.Time()) > gracePeriod {
+		helpers.ResponseBadRequestError(c, "Events can only be restored within "+strconv.Itoa(int(gracePeriod.Hours()))+" hours of cancelling")
+		return
+	}
+
+	filters := bson.D{{Key: "_id", Value: Events.EventsId}}
+	upd := bson.D{{Key: "$unset", Value: bson.M{
+		"events_cancelled":        "",
+		"events_cancelled_reason": "",
+		"events_cancelled_by":     "",
+		"events_cancelled_at":     "",
+	}}}
+	config.DB.Collection("Events").UpdateOne(context.TODO(), filters, upd)
+
+	refundFilter := bson.D{
+		{Key: "event_participants_event", Value: Events.EventsId},
+		{Key: "event_participants_refund_status", Value: refundStatusPending},
+	}
+	refundUpd := bson.D{{Key: "$unset", Value: bson.M{"event_participants_refund_status": ""}}}
+	config.DB.Collection("EventParticipants").UpdateMany(context.TODO(), refundFilter, refundUpd)
+
+	NotificationMessage := models.NotificationMessage{
+		Message: "團主已恢復{0}, 活動將照常舉行",
+		Data:    []map[string]interface{}{helpers.NotificationFormatEvent(Events)},
+	}
+	r.Notify(c, Events, helpers.NOTIFICATION_EVENT_RESTORED, NotificationMessage)
+
+	EventRepository{}.Read(c)
+}
+
+func (r EventCancellationRepository) IsCancelled(Events models.Events) bool {
+	return Events.EventsCancelled != nil && *Events.EventsCancelled
+}
+
+func (r EventCancellationRepository) Notify(c *gin.Context, Events models.Events, code string, NotificationMessage models.NotificationMessage) {
+	userDetail := helpers.GetAuthUser(c)
+	notifyData := map[string]string{
+		"events_name": Events.EventsName,
+	}
+
+	var notifyMsg helper.NotifyMsg
+	var err error
+	for _, v := range (EventParticipantsRepository{}).ActiveParticipants(Events.EventsId) {
+		if v.EventParticipantsUser == userDetail.UsersId {
+			continue
+		}
+		helpers.NotificationsCreate(c, code, v.EventParticipantsUser, NotificationMessage, Events.EventsId)
+
+		if notifyMsg == nil {
+			notifyMsg, err = helper.NewNotifyMsg(code, userDetail.UsersId, v.EventParticipantsUser, notifyData, helpers.FindUserSourceId)
+			if err != nil {
+				fmt.Println("new notify msg err: " + err.Error())
+			}
+		} else {
+			notifyMsg.AddTo(v.EventParticipantsUser)
+		}
+	}
+
+	if notifyMsg != nil {
+		notifyMsg.WriteToHeader(c, config.APP.NotificationHeaderName)
+	}
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EventHistoryRepository struct{}
+
+func (r EventHistoryRepository) Retrieve(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	agg := mongo.Pipeline{
+		bson.D{{
+			Key: "$match", Value: bson.M{"event_history_event": Event.EventsId},
+		}},
+		bson.D{{
+			Key: "$sort", Value: bson.M{"event_history_version": -1},
+		}},
+		bson.D{{
+			Key: "$lookup", Value: bson.M{
+				"from":         "Users",
+				"localField":   "event_history_created_by",
+				"foreignField": "_id",
+				"as":           "event_history_created_by_user",
+			},
+		}},
+		bson.D{{
+			Key: "$unwind", Value: bson.M{"path": "$event_history_created_by_user", "preserveNullAndEmptyArrays": true},
+		}},
+	}
+
+	var results []models.EventHistory
+	cursor, err := config.DB.Collection("EventHistory").Aggregate(context.TODO(), agg)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	cursor.All(context.TODO(), &results)
+
+	if len(results) == 0 {
+		helpers.ResponseNoData(c, "No Data")
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// Record stores a new version of the event when any of the fields that
+// matter to participants have changed. The returned history has no changes
+// when nothing was recorded.
+func (r EventHistoryRepository) Record(c *gin.Context, Previous models.Events, Events models.Events) models.EventHistory {
+	userDetail := helpers.GetAuthUser(c)
+	History := models.EventHistory{
+		EventHistoryEvent:     Events.EventsId,
+		EventHistoryChanges:   r.Diff(Previous, Events),
+		EventHistoryCreatedBy: userDetail.UsersId,
+		EventHistoryCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+	if len(History.EventHistoryChanges) == 0 {
+		return History
+	}
+
+	var Latest models.EventHistory
+	filter := bson.D{{Key: "event_history_event", Value: Events.EventsId}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "event_history_version", Value: -1}})
+	config.DB.Collection("EventHistory").FindOne(context.TODO(), filter, opts).Decode(&Latest)
+	History.EventHistoryVersion = Latest.EventHistoryVersion + 1
+
+	result, err := config.DB.Collection("EventHistory").InsertOne(context.TODO(), History)
+	if err == nil {
+		History.EventHistoryId = result.InsertedID.(primitive.ObjectID)
+	}
+	return History
+}
+
+// Diff compares the date, meeting point, fee and participant limit of two
+// versions of an event.
+func (r EventHistoryRepository) Diff(Previous models.Events, Events models.Events) []models.EventHistoryChange {
+	fields := []models.EventHistoryChange{
+		{Field: "events_date", From: Previous.EventsDate, To: Events.EventsDate},
+		{Field: "events_date_end", From: Previous.EventsDateEnd, To: Events.EventsDateEnd},
+		{Field: "events_meeting_point_name", From: Previous.EventsMeetingPointName, To: Events.EventsMeetingPointName},
+		{Field: "events_meeting_point_lat", From: Previous.EventsMeetingPointLat, To: Events.EventsMeetingPointLat},
+		{Field: "events_meeting_point_lng", From: Previous.EventsMeetingPointLng, To: Events.EventsMeetingPointLng},
+		{Field: "events_payment_required", From: Previous.EventsPaymentRequired, To: Events.EventsPaymentRequired},
+		{Field: "events_payment_fee", From: r.Float(Previous.EventsPaymentFee), To: r.Float(Events.EventsPaymentFee)},
+		{Field: "events_participant_limit", From: r.Int(Previous.EventsParticipantLimit), To: r.Int(Events.EventsParticipantLimit)},
+	}
+
+	changes := []models.EventHistoryChange{}
+	for _, v := range fields {
+		if v.From != v.To {
+			changes = append(changes, v)
+		}
+	}
+	return changes
+}
+
+func (r EventHistoryRepository) Float(value *float64) float64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+func (r EventHistoryRepository) Int(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EventInvitationLinkRepository struct{}
+type EventInvitationLinkRequest struct {
+	EventInvitationLinksBypassApproval bool   `json:"event_invitation_links_bypass_approval"`
+	EventInvitationLinksMaxUses        int    `json:"event_invitation_links_max_uses" validate:"omitempty,min=1"`
+	EventInvitationLinksExpiresAt      string `json:"event_invitation_links_expires_at" validate:"omitempty,datetime=2006-01-02 15:04:05"`
+}
+
+func (r EventInvitationLinkRepository) Retrieve(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	var results []models.EventInvitationLinks
+	filter := bson.D{{Key: "event_invitation_links_event", Value: Event.EventsId}}
+	opts := options.Find().SetSort(bson.D{{Key: "event_invitation_links_created_at", Value: -1}})
+	cursor, _ := config.DB.Collection("EventInvitationLinks").Find(context.TODO(), filter, opts)
+	cursor.All(context.TODO(), &results)
+
+	if len(results) == 0 {
+		helpers.ResponseNoData(c, "No Data")
+		return
+	}
+
+	for k, v := range results {
+		results[k].EventInvitationLinksUrl = r.Url(v.EventInvitationLinksToken)
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+func (r EventInvitationLinkRepository) Create(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	var payload EventInvitationLinkRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	token, err := helpers.RandomToken(16)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+
+	insert := models.EventInvitationLinks{
+		EventInvitationLinksEvent:          Event.EventsId,
+		EventInvitationLinksToken:          token,
+		EventInvitationLinksBypassApproval: payload.EventInvitationLinksBypassApproval,
+		EventInvitationLinksMaxUses:        payload.EventInvitationLinksMaxUses,
+		EventInvitationLinksCreatedBy:      userDetail.UsersId,
+		EventInvitationLinksCreatedAt:      primitive.NewDateTimeFromTime(time.Now()),
+	}
+	if payload.EventInvitationLinksExpiresAt != "" {
+		insert.EventInvitationLinksExpiresAt = helpers.StringToPrimitiveDateTime(payload.EventInvitationLinksExpiresAt)
+	}
+
+	result, err := config.DB.Collection("EventInvitationLinks").InsertOne(context.TODO(), insert)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+
+	insert.EventInvitationLinksId = result.InsertedID.(primitive.ObjectID)
+	insert.EventInvitationLinksUrl = r.Url(token)
+	c.JSON(http.StatusOK, insert)
+}
+
+// Delete revokes the link. Revoked links are kept so their usage stays
+// visible to the organizer.
+func (r EventInvitationLinkRepository) Delete(c *gin.Context) {
+	eventId := helpers.StringToPrimitiveObjId(c.Param("id"))
+	linkId := helpers.StringToPrimitiveObjId(c.Param("linkId"))
+
+	filters := bson.D{
+		{Key: "_id", Value: linkId},
+		{Key: "event_invitation_links_event", Value: eventId},
+	}
+	upd := bson.D{{Key: "$set", Value: bson.M{"event_invitation_links_revoked_at": primitive.NewDateTimeFromTime(time.Now())}}}
+	result, err := config.DB.Collection("EventInvitationLinks").UpdateOne(context.TODO(), filters, upd)
+	if err != nil || result.MatchedCount == 0 {
+		helpers.ResponseNotFound(c, "Invitation link not found")
+		return
+	}
+	helpers.ResultMessageSuccess(c, "Invitation link revoked")
+}
+
+// Read resolves a link for the join page, returning the event so the client
+// can prefill the join flow.
+func (r EventInvitationLinkRepository) Read(c *gin.Context) {
+	Link, Event, ok := r.ReadValid(c)
+	if !ok {
+		return
+	}
+
+	Link.EventInvitationLinksUrl = r.Url(Link.EventInvitationLinksToken)
+	c.JSON(http.StatusOK, gin.H{
+		"event_invitation_link": Link,
+		"event":                 Event,
+		"requires_approval":     Event.EventsRequiresApproval != nil && *Event.EventsRequiresApproval == 1 && !Link.EventInvitationLinksBypassApproval,
+	})
+}
+
+func (r EventInvitationLinkRepository) Join(c *gin.Context) {
+	var payload EventJoinMessageRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	Link, Event, ok := r.ReadValid(c)
+	if !ok {
+		return
+	}
+
+	// The use is claimed before joining so concurrent joins cannot exceed
+	// the limit, and given back when joining fails
+	if !r.Claim(Link) {
+		helpers.ResponseBadRequestError(c, "This invitation link has reached its usage limit")
+		return
+	}
+	if !(EventInvitationMessageRepository{}).JoinEvent(c, Event, payload, &Link) {
+		filters := bson.D{{Key: "_id", Value: Link.EventInvitationLinksId}}
+		upd := bson.D{{Key: "$inc", Value: bson.M{"event_invitation_links_usage_count": -1}}}
+		config.DB.Collection("EventInvitationLinks").UpdateOne(context.TODO(), filters, upd)
+	}
+}
+
+// Claim counts a use of the link, in the same operation as checking that the
+// link is not used up.
+func (r EventInvitationLinkRepository) Claim(Link models.EventInvitationLinks) bool {
+	filters := bson.D{{Key: "_id", Value: Link.EventInvitationLinksId}}
+	if Link.EventInvitationLinksMaxUses > 0 {
+		filters = append(filters, bson.E{Key: "event_invitation_links_usage_count", Value: bson.M{"$lt": Link.EventInvitationLinksMaxUses}})
+	}
+	upd := bson.D{{Key: "$inc", Value: bson.M{"event_invitation_links_usage_count": 1}}}
+	err := config.DB.Collection("EventInvitationLinks").FindOneAndUpdate(context.TODO(), filters, upd).Err()
+	return err == nil
+}
+
+// ReadValid loads the link from the :token parameter and its event, and
+// responds with an error when the link is revoked, expired or used up.
+func (r EventInvitationLinkRepository) ReadValid(c *gin.Context) (models.EventInvitationLinks, models.Events, bool) {
+	var Link models.EventInvitationLinks
+	var Event models.Events
+
+	filter := bson.D{{Key: "event_invitation_links_token", Value: c.Param("token")}}
+	err := config.DB.Collection("EventInvitationLinks").FindOne(context.TODO(), filter).Decode(&Link)
+	if err != nil {
+		helpers.ResponseNotFound(c, "Invitation link not found")
+		return Link, Event, false
+	}
+
+	if Link.EventInvitationLinksRevokedAt != 0 {
+		helpers.ResponseBadRequestError(c, "This invitation link has been revoked")
+		return Link, Event, false
+	}
+
+	if Link.EventInvitationLinksExpiresAt != 0 && time.Now().After(Link.EventInvitationLinksExpiresAt.Time()) {
+		helpers.ResponseBadRequestError(c, "This invitation link has expired")
+		return Link, Event, false
+	}
+
+	if Link.EventInvitationLinksMaxUses > 0 && Link.EventInvitationLinksUsageCount >= Link.EventInvitationLinksMaxUses {
+		helpers.ResponseBadRequestError(c, "This invitation link has reached its usage limit")
+		return Link, Event, false
+	}
+
+	eventFilter := bson.D{
+		{Key: "_id", Value: Link.EventInvitationLinksEvent},
+		{Key: "events_deleted", Value: bson.M{"$exists": false}},
+	}
+	err = config.DB.Collection("Events").FindOne(context.TODO(), eventFilter).Decode(&Event)
+	if err != nil {
+		helpers.ResponseNotFound(c, "Event not found")
+		return Link, Event, false
+	}
+	return Link, Event, true
+}
+
+func (r EventInvitationLinkRepository) Url(token string) string {
+	return config.APP.BaseUrl + "event/join/" + token
+}
@@ -14,7 +14,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type EventInvitationMessageRepository struct{}
@@ -57,7 +56,6 @@ func (r EventInvitationMessageRepository) Update(c *gin.Context) {
 }
 
 func (r EventInvitationMessageRepository) Join(c *gin.Context) {
-	userDetail := helpers.GetAuthUser(c)
 	var payload EventJoinMessageRequest
 	validateError := helpers.Validate(c, &payload)
 	if validateError != nil {
@@ -65,35 +63,43 @@ func (r EventInvitationMessageRepository) Join(c *gin.Context) {
 	}
 
 	var Events models.Events
-	var EventParticipants models.EventParticipants
 	id, _ := primitive.ObjectIDFromHex(c.Param("id"))
 	filter := bson.D{{Key: "_id", Value: id}}
 	err := config.DB.Collection("Events").FindOne(context.TODO(), filter).Decode(&Events)
 	if err != nil {
 		helpers.ResponseError(c, "Invalid event")
+		return
 	}
 
-	match, errMessage := helpers.ValidateStringLength(payload.EventParticipantsRequestMessage, int(config.APP_LIMIT.LengthEventParticipantMessage))
-	if !match {
-		helpers.ResponseBadRequestError(c, errMessage)
+	if !helpers.EventVisible(Events, helpers.GetAuthUser(c).UsersId) {
+		helpers.ResponseNotFound(c, "Event not found")
 		return
 	}
 
-	countFilter := bson.D{
-		{Key: "event_participants_event", Value: id},
-		{Key: "event_participants_status", Value: GetEventParticipantStatus("ACCEPTED")},
-	}
-	opts := options.Count().SetHint("_id_")
-	countParticipant, _ := config.DB.Collection("EventParticipants").CountDocuments(context.TODO(), countFilter, opts)
+	r.JoinEvent(c, Events, payload, nil)
+}
 
-	status := GetEventParticipantStatus("ACCEPTED")
+// JoinEvent adds the authenticated user to the event. When joining through
+// an invitation link, the link is recorded on the participant and may skip
+// the approval step. It reports whether the user was added.
+func (r EventInvitationMessageRepository) JoinEvent(c *gin.Context, Events models.Events, payload EventJoinMessageRequest, Link *models.EventInvitationLinks) bool {
+	userDetail := helpers.GetAuthUser(c)
+	var EventParticipants models.EventParticipants
+	id := Events.EventsId
 
-	if *Events.EventsParticipantLimit != 0 && *Events.EventsParticipantLimit < int(countParticipant+1) {
-		eventParticipantLimit := strconv.Itoa(*Events.EventsParticipantLimit)
-		helpers.ResponseBadRequestError(c, "This event can only be attended by "+eventParticipantLimit+" participants")
-		return
+	if (EventCancellationRepository{}).IsCancelled(Events) {
+		helpers.ResponseBadRequestError(c, "This event has been cancelled")
+		return false
 	}
 
+	match, errMessage := helpers.ValidateStringLength(payload.EventParticipantsRequestMessage, int(config.APP_LIMIT.LengthEventParticipantMessage))
+	if !match {
+		helpers.ResponseBadRequestError(c, errMessage)
+		return false
+	}
+
+	status := GetEventParticipantStatus("ACCEPTED")
+
 	// Check if user already in this event
 	checkParticipantFilter := bson.D{
 		{Key: "event_participants_event", Value: id},
@@ -102,11 +108,19 @@ func (r EventInvitationMessageRepository) Join(c *gin.Context) {
 	checkParticipantErr := config.DB.Collection("EventParticipants").FindOne(context.TODO(), checkParticipantFilter).Decode(&EventParticipants)
 	if checkParticipantErr == nil {
 		helpers.ResponseError(c, "You are already in this event")
-		return
+		return false
 	}
 
-	if *Events.EventsRequiresApproval == 1 {
+	// Applications are checked against the limit when the organizer approves
+	// them, otherwise a full event places the user on the waitlist
+	seatsAvailable := EventWaitlistRepository{}.SeatsAvailable(Events)
+	bypassApproval := Link != nil && Link.EventInvitationLinksBypassApproval
+	if Events.EventsRequiresApproval != nil && *Events.EventsRequiresApproval == 1 && !bypassApproval {
 		status = GetEventParticipantStatus("APPLIED")
+	} else if seatsAvailable == 0 {
+		status = GetEventParticipantStatus("WAITLISTED")
+	} else if (EventPaymentRepository{}).Required(Events) {
+		status = GetEventParticipantStatus("PAYMENT_PENDING")
 	}
 	insertParticipant := models.EventParticipants{
 		EventParticipantsEvent:          id,
@@ -116,16 +130,38 @@ func (r EventInvitationMessageRepository) Join(c *gin.Context) {
 		EventParticipantsCreatedAt:      primitive.NewDateTimeFromTime(time.Now()),
 		EventParticipantsRequestMessage: payload.EventParticipantsRequestMessage,
 	}
+	if status == GetEventParticipantStatus("WAITLISTED") {
+		insertParticipant.EventParticipantsWaitlistPosition = EventWaitlistRepository{}.NextPosition(id)
+	}
+	if Link != nil {
+		insertParticipant.EventParticipantsInvitationLink = Link.EventInvitationLinksId
+	}
 
 	insertResult, inserParticipantErr := config.DB.Collection("EventParticipants").InsertOne(context.TODO(), insertParticipant)
 
 	if inserParticipantErr != nil {
-		helpers.ResponseError(c, err.Error())
-		return
+		helpers.ResponseError(c, inserParticipantErr.Error())
+		return false
 	}
 
 	if status == GetEventParticipantStatus("ACCEPTED") {
 		EventRepository{}.HandleBadges(c, id)
+		EventParticipantsRepository{}.StreamJoined(id, userDetail.UsersId)
+	}
+
+	if status == GetEventParticipantStatus("PAYMENT_PENDING") {
+		insertParticipant.EventParticipantsId = insertResult.InsertedID.(primitive.ObjectID)
+		insertParticipant, err := EventPaymentRepository{}.Start(Events, insertParticipant)
+		if err != nil {
+			config.DB.Collection("EventParticipants").DeleteOne(context.TODO(), bson.D{{Key: "_id", Value: insertParticipant.EventParticipantsId}})
+			helpers.ResponseError(c, err.Error())
+			return false
+		}
+
+		response := EventPaymentRepository{}.Response(insertParticipant)
+		response["message"] = "Please complete the payment to confirm your seat"
+		c.JSON(http.StatusOK, response)
+		return true
 	}
 
 	if status == GetEventParticipantStatus("APPLIED") {
@@ -157,5 +193,11 @@ func (r EventInvitationMessageRepository) Join(c *gin.Context) {
 		}
 	}
 
+	if status == GetEventParticipantStatus("WAITLISTED") {
+		helpers.ResponseSuccessMessage(c, "This event is full. You have been added to the waitlist at position "+strconv.Itoa(insertParticipant.EventParticipantsWaitlistPosition))
+		return true
+	}
+
 	helpers.ResponseSuccessMessage(c, "Join request for event submitted")
+	return true
 }
@@ -7,7 +7,6 @@ import (
 	"oosa_rewild/internal/config"
 	"oosa_rewild/internal/helpers"
 	"oosa_rewild/internal/models"
-	"strconv"
 
 	"github.com/arwoosa/notifaction/helper"
 	"github.com/gin-gonic/gin"
@@ -72,7 +71,10 @@ func (r EventInvitationRepository) Retrieve(c *gin.Context) {
 			}},
 			bson.D{{
 				Key: "$match", Value: bson.M{
-					"event_participants_event_detail.events_created_by": userDetail.UsersId,
+					"$or": []bson.M{
+						{"event_participants_event_detail.events_created_by": userDetail.UsersId},
+						{"event_participants_event": bson.M{"$in": r.CohostEvents(userDetail.UsersId)}},
+					},
 				},
 			}})
 	}
@@ -91,6 +93,24 @@ func (r EventInvitationRepository) Retrieve(c *gin.Context) {
 	c.JSON(http.StatusOK, EventParticipants)
 }
 
+// CohostEvents returns the events in which the user is a co-host.
+func (r EventInvitationRepository) CohostEvents(userId primitive.ObjectID) []primitive.ObjectID {
+	var EventParticipants []models.EventParticipants
+	filter := bson.D{
+		{Key: "event_participants_user", Value: userId},
+		{Key: "event_participants_status", Value: GetEventParticipantStatus("ACCEPTED")},
+		{Key: "event_participants_role", Value: helpers.EVENT_ROLE_COHOST},
+	}
+	cursor, _ := config.DB.Collection("EventParticipants").Find(context.TODO(), filter)
+	cursor.All(context.TODO(), &EventParticipants)
+
+	eventIds := []primitive.ObjectID{}
+	for _, v := range EventParticipants {
+		eventIds = append(eventIds, v.EventParticipantsEvent)
+	}
+	return eventIds
+}
+
 func (r EventInvitationRepository) Read(c *gin.Context) {
 	id, _ := primitive.ObjectIDFromHex(c.Param("id"))
 	userDetail := helpers.GetAuthUser(c)
@@ -162,40 +182,62 @@ func (r EventInvitationRepository) Update(c *gin.Context) {
 		return
 	}
 
+	if payload.EventParticipantsStatus == GetEventParticipantStatus("ACCEPTED") && (EventCancellationRepository{}).IsCancelled(Event) {
+		helpers.ResponseBadRequestError(c, "This event has been cancelled")
+		return
+	}
+
+	// 活動額滿時改為加入候補名單
+	isWaitlisted := false
 	if payload.EventParticipantsStatus == GetEventParticipantStatus("ACCEPTED") {
-		countFilter := bson.D{
-			{Key: "event_participants_event", Value: results.EventParticipantsEvent},
-			{Key: "event_participants_status", Value: GetEventParticipantStatus("ACCEPTED")},
-		}
-		acceptedCount, err := config.DB.Collection("EventParticipants").CountDocuments(context.TODO(), countFilter)
-		if err != nil {
-			helpers.ResponseError(c, "Error checking accepted participants count")
-			return
-		}
-		if *Event.EventsParticipantLimit != 0 && int(acceptedCount+1) > *Event.EventsParticipantLimit {
-			limitMsg := "This event can only be attended by " + strconv.Itoa(*Event.EventsParticipantLimit) + " participants"
-			helpers.ResponseBadRequestError(c, limitMsg)
-			return
-		}
+		isWaitlisted = EventWaitlistRepository{}.SeatsAvailable(Event) == 0
 	}
 
+	// 付費活動需完成付款才確認名額
+	isPaymentPending := payload.EventParticipantsStatus == GetEventParticipantStatus("ACCEPTED") && !isWaitlisted && (EventPaymentRepository{}).Required(Event)
+
 	// 更新參與者狀態
 	results.EventParticipantsStatus = payload.EventParticipantsStatus
+	if isWaitlisted {
+		results.EventParticipantsStatus = GetEventParticipantStatus("WAITLISTED")
+		results.EventParticipantsWaitlistPosition = EventWaitlistRepository{}.NextPosition(results.EventParticipantsEvent)
+	}
+	if isPaymentPending {
+		results.EventParticipantsStatus = GetEventParticipantStatus("PAYMENT_PENDING")
+	}
 	upd := bson.D{{Key: "$set", Value: results}}
 	config.DB.Collection("EventParticipants").UpdateOne(context.TODO(), filter, upd)
+
+	if isPaymentPending {
+		results, err = EventPaymentRepository{}.Start(Event, results)
+		if err != nil {
+			fmt.Println("payment intent err: " + err.Error())
+		} else if applied == "true" {
+			EventPaymentRepository{}.Notify(c, Event, results)
+		}
+	}
+	results.EventParticipantsStatusLabel = GetEventParticipantStatusLabel(results.EventParticipantsStatus)
+
+	if isWaitlisted && applied == "true" {
+		EventWaitlistRepository{}.NotifyWaitlisted(c, Event, results)
+	}
+
+	// Participants waiting to pay only join once the payment is confirmed
+	if isWaitlisted || isPaymentPending {
+		c.JSON(http.StatusOK, results)
+		return
+	}
+
+	if payload.EventParticipantsStatus == GetEventParticipantStatus("REJECTED") {
+		EventWaitlistRepository{}.Promote(c, results.EventParticipantsEvent)
+	}
+
 	EventRepository{}.HandleParticipation(c, userDetail.UsersId, id)
 	EventRepository{}.HandleBadges(c, id)
 
 	// 如果是接受邀請，發送通知給現有參與者
 	if payload.EventParticipantsStatus == GetEventParticipantStatus("ACCEPTED") {
-		ActiveParticipants := EventParticipantsRepository{}.ActiveParticipants(results.EventParticipantsEvent)
-		for _, v := range ActiveParticipants {
-			NotificationMessage := models.NotificationMessage{
-				Message: "{0}有新的夥伴加入!",
-				Data:    []map[string]interface{}{helpers.NotificationFormatEvent(Event)},
-			}
-			helpers.NotificationsCreate(c, helpers.NOTIFICATION_JOINING_NEW, v.EventParticipantsUser, NotificationMessage, results.EventParticipantsEvent)
-		}
+		EventParticipantsRepository{}.NotifyJoined(c, Event, results.EventParticipantsUser)
 	}
 
 	// 只有在處理申請時才處理申請者通知 (applied == "true")
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+
+	"github.com/arwoosa/notifaction/helper"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventMentionRepository resolves the @username mentions of message board
+// posts and announcements to the accepted participants of the event.
+type EventMentionRepository struct{}
+
+// Parse returns the mentions in the text. Tokens that do not name a
+// participant of the event, such as "meet @6:30", are left as plain text.
+func (r EventMentionRepository) Parse(eventId primitive.ObjectID, text string) []models.EventMention {
+	tokens := helpers.MentionParse(text)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	var usernames []string
+	for _, v := range tokens {
+		usernames = append(usernames, v.Username)
+	}
+
+	var participantIds []primitive.ObjectID
+	for _, v := range (EventParticipantsRepository{}).ActiveParticipants(eventId) {
+		participantIds = append(participantIds, v.EventParticipantsUser)
+	}
+
+	var Users []models.Users
+	if len(participantIds) > 0 {
+		filter := bson.M{"_id": bson.M{"$in": participantIds}, "users_username": bson.M{"$in": usernames}}
+		cursor, _ := config.DB.Collection("Users").Find(context.TODO(), filter)
+		cursor.All(context.TODO(), &Users)
+	}
+	participants := map[string]primitive.ObjectID{}
+	for _, v := range Users {
+		participants[v.UsersUsername] = v.UsersId
+	}
+
+	var mentions []models.EventMention
+	for _, v := range tokens {
+		userId, ok := participants[v.Username]
+		if !ok {
+			continue
+		}
+		mentions = append(mentions, models.EventMention{
+			EventMentionUser:     userId,
+			EventMentionUsername: v.Username,
+			EventMentionOffset:   v.Offset,
+			EventMentionLength:   v.Length,
+		})
+	}
+	return mentions
+}
+
+// Notify tells the mentioned users about the mention, once per user and
+// skipping the author and anyone in previous, who was already notified.
+func (r EventMentionRepository) Notify(c *gin.Context, Events models.Events, mentions []models.EventMention, previous []models.EventMention) {
+	notifyMsg := r.NotifyAs(helpers.GetAuthUser(c), Events, mentions, previous)
+	if notifyMsg != nil {
+		notifyMsg.WriteToHeader(c, config.APP.NotificationHeaderName)
+	}
+}
+
+// NotifyAs notifies the mentions on behalf of the author, for background jobs
+// with no request. It returns the push notification, which only a request
+// can send.
+func (r EventMentionRepository) NotifyAs(userDetail models.Users, Events models.Events, mentions []models.EventMention, previous []models.EventMention) helper.NotifyMsg {
+	notified := map[primitive.ObjectID]bool{userDetail.UsersId: true}
+	for _, v := range previous {
+		notified[v.EventMentionUser] = true
+	}
+
+	notifyData := map[string]string{
+		"events_name": Events.EventsName,
+		"users_name":  userDetail.UsersName,
+	}
+	var notifyMsg helper.NotifyMsg
+	for _, v := range mentions {
+		if notified[v.EventMentionUser] {
+			continue
+		}
+		notified[v.EventMentionUser] = true
+
+		NotificationMessage := models.NotificationMessage{
+			Message: "{0}在{1}提到了你",
+			Data: []map[string]interface{}{
+				helpers.NotificationFormatUser(userDetail),
+				helpers.NotificationFormatEvent(Events),
+			},
+		}
+		helpers.NotificationsInsert(helpers.NOTIFICATION_EVENT_MENTION, v.EventMentionUser, NotificationMessage, Events.EventsId, userDetail.UsersId)
+
+		if notifyMsg == nil {
+			var err error
+			notifyMsg, err = helper.NewNotifyMsg(
+				helpers.NOTIFICATION_EVENT_MENTION,
+				userDetail.UsersId, v.EventMentionUser,
+				notifyData, helpers.FindUserSourceId)
+			if err != nil {
+				fmt.Println("new notify msg err: " + err.Error())
+			}
+		} else {
+			notifyMsg.AddTo(v.EventMentionUser)
+		}
+	}
+	return notifyMsg
+}
@@ -7,6 +7,7 @@ import (
 	"oosa_rewild/internal/config"
 	"oosa_rewild/internal/helpers"
 	"oosa_rewild/internal/models"
+	"sort"
 	"strconv"
 	"time"
 
@@ -14,12 +15,20 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// EventMessageBoardRepository handles the message board of an event. Replies
+// are threaded one level deep: replying to a reply attaches the message to
+// the thread of its parent.
 type EventMessageBoardRepository struct{}
 type EventMessageBoardRequest struct {
 	EventMessageBoardBaseMessage string `json:"event_message_board_base_message" validate:"required"`
 	EventMessageBoardIsPinned    int    `json:"event_message_board_is_pinned"`
+	EventMessageBoardParent      string `json:"event_message_board_parent"`
+}
+type EventMessageBoardReactionRequest struct {
+	EventMessageBoardReactionsEmoji string `json:"event_message_board_reactions_emoji" validate:"required"`
 }
 type EventMessageBoardPinRequest struct {
 	EventMessageBoardCategory string `json:"event_message_board_category" validate:"required"`
@@ -35,14 +44,75 @@ func (r EventMessageBoardRepository) Retrieve(c *gin.Context) {
 	match := bson.D{
 		{Key: "event_message_board_event", Value: eventId},
 		{Key: "event_message_board_base_message", Value: bson.M{"$exists": true}},
+		{Key: "event_message_board_parent", Value: bson.M{"$exists": false}},
+	}
+
+	// sort=replies puts the busiest threads first, sort=activity the threads
+	// with the latest reply
+	sortBy := bson.D{{Key: "event_message_board_created_at", Value: 1}}
+	switch c.Query("sort") {
+	case "replies":
+		sortBy = bson.D{{Key: "event_message_board_reply_count", Value: -1}, {Key: "event_message_board_last_activity", Value: -1}}
+	case "activity":
+		sortBy = bson.D{{Key: "event_message_board_last_activity", Value: -1}}
 	}
 
-	criteria := bson.D{{
-		Key: "$match", Value: match,
-	}}
+	results, err := r.Aggregate(match, sortBy)
+	if err != nil {
+		return
+	}
 
+	if len(results) == 0 {
+		helpers.ResponseNoData(c, "No Data")
+		return
+	}
+	r.FillReactions(c, results)
+	c.JSON(http.StatusOK, results)
+}
+
+// Replies lists the replies of a message, oldest first.
+func (r EventMessageBoardRepository) Replies(c *gin.Context) {
+	err := EventRepository{}.ReadOne(c, &models.Events{})
+	if err != nil {
+		return
+	}
+
+	var EventMessageBoard models.EventMessageBoard
+	errMb := r.ReadOne(c, &EventMessageBoard)
+	if errMb != nil {
+		return
+	}
+
+	match := bson.D{{Key: "event_message_board_parent", Value: EventMessageBoard.EventMessageBoardId}}
+	results, err := r.Aggregate(match, bson.D{{Key: "event_message_board_created_at", Value: 1}})
+	if err != nil {
+		return
+	}
+
+	if len(results) == 0 {
+		helpers.ResponseNoData(c, "No Data")
+		return
+	}
+	r.FillReactions(c, results)
+	c.JSON(http.StatusOK, results)
+}
+
+func (r EventMessageBoardRepository) Aggregate(match bson.D, sortBy bson.D) ([]models.EventMessageBoard, error) {
+	// Messages hidden by a moderator are never listed
+	match = append(match, bson.E{Key: "event_message_board_hidden", Value: bson.M{"$ne": true}})
 	agg := mongo.Pipeline{
-		criteria,
+		bson.D{{
+			Key: "$match", Value: match,
+		}},
+		// Messages from before threading have no last activity
+		bson.D{{
+			Key: "$addFields", Value: bson.M{
+				"event_message_board_last_activity": bson.M{"$ifNull": bson.A{"$event_message_board_last_activity", "$event_message_board_created_at"}},
+			},
+		}},
+		bson.D{{
+			Key: "$sort", Value: sortBy,
+		}},
 		bson.D{{
 			Key: "$lookup", Value: bson.M{
 				"from":         "Users",
@@ -58,17 +128,11 @@ func (r EventMessageBoardRepository) Retrieve(c *gin.Context) {
 
 	var results []models.EventMessageBoard
 	cursor, err := config.DB.Collection("EventMessageBoard").Aggregate(context.TODO(), agg)
-	cursor.All(context.TODO(), &results)
-
 	if err != nil {
-		return
-	}
-
-	if len(results) == 0 {
-		helpers.ResponseNoData(c, "No Data")
-		return
+		return results, err
 	}
-	c.JSON(http.StatusOK, results)
+	err = cursor.All(context.TODO(), &results)
+	return results, err
 }
 
 func (r EventMessageBoardRepository) Create(c *gin.Context) {
@@ -107,12 +171,26 @@ func (r EventMessageBoardRepository) Create(c *gin.Context) {
 		return
 	}
 
+	mentions := EventMentionRepository{}.Parse(eventId, payload.EventMessageBoardBaseMessage)
+
+	var Parent models.EventMessageBoard
+	if payload.EventMessageBoardParent != "" {
+		err = r.ReadThread(eventId, helpers.StringToPrimitiveObjId(payload.EventMessageBoardParent), &Parent)
+		if err != nil {
+			helpers.ResponseNotFound(c, "Parent message not found")
+			return
+		}
+	}
+
 	insert := models.EventMessageBoard{
 		EventMessageBoardEvent: eventId,
 		// EventMessageBoardStatus
 		// EventMessageBoardCategory
-		EventMessageBoardCreatedBy: userDetail.UsersId,
-		EventMessageBoardCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+		EventMessageBoardCreatedBy:    userDetail.UsersId,
+		EventMessageBoardCreatedAt:    primitive.NewDateTimeFromTime(currentTime),
+		EventMessageBoardParent:       Parent.EventMessageBoardId,
+		EventMessageBoardLastActivity: primitive.NewDateTimeFromTime(currentTime),
+		EventMessageBoardMentions:     mentions,
 	}
 
 	r.ProcessData(c, &insert, payload)
@@ -123,8 +201,20 @@ func (r EventMessageBoardRepository) Create(c *gin.Context) {
 		return
 	}
 
+	if !Parent.EventMessageBoardId.IsZero() {
+		filters := bson.D{{Key: "_id", Value: Parent.EventMessageBoardId}}
+		upd := bson.D{
+			{Key: "$inc", Value: bson.M{"event_message_board_reply_count": 1}},
+			{Key: "$set", Value: bson.M{"event_message_board_last_activity": insert.EventMessageBoardLastActivity}},
+		}
+		config.DB.Collection("EventMessageBoard").UpdateOne(context.TODO(), filters, upd)
+		r.NotifyReply(c, Events, Parent)
+	}
+	EventMentionRepository{}.Notify(c, Events, mentions, nil)
+
 	var EventMessageBoard models.EventMessageBoard
 	config.DB.Collection("EventMessageBoard").FindOne(context.TODO(), bson.D{{Key: "_id", Value: result.InsertedID}}).Decode(&EventMessageBoard)
+	helpers.EventStreamPublish(eventId, helpers.EVENT_STREAM_MESSAGE_CREATED, EventMessageBoard)
 	c.JSON(http.StatusOK, EventMessageBoard)
 }
 
@@ -137,14 +227,61 @@ func (r EventMessageBoardRepository) Read(c *gin.Context) {
 	var EventMessageBoard models.EventMessageBoard
 	errMb := r.ReadOne(c, &EventMessageBoard)
 	if errMb == nil {
+		EventMessageBoard.EventMessageBoardReactions = r.Reactions(c, []primitive.ObjectID{EventMessageBoard.EventMessageBoardId})[EventMessageBoard.EventMessageBoardId]
 		c.JSON(http.StatusOK, EventMessageBoard)
 	}
 }
 
+// ReadThread finds the top level message of the thread the message is in.
+func (r EventMessageBoardRepository) ReadThread(eventId primitive.ObjectID, messageId primitive.ObjectID, EventMessageBoard *models.EventMessageBoard) error {
+	filter := bson.D{
+		{Key: "_id", Value: messageId},
+		{Key: "event_message_board_event", Value: eventId},
+		{Key: "event_message_board_base_message", Value: bson.M{"$exists": true}},
+		{Key: "event_message_board_hidden", Value: bson.M{"$ne": true}},
+	}
+	err := config.DB.Collection("EventMessageBoard").FindOne(context.TODO(), filter).Decode(EventMessageBoard)
+	if err != nil || EventMessageBoard.EventMessageBoardParent.IsZero() {
+		return err
+	}
+	return r.ReadThread(eventId, EventMessageBoard.EventMessageBoardParent, EventMessageBoard)
+}
+
+// NotifyReply tells the author of the thread and everyone who replied to it
+// about a new reply, except the one replying.
+func (r EventMessageBoardRepository) NotifyReply(c *gin.Context, Events models.Events, Parent models.EventMessageBoard) {
+	userDetail := helpers.GetAuthUser(c)
+	recipients := map[primitive.ObjectID]bool{Parent.EventMessageBoardCreatedBy: true}
+
+	var Replies []models.EventMessageBoard
+	cursor, _ := config.DB.Collection("EventMessageBoard").Find(context.TODO(), bson.D{{Key: "event_message_board_parent", Value: Parent.EventMessageBoardId}})
+	cursor.All(context.TODO(), &Replies)
+	for _, v := range Replies {
+		recipients[v.EventMessageBoardCreatedBy] = true
+	}
+	delete(recipients, userDetail.UsersId)
+
+	for user := range recipients {
+		NotificationMessage := models.NotificationMessage{
+			Message: "{0}回覆了{1}的留言",
+			Data: []map[string]interface{}{
+				helpers.NotificationFormatUser(userDetail),
+				helpers.NotificationFormatEvent(Events),
+				{"event_message_board_id": Parent.EventMessageBoardId},
+			},
+		}
+		helpers.NotificationsCreate(c, helpers.NOTIFICATION_EVENT_MESSAGE_REPLY, user, NotificationMessage, Events.EventsId)
+	}
+}
+
 func (r EventMessageBoardRepository) ReadOne(c *gin.Context, EventMessageBoard *models.EventMessageBoard) error {
 	eventId := helpers.StringToPrimitiveObjId(c.Param("id"))
 	eventMessageBoardId := helpers.StringToPrimitiveObjId(c.Param("messageBoardId"))
-	filter := bson.D{{Key: "_id", Value: eventMessageBoardId}, {Key: "event_message_board_event", Value: eventId}}
+	filter := bson.D{
+		{Key: "_id", Value: eventMessageBoardId},
+		{Key: "event_message_board_event", Value: eventId},
+		{Key: "event_message_board_hidden", Value: bson.M{"$ne": true}},
+	}
 	err := config.DB.Collection("EventMessageBoard").FindOne(context.TODO(), filter).Decode(&EventMessageBoard)
 	if err != nil {
 		helpers.ResultEmpty(c, err)
@@ -153,7 +290,8 @@ func (r EventMessageBoardRepository) ReadOne(c *gin.Context, EventMessageBoard *
 }
 
 func (r EventMessageBoardRepository) Update(c *gin.Context) {
-	err := EventRepository{}.ReadOne(c, &models.Events{})
+	var Events models.Events
+	err := EventRepository{}.ReadOne(c, &Events)
 	if err != nil {
 		return
 	}
@@ -173,10 +311,19 @@ func (r EventMessageBoardRepository) Update(c *gin.Context) {
 	var EventMessageBoard models.EventMessageBoard
 	errMb := r.ReadOne(c, &EventMessageBoard)
 	if errMb == nil {
+		mentions := EventMentionRepository{}.Parse(Events.EventsId, payload.EventMessageBoardBaseMessage)
+
+		// Only users who were not mentioned before are notified of the edit
+		previous := EventMessageBoard.EventMessageBoardMentions
 		r.ProcessData(c, &EventMessageBoard, payload)
+		EventMessageBoard.EventMessageBoardMentions = mentions
 		filters := bson.D{{Key: "_id", Value: EventMessageBoard.EventMessageBoardId}, {Key: "event_message_board_event", Value: EventMessageBoard.EventMessageBoardEvent}}
 		upd := bson.D{{Key: "$set", Value: EventMessageBoard}}
+		if len(mentions) == 0 {
+			upd = append(upd, bson.E{Key: "$unset", Value: bson.M{"event_message_board_mentions": ""}})
+		}
 		config.DB.Collection("EventMessageBoard").UpdateOne(context.TODO(), filters, upd)
+		EventMentionRepository{}.Notify(c, Events, mentions, previous)
 		c.JSON(http.StatusOK, EventMessageBoard)
 	}
 }
@@ -190,12 +337,172 @@ func (r EventMessageBoardRepository) Delete(c *gin.Context) {
 	var EventMessageBoard models.EventMessageBoard
 	errMb := r.ReadOne(c, &EventMessageBoard)
 	if errMb == nil {
-		filters := bson.D{{Key: "_id", Value: EventMessageBoard.EventMessageBoardId}}
-		config.DB.Collection("EventMessageBoard").DeleteOne(context.TODO(), filters)
+		r.Remove(EventMessageBoard)
 		helpers.ResultMessageSuccess(c, "Message board record deleted")
 	}
 }
 
+// Remove deletes the message with its reactions. Deleting a thread removes
+// its replies, and deleting a reply updates the reply count of its thread.
+func (r EventMessageBoardRepository) Remove(EventMessageBoard models.EventMessageBoard) {
+	messageIds := []primitive.ObjectID{EventMessageBoard.EventMessageBoardId}
+	if EventMessageBoard.EventMessageBoardParent.IsZero() {
+		var Replies []models.EventMessageBoard
+		cursor, _ := config.DB.Collection("EventMessageBoard").Find(context.TODO(), bson.D{{Key: "event_message_board_parent", Value: EventMessageBoard.EventMessageBoardId}})
+		cursor.All(context.TODO(), &Replies)
+		for _, v := range Replies {
+			messageIds = append(messageIds, v.EventMessageBoardId)
+		}
+	} else if !EventMessageBoard.EventMessageBoardHidden {
+		r.CountReply(EventMessageBoard.EventMessageBoardParent, -1)
+	}
+
+	filters := bson.D{{Key: "_id", Value: bson.M{"$in": messageIds}}}
+	config.DB.Collection("EventMessageBoard").DeleteMany(context.TODO(), filters)
+	config.DB.Collection("EventMessageBoardReactions").DeleteMany(context.TODO(), bson.D{{Key: "event_message_board_reactions_message", Value: bson.M{"$in": messageIds}}})
+}
+
+// CountReply adds to the reply count of the thread. Hidden replies are not
+// counted.
+func (r EventMessageBoardRepository) CountReply(parentId primitive.ObjectID, inc int) {
+	filters := bson.D{{Key: "_id", Value: parentId}}
+	upd := bson.D{{Key: "$inc", Value: bson.M{"event_message_board_reply_count": inc}}}
+	config.DB.Collection("EventMessageBoard").UpdateOne(context.TODO(), filters, upd)
+}
+
+// CreateReaction adds the user's emoji reaction to a message. Reacting twice
+// with the same emoji has no further effect.
+func (r EventMessageBoardRepository) CreateReaction(c *gin.Context) {
+	err := EventRepository{}.ReadOne(c, &models.Events{})
+	if err != nil {
+		return
+	}
+
+	userDetail := helpers.GetAuthUser(c)
+	var payload EventMessageBoardReactionRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+	if !helpers.ValidateEmoji(payload.EventMessageBoardReactionsEmoji) {
+		helpers.ResponseBadRequestError(c, "Reactions can only be a single emoji")
+		return
+	}
+
+	var EventMessageBoard models.EventMessageBoard
+	errMb := r.ReadOne(c, &EventMessageBoard)
+	if errMb != nil {
+		return
+	}
+
+	filter := bson.D{
+		{Key: "event_message_board_reactions_message", Value: EventMessageBoard.EventMessageBoardId},
+		{Key: "event_message_board_reactions_user", Value: userDetail.UsersId},
+		{Key: "event_message_board_reactions_emoji", Value: payload.EventMessageBoardReactionsEmoji},
+	}
+	upd := bson.D{{Key: "$setOnInsert", Value: models.EventMessageBoardReactions{
+		EventMessageBoardReactionsEvent:     EventMessageBoard.EventMessageBoardEvent,
+		EventMessageBoardReactionsMessage:   EventMessageBoard.EventMessageBoardId,
+		EventMessageBoardReactionsUser:      userDetail.UsersId,
+		EventMessageBoardReactionsEmoji:     payload.EventMessageBoardReactionsEmoji,
+		EventMessageBoardReactionsCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}}}
+	_, err = config.DB.Collection("EventMessageBoardReactions").UpdateOne(context.TODO(), filter, upd, options.Update().SetUpsert(true))
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, r.ReactionsResponse(c, EventMessageBoard.EventMessageBoardId))
+}
+
+// DeleteReaction removes the user's reaction with the emoji in the path.
+func (r EventMessageBoardRepository) DeleteReaction(c *gin.Context) {
+	err := EventRepository{}.ReadOne(c, &models.Events{})
+	if err != nil {
+		return
+	}
+
+	userDetail := helpers.GetAuthUser(c)
+	var EventMessageBoard models.EventMessageBoard
+	errMb := r.ReadOne(c, &EventMessageBoard)
+	if errMb != nil {
+		return
+	}
+
+	filter := bson.D{
+		{Key: "event_message_board_reactions_message", Value: EventMessageBoard.EventMessageBoardId},
+		{Key: "event_message_board_reactions_user", Value: userDetail.UsersId},
+		{Key: "event_message_board_reactions_emoji", Value: c.Param("emoji")},
+	}
+	result, _ := config.DB.Collection("EventMessageBoardReactions").DeleteOne(context.TODO(), filter)
+	if result == nil || result.DeletedCount == 0 {
+		helpers.ResponseNotFound(c, "Reaction not found")
+		return
+	}
+	c.JSON(http.StatusOK, r.ReactionsResponse(c, EventMessageBoard.EventMessageBoardId))
+}
+
+func (r EventMessageBoardRepository) ReactionsResponse(c *gin.Context, messageId primitive.ObjectID) []models.EventMessageBoardReactionSummary {
+	summary := r.Reactions(c, []primitive.ObjectID{messageId})[messageId]
+	if summary == nil {
+		return []models.EventMessageBoardReactionSummary{}
+	}
+	return summary
+}
+
+func (r EventMessageBoardRepository) FillReactions(c *gin.Context, results []models.EventMessageBoard) {
+	var messageIds []primitive.ObjectID
+	for _, v := range results {
+		messageIds = append(messageIds, v.EventMessageBoardId)
+	}
+	reactions := r.Reactions(c, messageIds)
+	for k, v := range results {
+		results[k].EventMessageBoardReactions = reactions[v.EventMessageBoardId]
+	}
+}
+
+// Reactions counts the reactions of the messages per emoji, the most used
+// emoji first and otherwise in the order they were first used.
+func (r EventMessageBoardRepository) Reactions(c *gin.Context, messageIds []primitive.ObjectID) map[primitive.ObjectID][]models.EventMessageBoardReactionSummary {
+	userDetail := helpers.GetAuthUser(c)
+	summaries := map[primitive.ObjectID][]models.EventMessageBoardReactionSummary{}
+	if len(messageIds) == 0 {
+		return summaries
+	}
+
+	var Reactions []models.EventMessageBoardReactions
+	opts := options.Find().SetSort(bson.D{{Key: "event_message_board_reactions_created_at", Value: 1}})
+	cursor, _ := config.DB.Collection("EventMessageBoardReactions").Find(context.TODO(), bson.D{{Key: "event_message_board_reactions_message", Value: bson.M{"$in": messageIds}}}, opts)
+	cursor.All(context.TODO(), &Reactions)
+
+	for _, v := range Reactions {
+		messageId := v.EventMessageBoardReactionsMessage
+		idx := -1
+		for k, summary := range summaries[messageId] {
+			if summary.Emoji == v.EventMessageBoardReactionsEmoji {
+				idx = k
+			}
+		}
+		if idx < 0 {
+			summaries[messageId] = append(summaries[messageId], models.EventMessageBoardReactionSummary{Emoji: v.EventMessageBoardReactionsEmoji})
+			idx = len(summaries[messageId]) - 1
+		}
+		summary := &summaries[messageId][idx]
+		summary.Count++
+		summary.Users = append(summary.Users, v.EventMessageBoardReactionsUser)
+		if v.EventMessageBoardReactionsUser == userDetail.UsersId {
+			summary.Reacted = true
+		}
+	}
+
+	for messageId := range summaries {
+		sort.SliceStable(summaries[messageId], func(i, j int) bool {
+			return summaries[messageId][i].Count > summaries[messageId][j].Count
+		})
+	}
+	return summaries
+}
+
 func (r EventMessageBoardRepository) ProcessData(c *gin.Context, EventMessageBoard *models.EventMessageBoard, payload EventMessageBoardRequest) {
 	EventMessageBoard.EventMessageBoardBaseMessage = payload.EventMessageBoardBaseMessage
 
@@ -22,13 +22,18 @@ type EventParticipantsRepository struct{}
 type EventParticipantsRequest struct {
 	EventParticipantsUser []string `json:"event_participants_user" validate:"required"`
 }
+type EventParticipantsRoleRequest struct {
+	EventParticipantsRole string `json:"event_participants_role" validate:"required,oneof=COHOST MEMBER"`
+}
 
 func GetEventParticipantStatus(status string) int64 {
 	ParticipantStatus := map[string]int64{
-		"PENDING":  0,
-		"ACCEPTED": 1,
-		"REJECTED": 2,
-		"APPLIED":  3,
+		"PENDING":         0,
+		"ACCEPTED":        1,
+		"REJECTED":        2,
+		"APPLIED":         3,
+		"WAITLISTED":      4,
+		"PAYMENT_PENDING": 5,
 	}
 	return ParticipantStatus[status]
 }
@@ -39,6 +44,8 @@ func GetEventParticipantStatusLabel(status int64) string {
 		1: "ACCEPTED",
 		2: "REJECTED",
 		3: "APPLIED",
+		4: "WAITLISTED",
+		5: "PAYMENT_PENDING",
 	}
 	return ParticipantStatus[status]
 }
@@ -224,21 +231,112 @@ func (r EventParticipantsRepository) ReadOne(c *gin.Context, EventParticipants *
 	return err
 }
 
+// Delete removes a participant from the event. Organizers can remove members,
+// only the owner can remove a co-host, and participants can remove
+// themselves. The owner's record cannot be removed, so the event keeps its
+// owner.
 func (r EventParticipantsRepository) Delete(c *gin.Context) {
-	err := EventRepository{}.ReadOne(c, &models.Events{})
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
 	if err != nil {
 		return
 	}
 
+	userDetail := helpers.GetAuthUser(c)
 	var EventParticipants models.EventParticipants
 	errMb := r.ReadOne(c, &EventParticipants)
 	if errMb == nil {
+		if EventParticipants.EventParticipantsUser == Event.EventsCreatedBy || EventParticipants.EventParticipantsRole == helpers.EVENT_ROLE_OWNER {
+			helpers.ResponseBadRequestError(c, "The event owner cannot be removed")
+			return
+		}
+		if EventParticipants.EventParticipantsUser != userDetail.UsersId {
+			role := helpers.EventRole(Event.EventsId, userDetail.UsersId)
+			if EventParticipants.EventParticipantsRole == helpers.EVENT_ROLE_COHOST && role != helpers.EVENT_ROLE_OWNER {
+				helpers.ResponseBadRequestError(c, "Only the event owner can remove a co-host")
+				return
+			}
+			if !helpers.EventRoleIn(role, []string{helpers.EVENT_ROLE_OWNER, helpers.EVENT_ROLE_COHOST}) {
+				helpers.ResponseBadRequestError(c, "Only an organizer can remove other participants")
+				return
+			}
+		}
+
 		filters := bson.D{{Key: "_id", Value: EventParticipants.EventParticipantsId}}
 		config.DB.Collection("EventParticipants").DeleteOne(context.TODO(), filters)
+		if EventParticipants.EventParticipantsStatus == GetEventParticipantStatus("ACCEPTED") {
+			helpers.EventStreamPublish(EventParticipants.EventParticipantsEvent, helpers.EVENT_STREAM_PARTICIPANT_LEFT, gin.H{"event_participants_user": EventParticipants.EventParticipantsUser})
+		}
+		if EventParticipants.EventParticipantsStatus == GetEventParticipantStatus("ACCEPTED") || EventParticipants.EventParticipantsStatus == GetEventParticipantStatus("PAYMENT_PENDING") {
+			EventWaitlistRepository{}.Promote(c, EventParticipants.EventParticipantsEvent)
+		}
 		helpers.ResultMessageSuccess(c, "User removed from event")
 	}
 }
 
+// StreamJoined pushes a new accepted participant to the event stream, with
+// the same user fields the participant list returns.
+func (r EventParticipantsRepository) StreamJoined(eventId primitive.ObjectID, userId primitive.ObjectID) {
+	UsersDetail := EventSettlementRepository{}.Users([]primitive.ObjectID{userId})
+	helpers.EventStreamPublish(eventId, helpers.EVENT_STREAM_PARTICIPANT_JOINED, gin.H{
+		"event_participants_user":        userId,
+		"event_participants_user_detail": UsersDetail[userId],
+	})
+}
+
+// NotifyJoined pushes a new accepted participant to the event stream and
+// tells the active participants someone joined.
+func (r EventParticipantsRepository) NotifyJoined(c *gin.Context, Event models.Events, userId primitive.ObjectID) {
+	r.StreamJoined(Event.EventsId, userId)
+	for _, v := range r.ActiveParticipants(Event.EventsId) {
+		NotificationMessage := models.NotificationMessage{
+			Message: "{0}有新的夥伴加入!",
+			Data:    []map[string]interface{}{helpers.NotificationFormatEvent(Event)},
+		}
+		helpers.NotificationsCreate(c, helpers.NOTIFICATION_JOINING_NEW, v.EventParticipantsUser, NotificationMessage, Event.EventsId)
+	}
+}
+
+// UpdateRole promotes an accepted participant to co-host or demotes them back
+// to member. The owner's role cannot be changed.
+func (r EventParticipantsRepository) UpdateRole(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	var payload EventParticipantsRoleRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	var EventParticipants models.EventParticipants
+	errMb := r.ReadOne(c, &EventParticipants)
+	if errMb != nil {
+		return
+	}
+
+	if EventParticipants.EventParticipantsUser == Event.EventsCreatedBy {
+		helpers.ResponseBadRequestError(c, "The role of the event owner cannot be changed")
+		return
+	}
+
+	if EventParticipants.EventParticipantsStatus != GetEventParticipantStatus("ACCEPTED") {
+		helpers.ResponseBadRequestError(c, "Only accepted participants can be assigned a role")
+		return
+	}
+
+	EventParticipants.EventParticipantsRole = payload.EventParticipantsRole
+	filters := bson.D{{Key: "_id", Value: EventParticipants.EventParticipantsId}}
+	upd := bson.D{{Key: "$set", Value: bson.M{"event_participants_role": payload.EventParticipantsRole}}}
+	config.DB.Collection("EventParticipants").UpdateOne(context.TODO(), filters, upd)
+
+	EventParticipants.EventParticipantsStatusLabel = GetEventParticipantStatusLabel(EventParticipants.EventParticipantsStatus)
+	c.JSON(http.StatusOK, EventParticipants)
+}
+
 func (r EventParticipantsRepository) Read(c *gin.Context) {
 	participantId := helpers.StringToPrimitiveObjId(c.Param("participantId"))
 
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventPaymentExpiryRepository releases the seats of participants who did not
+// pay before their payment deadline and promotes the waitlist into them. It
+// runs in the background of every instance; a participant is removed before
+// the seat is released, so each seat is only released once.
+type EventPaymentExpiryRepository struct{}
+
+const eventPaymentExpiryInterval = time.Minute
+
+// Run releases the expired payments on every tick until the process exits.
+func (r EventPaymentExpiryRepository) Run() {
+	for {
+		r.Tick(time.Now())
+		time.Sleep(eventPaymentExpiryInterval)
+	}
+}
+
+// Tick runs one pass, recovering from a panic so the next tick still runs.
+func (r EventPaymentExpiryRepository) Tick(now time.Time) {
+	defer func() {
+		if err := recover(); err != nil {
+			fmt.Println("payment expiry panic:", err)
+		}
+	}()
+	r.Expire(now)
+}
+
+// Expire removes the PAYMENT_PENDING participants whose deadline passed and
+// fills their seats from the waitlist. Participants left pending before the
+// deadline existed are given one from now.
+func (r EventPaymentExpiryRepository) Expire(now time.Time) {
+	backfill := bson.D{
+		{Key: "event_participants_status", Value: GetEventParticipantStatus("PAYMENT_PENDING")},
+		{Key: "event_participants_payment_deadline", Value: bson.M{"$exists": false}},
+	}
+	deadline := primitive.NewDateTimeFromTime(now.Add(EventPaymentRepository{}.Deadline()))
+	config.DB.Collection("EventParticipants").UpdateMany(context.TODO(), backfill, bson.D{{Key: "$set", Value: bson.M{"event_participants_payment_deadline": deadline}}})
+
+	filter := bson.D{
+		{Key: "event_participants_status", Value: GetEventParticipantStatus("PAYMENT_PENDING")},
+		{Key: "event_participants_payment_deadline", Value: bson.M{"$lte": primitive.NewDateTimeFromTime(now)}},
+	}
+	var Expired []models.EventParticipants
+	cursor, err := config.DB.Collection("EventParticipants").Find(context.TODO(), filter)
+	if err != nil {
+		fmt.Println("payment expiry err: " + err.Error())
+		return
+	}
+	cursor.All(context.TODO(), &Expired)
+
+	var eventIds []primitive.ObjectID
+	released := map[primitive.ObjectID]bool{}
+	for _, v := range Expired {
+		// The participant may have paid, or another instance released the
+		// seat, in the meantime
+		claim := bson.D{
+			{Key: "_id", Value: v.EventParticipantsId},
+			{Key: "event_participants_status", Value: GetEventParticipantStatus("PAYMENT_PENDING")},
+			{Key: "event_participants_payment_deadline", Value: bson.M{"$lte": primitive.NewDateTimeFromTime(now)}},
+		}
+		result, err := config.DB.Collection("EventParticipants").DeleteOne(context.TODO(), claim)
+		if err != nil || result.DeletedCount == 0 {
+			continue
+		}
+
+		var Event models.Events
+		config.DB.Collection("Events").FindOne(context.TODO(), bson.D{{Key: "_id", Value: v.EventParticipantsEvent}}).Decode(&Event)
+		NotificationMessage := models.NotificationMessage{
+			Message: "{0}的付款期限已過, 你的名額已釋出",
+			Data:    []map[string]interface{}{helpers.NotificationFormatEvent(Event)},
+		}
+		helpers.NotificationsInsert(helpers.NOTIFICATION_EVENT_PAYMENT_EXPIRED, v.EventParticipantsUser, NotificationMessage, Event.EventsId, Event.EventsCreatedBy)
+
+		if !released[v.EventParticipantsEvent] {
+			eventIds = append(eventIds, v.EventParticipantsEvent)
+		}
+		released[v.EventParticipantsEvent] = true
+	}
+
+	for _, eventId := range eventIds {
+		// Push notifications need a request, so only the in-app ones are sent
+		EventWaitlistRepository{}.PromoteAs(r.Owner(eventId), eventId)
+	}
+}
+
+// Owner is the event owner, on whose behalf the waitlist is promoted.
+func (r EventPaymentExpiryRepository) Owner(eventId primitive.ObjectID) primitive.ObjectID {
+	var Event models.Events
+	config.DB.Collection("Events").FindOne(context.TODO(), bson.D{{Key: "_id", Value: eventId}}).Decode(&Event)
+	return Event.EventsCreatedBy
+}
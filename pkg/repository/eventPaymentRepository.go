@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventPaymentRepository collects the fee of paid events. Participants who
+// would be accepted into a paid event are held as PAYMENT_PENDING, which
+// keeps their seat, until the provider confirms the payment by webhook or
+// the payment deadline passes.
+type EventPaymentRepository struct{}
+type EventPaymentFakeRequest struct {
+	Status string `json:"status" validate:"required,oneof=PAID FAILED"`
+}
+
+// Read returns the payment state of the user's participation.
+func (r EventPaymentRepository) Read(c *gin.Context) {
+	EventParticipants, ok := r.ReadParticipant(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, r.Response(EventParticipants))
+}
+
+// Create starts a new payment for a PAYMENT_PENDING participation, for when
+// the previous checkout was abandoned or failed to start.
+func (r EventPaymentRepository) Create(c *gin.Context) {
+	EventParticipants, ok := r.ReadParticipant(c)
+	if !ok {
+		return
+	}
+	if EventParticipants.EventParticipantsStatus != GetEventParticipantStatus("PAYMENT_PENDING") {
+		helpers.ResponseBadRequestError(c, "There is no pending payment for this event")
+		return
+	}
+
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	EventParticipants, err = r.Start(Event, EventParticipants)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, r.Response(EventParticipants))
+}
+
+func (r EventPaymentRepository) ReadParticipant(c *gin.Context) (models.EventParticipants, bool) {
+	userDetail := helpers.GetAuthUser(c)
+	var EventParticipants models.EventParticipants
+	filter := bson.D{
+		{Key: "event_participants_event", Value: helpers.StringToPrimitiveObjId(c.Param("id"))},
+		{Key: "event_participants_user", Value: userDetail.UsersId},
+	}
+	err := config.DB.Collection("EventParticipants").FindOne(context.TODO(), filter).Decode(&EventParticipants)
+	if err != nil {
+		helpers.ResponseNotFound(c, "You are not a participant of this event")
+		return EventParticipants, false
+	}
+	return EventParticipants, true
+}
+
+func (r EventPaymentRepository) Response(EventParticipants models.EventParticipants) gin.H {
+	return gin.H{
+		"event_participants_id":               EventParticipants.EventParticipantsId,
+		"event_participants_status":           EventParticipants.EventParticipantsStatus,
+		"event_participants_status_label":     GetEventParticipantStatusLabel(EventParticipants.EventParticipantsStatus),
+		"event_participants_is_paid":          EventParticipants.EventParticipantsIsPaid,
+		"event_participants_paid_amount":      EventParticipants.EventParticipantsPaidAmount,
+		"event_participants_paid_at":          EventParticipants.EventParticipantsPaidAt,
+		"event_participants_payment_url":      EventParticipants.EventParticipantsPaymentUrl,
+		"event_participants_payment_deadline": EventParticipants.EventParticipantsPaymentDeadline,
+	}
+}
+
+// Required reports whether joining the event needs a payment. Payments are
+// only collected once a provider is configured.
+func (r EventPaymentRepository) Required(Event models.Events) bool {
+	return config.APP.PaymentProvider != "" && Event.EventsPaymentRequired == 1 && Event.EventsPaymentFee != nil && *Event.EventsPaymentFee > 0
+}
+
+// Currency is the currency the fee of the event is charged in.
+func (r EventPaymentRepository) Currency(Event models.Events) string {
+	currency := Event.EventsCurrency
+	if currency == "" {
+		currency = config.APP.PaymentCurrency
+	}
+	if currency == "" {
+		currency = helpers.DEFAULT_CURRENCY
+	}
+	return currency
+}
+
+// Deadline is how long a PAYMENT_PENDING participant keeps the seat.
+func (r EventPaymentRepository) Deadline() time.Duration {
+	hours := config.APP_LIMIT.PaymentDeadlineHours
+	if hours <= 0 {
+		hours = 24
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// Start creates a payment intent for the participant and stores it, marking
+// the participant PAYMENT_PENDING. The payment deadline is set on the first
+// attempt; starting the payment again does not extend it.
+func (r EventPaymentRepository) Start(Event models.Events, EventParticipants models.EventParticipants) (models.EventParticipants, error) {
+	filters := bson.D{{Key: "_id", Value: EventParticipants.EventParticipantsId}}
+	if EventParticipants.EventParticipantsPaymentDeadline == 0 {
+		EventParticipants.EventParticipantsPaymentDeadline = primitive.NewDateTimeFromTime(time.Now().Add(r.Deadline()))
+		upd := bson.D{{Key: "$set", Value: bson.M{"event_participants_payment_deadline": EventParticipants.EventParticipantsPaymentDeadline}}}
+		config.DB.Collection("EventParticipants").UpdateOne(context.TODO(), filters, upd)
+	}
+
+	provider, err := helpers.Payment()
+	if err != nil {
+		return EventParticipants, err
+	}
+
+	intent, err := provider.CreateIntent(helpers.PaymentIntentRequest{
+		Reference:   EventParticipants.EventParticipantsId.Hex(),
+		Amount:      *Event.EventsPaymentFee,
+		Currency:    r.Currency(Event),
+		Description: Event.EventsName,
+	})
+	if err != nil {
+		return EventParticipants, err
+	}
+
+	paymentRequest, _ := json.Marshal(intent)
+	EventParticipants.EventParticipantsStatus = GetEventParticipantStatus("PAYMENT_PENDING")
+	EventParticipants.EventParticipantsPaymentIntent = intent.Id
+	EventParticipants.EventParticipantsPaymentUrl = intent.CheckoutUrl
+	EventParticipants.EventParticipantsPaymentRequest = string(paymentRequest)
+
+	upd := bson.D{{Key: "$set", Value: bson.M{
+		"event_participants_status":          EventParticipants.EventParticipantsStatus,
+		"event_participants_payment_intent":  EventParticipants.EventParticipantsPaymentIntent,
+		"event_participants_payment_url":     EventParticipants.EventParticipantsPaymentUrl,
+		"event_participants_payment_request": EventParticipants.EventParticipantsPaymentRequest,
+	}}}
+	_, err = config.DB.Collection("EventParticipants").UpdateOne(context.TODO(), filters, upd)
+	return EventParticipants, err
+}
+
+// Notify asks the participant to complete the payment, for participants who
+// were accepted by someone else.
+func (r EventPaymentRepository) Notify(c *gin.Context, Event models.Events, EventParticipants models.EventParticipants) {
+	r.NotifyAs(helpers.GetAuthUser(c).UsersId, Event, EventParticipants)
+}
+
+// NotifyAs asks the participant to complete the payment on behalf of
+// createdBy, for background jobs with no request.
+func (r EventPaymentRepository) NotifyAs(createdBy primitive.ObjectID, Event models.Events, EventParticipants models.EventParticipants) {
+	NotificationMessage := models.NotificationMessage{
+		Message: "你已獲得{0}的名額, 請完成付款以確認參加",
+		Data: []map[string]interface{}{
+			helpers.NotificationFormatEvent(Event),
+			{"event_participants_payment_url": EventParticipants.EventParticipantsPaymentUrl},
+		},
+	}
+	helpers.NotificationsInsert(helpers.NOTIFICATION_EVENT_PAYMENT_REQUIRED, EventParticipants.EventParticipantsUser, NotificationMessage, Event.EventsId, createdBy)
+}
+
+// Webhook receives the provider callback. The signature is verified by the
+// provider before the payload is trusted.
+func (r EventPaymentRepository) Webhook(c *gin.Context) {
+	provider, err := helpers.Payment()
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		helpers.ResponseBadRequestError(c, "Invalid payload")
+		return
+	}
+
+	webhook, err := provider.ParseWebhook(c.Request.Header, body)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "PAY01-WEBHOOK: " + err.Error()})
+		return
+	}
+	r.Process(c, webhook, body)
+}
+
+// Fake completes a payment of the fake provider by sending it a signed
+// webhook, standing in for the gateway's checkout page. It is only routed
+// when PAYMENT_FAKE_CHECKOUT is true, and only for the user's own payment.
+func (r EventPaymentRepository) Fake(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	provider, err := helpers.Payment()
+	fake, ok := provider.(helpers.FakePaymentProvider)
+	if err != nil || !ok {
+		helpers.ResponseNotFound(c, "Not found")
+		return
+	}
+
+	var payload EventPaymentFakeRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	var EventParticipants models.EventParticipants
+	filter := bson.D{
+		{Key: "event_participants_payment_intent", Value: c.Param("intentId")},
+		{Key: "event_participants_user", Value: userDetail.UsersId},
+	}
+	err = config.DB.Collection("EventParticipants").FindOne(context.TODO(), filter).Decode(&EventParticipants)
+	if err != nil {
+		helpers.ResponseNotFound(c, "Payment not found")
+		return
+	}
+
+	var intent helpers.PaymentIntent
+	json.Unmarshal([]byte(EventParticipants.EventParticipantsPaymentRequest), &intent)
+	body, _ := json.Marshal(helpers.PaymentWebhook{
+		IntentId: intent.Id,
+		Status:   payload.Status,
+		Amount:   intent.Amount,
+		Currency: intent.Currency,
+	})
+
+	header := http.Header{}
+	header.Set(helpers.PAYMENT_SIGNATURE_HEADER, fake.Sign(body))
+	webhook, err := fake.ParseWebhook(header, body)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "PAY01-WEBHOOK: " + err.Error()})
+		return
+	}
+	r.Process(c, webhook, body)
+}
+
+// Process applies a verified webhook. A paid intent accepts the participant
+// like an accepted invitation does, once the amount and currency match the
+// stored intent; a failed one releases the seat to the waitlist. Repeated
+// callbacks are ignored.
+func (r EventPaymentRepository) Process(c *gin.Context, webhook helpers.PaymentWebhook, body []byte) {
+	var EventParticipants models.EventParticipants
+	filter := bson.D{{Key: "event_participants_payment_intent", Value: webhook.IntentId}}
+	err := config.DB.Collection("EventParticipants").FindOne(context.TODO(), filter).Decode(&EventParticipants)
+	if err != nil {
+		helpers.ResponseNotFound(c, "Payment not found")
+		return
+	}
+
+	if EventParticipants.EventParticipantsStatus != GetEventParticipantStatus("PAYMENT_PENDING") {
+		helpers.ResponseSuccessMessage(c, "Payment already processed")
+		return
+	}
+
+	var Event models.Events
+	config.DB.Collection("Events").FindOne(context.TODO(), bson.D{{Key: "_id", Value: EventParticipants.EventParticipantsEvent}}).Decode(&Event)
+
+	pendingFilter := bson.D{
+		{Key: "_id", Value: EventParticipants.EventParticipantsId},
+		{Key: "event_participants_status", Value: GetEventParticipantStatus("PAYMENT_PENDING")},
+	}
+
+	switch webhook.Status {
+	case helpers.PAYMENT_STATUS_PAID:
+		// The fee may have changed since the intent was created
+		var intent helpers.PaymentIntent
+		json.Unmarshal([]byte(EventParticipants.EventParticipantsPaymentRequest), &intent)
+		if intent.Id != webhook.IntentId || math.Abs(webhook.Amount-intent.Amount) > 0.005 {
+			helpers.ResponseBadRequestError(c, "Paid amount does not match the payment")
+			return
+		}
+		if !strings.EqualFold(webhook.Currency, intent.Currency) {
+			helpers.ResponseBadRequestError(c, "Paid currency does not match the payment")
+			return
+		}
+
+		upd := bson.D{{Key: "$set", Value: bson.M{
+			"event_participants_status":           GetEventParticipantStatus("ACCEPTED"),
+			"event_participants_is_paid":          1,
+			"event_participants_paid_amount":      webhook.Amount,
+			"event_participants_paid_at":          time.Now().Format(time.RFC3339),
+			"event_participants_payment_response": string(body),
+		}}}
+		result, err := config.DB.Collection("EventParticipants").UpdateOne(context.TODO(), pendingFilter, upd)
+		if err != nil || result.ModifiedCount == 0 {
+			helpers.ResponseSuccessMessage(c, "Payment already processed")
+			return
+		}
+
+		NotificationMessage := models.NotificationMessage{
+			Message: "已收到你的付款, {0}的名額已確認",
+			Data:    []map[string]interface{}{helpers.NotificationFormatEvent(Event)},
+		}
+		helpers.NotificationsCreate(c, helpers.NOTIFICATION_EVENT_PAYMENT_PAID, EventParticipants.EventParticipantsUser, NotificationMessage, Event.EventsId)
+
+		// The webhook has no logged in user, the participant joins on their own
+		var User models.Users
+		config.DB.Collection("Users").FindOne(context.TODO(), bson.D{{Key: "_id", Value: EventParticipants.EventParticipantsUser}}).Decode(&User)
+		c.Set("user", &User)
+		EventRepository{}.HandleParticipation(c, EventParticipants.EventParticipantsUser, Event.EventsId)
+		EventRepository{}.HandleBadges(c, Event.EventsId)
+		EventRepository{}.HandleParticipantFriend(c, Event.EventsId)
+		EventParticipantsRepository{}.NotifyJoined(c, Event, EventParticipants.EventParticipantsUser)
+	case helpers.PAYMENT_STATUS_FAILED:
+		result, err := config.DB.Collection("EventParticipants").DeleteOne(context.TODO(), pendingFilter)
+		if err != nil || result.DeletedCount == 0 {
+			helpers.ResponseSuccessMessage(c, "Payment already processed")
+			return
+		}
+		EventWaitlistRepository{}.Promote(c, Event.EventsId)
+	default:
+		fmt.Println("payment webhook status ignored: " + webhook.Status)
+	}
+
+	helpers.ResponseSuccessMessage(c, "Payment "+webhook.Status)
+}
@@ -38,65 +38,28 @@ type EventRequest struct {
 	EventsMeetingPointLng  float64  `json:"events_meeting_point_lng" form:"events_meeting_point_lng" validate:"required"`
 	EventsMeetingPointName string   `json:"events_meeting_point_name" form:"events_meeting_point_name" validate:"required"`
 	EventsParticipants     []string `json:"events_participants" form:"events_participants"`
+	EventsVisibility       string   `json:"events_visibility" form:"events_visibility" validate:"omitempty,oneof=PUBLIC FRIENDS INVITE"`
+	EventsCurrency         string   `json:"events_currency" form:"events_currency" validate:"omitempty,iso4217"`
 }
 
 func (r EventRepository) Retrieve(c *gin.Context) {
 	var results []models.Events
 
-	eventPeriodBegin := c.Query("event_period_begin")
-	eventPeriodEnd := c.Query("event_period_end")
-	eventRewilding := c.Query("event_rewilding")
-	eventIsPast := c.Query("event_past")
-
-	var eventBegin time.Time
-	var eventEnd time.Time
-
-	match := bson.M{
-		"events_deleted": bson.M{"$exists": false},
-	}
-
-	currentTime := primitive.NewDateTimeFromTime(time.Now())
-
-	if eventPeriodBegin != "" && eventPeriodEnd == "" {
-		eventBegin = helpers.StringToDatetime(eventPeriodBegin + " 00:00:00")
-		eventEnd = helpers.StringToDatetime(eventPeriodBegin + " 23:59:59")
-		match["events_date"] = bson.M{
-			"$gte": primitive.NewDateTimeFromTime(eventBegin),
-			"$lte": primitive.NewDateTimeFromTime(eventEnd),
-		}
-
-	} else if eventPeriodBegin == "" && eventPeriodEnd != "" {
-		eventBegin = helpers.StringToDatetime(eventPeriodEnd + " 00:00:00")
-		eventEnd = helpers.StringToDatetime(eventPeriodEnd + " 23:59:59")
-		match["events_date"] = bson.M{
-			"$gte": primitive.NewDateTimeFromTime(eventBegin),
-			"$lte": primitive.NewDateTimeFromTime(eventEnd),
-		}
-
-	} else if eventPeriodBegin != "" && eventPeriodEnd != "" {
-		eventBegin = helpers.StringToDatetime(eventPeriodBegin + " 00:00:00")
-		eventEnd = helpers.StringToDatetime(eventPeriodEnd + " 23:59:59")
-		match["events_date"] = bson.M{
-			"$gte": primitive.NewDateTimeFromTime(eventBegin),
-			"$lte": primitive.NewDateTimeFromTime(eventEnd),
-		}
-	} else if eventIsPast == "1" {
-		match["events_date"] = bson.M{"$lt": currentTime}
-	} else {
-		match["events_date"] = bson.M{"$gte": currentTime}
+	search := EventSearchRepository{}
+	agg, err := search.Pipeline(c)
+	if err != nil {
+		helpers.ResponseBadRequestError(c, err.Error())
+		return
 	}
 
-	if eventRewilding != "" {
-		rewildingId := helpers.StringToPrimitiveObjId(eventRewilding)
-		match["events_rewilding"] = rewildingId
+	paginate, limit, err := search.Paginate(c)
+	if err != nil {
+		helpers.ResponseBadRequestError(c, err.Error())
+		return
 	}
+	agg = append(agg, paginate...)
 
-	filterPeriod := bson.D{{
-		Key: "$match", Value: match,
-	}}
-
-	agg := mongo.Pipeline{
-		filterPeriod,
+	agg = append(agg,
 		bson.D{{
 			Key: "$lookup", Value: bson.M{
 				"from":         "Users",
@@ -108,31 +71,30 @@ func (r EventRepository) Retrieve(c *gin.Context) {
 		bson.D{{
 			Key: "$unwind", Value: "$events_created_by_user",
 		}},
+		RewildingRepository{}.Lookup("events_rewilding", "events_rewilding_detail"),
+		// Events keep showing when their rewilding is hidden
 		bson.D{{
-			Key: "$lookup", Value: bson.M{
-				"from":         "Rewilding",
-				"localField":   "events_rewilding",
-				"foreignField": "_id",
-				"as":           "events_rewilding_detail",
-			},
-		}},
-		bson.D{{
-			Key: "$unwind", Value: "$events_rewilding_detail",
+			Key: "$unwind", Value: bson.M{"path": "$events_rewilding_detail", "preserveNullAndEmptyArrays": true},
 		}},
-	}
+	)
 
 	cursor, err := config.DB.Collection("Events").Aggregate(context.TODO(), agg)
-	cursor.All(context.TODO(), &results)
-
 	if err != nil {
+		helpers.ResponseError(c, err.Error())
 		return
 	}
+	cursor.All(context.TODO(), &results)
 
 	if len(results) == 0 {
 		helpers.ResponseNoData(c, "No Data")
 		return
 	}
 
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+		c.Header("X-Next-Cursor", search.NextCursor(c, results[limit-1]))
+	}
+
 	results = r.RetrieveParticipantDetails(results)
 	c.JSON(http.StatusOK, results)
 }
@@ -290,6 +252,7 @@ func (r EventRepository) Create(c *gin.Context) {
 			EventParticipantsEvent:     Events.EventsId,
 			EventParticipantsUser:      userDetail.UsersId,
 			EventParticipantsStatus:    GetEventParticipantStatus("ACCEPTED"),
+			EventParticipantsRole:      helpers.EVENT_ROLE_OWNER,
 			EventParticipantsCreatedBy: userDetail.UsersId,
 			EventParticipantsCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
 		},
@@ -335,6 +298,12 @@ func (r EventRepository) HandleParticipation(c *gin.Context, userId primitive.Ob
 }
 
 func (r EventRepository) HandleParticipantFriend(c *gin.Context, eventId primitive.ObjectID) {
+	r.SuggestFriends(eventId)
+}
+
+// SuggestFriends suggests the participants of the event to each other, for
+// background jobs with no request.
+func (r EventRepository) SuggestFriends(eventId primitive.ObjectID) {
 	var EventParticipants []models.EventParticipants
 
 	filters := bson.D{{Key: "event_participants_event", Value: eventId}}
@@ -346,7 +315,7 @@ func (r EventRepository) HandleParticipantFriend(c *gin.Context, eventId primiti
 	for i := 0; i < lenParticipants; i++ {
 		for j := i + 1; j < lenParticipants; j++ {
 			var UserFriends models.UserFriends
-			err := r.CheckIfFriend(c, EventParticipants[i].EventParticipantsUser, EventParticipants[j].EventParticipantsUser, &UserFriends)
+			err := r.CheckIfFriend(EventParticipants[i].EventParticipantsUser, EventParticipants[j].EventParticipantsUser, &UserFriends)
 			if err == mongo.ErrNoDocuments {
 				suggestedStatus := 0
 				ins := models.UserFriends{
@@ -361,7 +330,7 @@ func (r EventRepository) HandleParticipantFriend(c *gin.Context, eventId primiti
 	}
 }
 
-func (r EventRepository) CheckIfFriend(c *gin.Context, user1 primitive.ObjectID, user2 primitive.ObjectID, UserFriends *models.UserFriends) error {
+func (r EventRepository) CheckIfFriend(user1 primitive.ObjectID, user2 primitive.ObjectID, UserFriends *models.UserFriends) error {
 	err := config.DB.Collection("UserFriends").FindOne(context.TODO(), bson.D{
 		{
 			Key: "$or", Value: []bson.D{
@@ -413,6 +382,8 @@ func (r EventRepository) Read(c *gin.Context) {
 	if err == nil {
 		if len(Events) == 0 {
 			helpers.ResponseNoData(c, "No data")
+		} else if !helpers.EventVisible(Events[0], helpers.GetAuthUserByCheckHeaders(c).UsersId) {
+			helpers.ResponseNotFound(c, "Event not found")
 		} else {
 			Events = r.RetrieveParticipantDetails(Events)
 			returnData := returnData{
@@ -432,8 +403,6 @@ func (r EventRepository) Delete(c *gin.Context) {
 	var Events models.Events
 	err := r.ReadOne(c, &Events)
 
-	// TODO: What are the rules of allowing delete
-
 	isDeleted := 1
 	Events.EventsDeleted = &isDeleted
 	Events.EventsDeletedAt = primitive.NewDateTimeFromTime(time.Now())
@@ -442,10 +411,22 @@ func (r EventRepository) Delete(c *gin.Context) {
 		filters := bson.D{{Key: "_id", Value: Events.EventsId}}
 		upd := bson.D{{Key: "$set", Value: Events}}
 		config.DB.Collection("Events").UpdateOne(context.TODO(), filters, upd)
+
+		if c.Query("scope") == "future" && !helpers.MongoZeroID(Events.EventsSeries) {
+			EventSeriesRepository{}.DeleteFuture(Events)
+		}
 		c.JSON(http.StatusOK, Events)
 	}
 }
 
+// IsOrganizer reports whether the authenticated user is the owner or a
+// co-host of the event.
+func (r EventRepository) IsOrganizer(c *gin.Context, Events models.Events) bool {
+	userDetail := helpers.GetAuthUser(c)
+	role := helpers.EventRole(Events.EventsId, userDetail.UsersId)
+	return helpers.EventRoleIn(role, []string{helpers.EVENT_ROLE_OWNER, helpers.EVENT_ROLE_COHOST})
+}
+
 func (r EventRepository) ReadOne(c *gin.Context, Events *models.Events) error {
 	id, _ := primitive.ObjectIDFromHex(c.Param("id"))
 	filter := bson.D{{Key: "_id", Value: id}}
@@ -501,24 +482,55 @@ func (r EventRepository) Update(c *gin.Context) {
 			fileName := cloudflare.ImageDelivery(cloudflareResponse.Result.Id, "public")
 			Events.EventsPhoto = fileName
 		}
+		Previous := Events
 		r.ProcessData(c, &Events, payload)
+		if Previous.EventsCurrency != Events.EventsCurrency {
+			rebaseErr := EventAccountingRepository{}.Rebase(Previous, Events)
+			if rebaseErr != nil {
+				helpers.ResponseBadRequestError(c, "Unable to change the currency of the accounting records. "+rebaseErr.Error())
+				return
+			}
+		}
+
+		updateFuture := c.Query("scope") == "future" && !helpers.MongoZeroID(Events.EventsSeries)
+		if !helpers.MongoZeroID(Events.EventsSeries) && !updateFuture {
+			detached := true
+			Events.EventsSeriesDetached = &detached
+		}
+
 		filters := bson.D{{Key: "_id", Value: Events.EventsId}}
 		upd := bson.D{{Key: "$set", Value: Events}}
 		config.DB.Collection("Events").UpdateOne(context.TODO(), filters, upd)
+		History := EventHistoryRepository{}.Record(c, Previous, Events)
+		r.NotifyUpdated(c, Events, History)
 
-		ActiveParticipants := EventParticipantsRepository{}.ActiveParticipants(Events.EventsId)
-		for _, v := range ActiveParticipants {
-			NotificationMessage := models.NotificationMessage{
-				Message: "團主於{0}中更新了重要資訊! 點擊查看",
-				Data:    []map[string]interface{}{helpers.NotificationFormatEvent(Events)},
-			}
-			helpers.NotificationsCreate(c, helpers.NOTIFICATION_EVENT_INFO, v.EventParticipantsUser, NotificationMessage, Events.EventsId)
+		if updateFuture {
+			EventSeriesRepository{}.UpdateFuture(c, Events, Previous.EventsDate.Time())
 		}
 
 		r.Read(c)
 	}
 }
 
+// NotifyUpdated tells the participants about an update, including the
+// fields that changed in the recorded version.
+func (r EventRepository) NotifyUpdated(c *gin.Context, Events models.Events, History models.EventHistory) {
+	ActiveParticipants := EventParticipantsRepository{}.ActiveParticipants(Events.EventsId)
+	for _, v := range ActiveParticipants {
+		NotificationMessage := models.NotificationMessage{
+			Message: "團主於{0}中更新了重要資訊! 點擊查看",
+			Data: []map[string]interface{}{
+				helpers.NotificationFormatEvent(Events),
+				{
+					"event_history_version": History.EventHistoryVersion,
+					"event_history_changes": History.EventHistoryChanges,
+				},
+			},
+		}
+		helpers.NotificationsCreate(c, helpers.NOTIFICATION_EVENT_INFO, v.EventParticipantsUser, NotificationMessage, Events.EventsId)
+	}
+}
+
 func (r EventRepository) ProcessData(c *gin.Context, Events *models.Events, payload EventRequest) {
 	var Rewilding models.Rewilding
 	eventsLat := payload.EventsLat
@@ -530,8 +542,6 @@ func (r EventRepository) ProcessData(c *gin.Context, Events *models.Events, payl
 	eventDateEnd := helpers.StringToPrimitiveDateTime(payload.EventsDateEnd)
 	eventDurationSecond := eventDateEnd.Time().Sub(eventDate.Time()).Seconds()
 
-	eventStatisticDistance := (helpers.Haversine(eventsLat, eventsLng, meetingPointLat, meetingPointLng) * 100000) / 70
-
 	Events.EventsDate = eventDate
 	Events.EventsDateEnd = eventDateEnd
 	Events.EventsDeadline = helpers.StringToPrimitiveDateTime(payload.EventsDeadline)
@@ -547,8 +557,19 @@ func (r EventRepository) ProcessData(c *gin.Context, Events *models.Events, payl
 	Events.EventsMeetingPointName = payload.EventsMeetingPointName
 	Events.EventsLat = eventsLat
 	Events.EventsLng = eventsLng
+	Events.EventsLocation = helpers.GeoPoint(eventsLat, eventsLng)
 	Events.EventsParticipantLimit = &payload.EventsParticipantLimit
 	Events.EventsCountryCode = payload.EventsCountryCode
+	if payload.EventsVisibility != "" {
+		Events.EventsVisibility = payload.EventsVisibility
+	} else if Events.EventsVisibility == "" {
+		Events.EventsVisibility = helpers.EVENT_VISIBILITY_PUBLIC
+	}
+	if payload.EventsCurrency != "" {
+		Events.EventsCurrency = payload.EventsCurrency
+	} else if Events.EventsCurrency == "" {
+		Events.EventsCurrency = helpers.DEFAULT_CURRENCY
+	}
 
 	config.DB.Collection("Rewilding").FindOne(context.TODO(), bson.D{{Key: "_id", Value: Events.EventsRewilding}}).Decode(&Rewilding)
 
@@ -567,16 +588,32 @@ func (r EventRepository) ProcessData(c *gin.Context, Events *models.Events, payl
 		Events.EventsPhoto = coverImage
 	}
 
-	Events.EventsStatisticDistance = eventStatisticDistance
+	Events.EventsStatisticDistance = r.StatisticDistance(*Events)
 	Events.EventsStatisticTime = eventDurationSecond
 }
 
+// StatisticDistance is the distance in 70cm steps, following the uploaded
+// route when there is one and the straight line from the meeting point
+// otherwise.
+func (r EventRepository) StatisticDistance(Events models.Events) float64 {
+	if Events.EventsRoute != nil {
+		return (Events.EventsRoute.EventsRouteDistance * 100) / 70
+	}
+	return (helpers.Haversine(Events.EventsLat, Events.EventsLng, Events.EventsMeetingPointLat, Events.EventsMeetingPointLng) * 100000) / 70
+}
+
 func (r EventRepository) Options(c *gin.Context) {
 	RefRewildingTypes := helpers.RefRewildingTypes()
 	c.JSON(http.StatusOK, gin.H{"rewilding_types": RefRewildingTypes})
 }
 
 func (r EventRepository) HandleBadges(c *gin.Context, eventId primitive.ObjectID) {
+	r.HandleBadgesAs(eventId, helpers.GetAuthUser(c).UsersId)
+}
+
+// HandleBadgesAs allocates the participant badges to the user, for
+// background jobs with no request.
+func (r EventRepository) HandleBadgesAs(eventId primitive.ObjectID, userId primitive.ObjectID) {
 	agg := mongo.Pipeline{
 		bson.D{{
 			Key: "$match", Value: bson.M{
@@ -622,7 +659,7 @@ func (r EventRepository) HandleBadges(c *gin.Context, eventId primitive.ObjectID
 
 	for _, v := range results {
 		if v.EventParticipantBadges == nil && v.EventParticipantsUser != v.EventParticipantsEventDetail.EventsCreatedBy {
-			helpers.BadgeAllocate(c, "R2", helpers.BADGE_EVENT_PARTICIPANTS, v.EventParticipantsUser, primitive.NilObjectID)
+			helpers.BadgeInsert("R2", helpers.BADGE_EVENT_PARTICIPANTS, v.EventParticipantsUser, userId, userId)
 		}
 	}
 }
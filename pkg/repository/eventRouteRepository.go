@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EventRouteRepository handles the GPX / KML track of an event. The parsed
+// track is kept in EventRoutes, and its statistics are copied onto the event.
+type EventRouteRepository struct{}
+
+// Read returns the track as a GeoJSON feature for map rendering.
+func (r EventRouteRepository) Read(c *gin.Context) {
+	eventId := helpers.StringToPrimitiveObjId(c.Param("id"))
+	var EventRoutes models.EventRoutes
+	err := config.DB.Collection("EventRoutes").FindOne(context.TODO(), bson.D{{Key: "event_routes_event", Value: eventId}}).Decode(&EventRoutes)
+	if err != nil {
+		helpers.ResponseNotFound(c, "Route not found")
+		return
+	}
+
+	c.Header("Content-Type", "application/geo+json")
+	c.JSON(http.StatusOK, gin.H{
+		"type":     "Feature",
+		"geometry": EventRoutes.EventRoutesTrack,
+		"properties": gin.H{
+			"event_routes_id":        EventRoutes.EventRoutesId,
+			"event_routes_format":    EventRoutes.EventRoutesFormat,
+			"event_routes_file_name": EventRoutes.EventRoutesFileName,
+			"events_route":           EventRoutes.EventRoutesStatistic,
+		},
+	})
+}
+
+// FileSize is the largest route file accepted, 5 MB when
+// EVENT_ROUTE_FILE_SIZE is not set.
+func (r EventRouteRepository) FileSize() int64 {
+	if config.APP_LIMIT.EventRouteFileSize <= 0 {
+		return 5 * 1024 * 1024
+	}
+	return config.APP_LIMIT.EventRouteFileSize
+}
+
+// Update uploads the event_routes_file track, replacing any previous one.
+func (r EventRouteRepository) Update(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Events models.Events
+	err := EventRepository{}.ReadOne(c, &Events)
+	if err != nil {
+		return
+	}
+
+	file, err := c.FormFile("event_routes_file")
+	if err != nil {
+		helpers.ResponseBadRequestError(c, "No file is received")
+		return
+	}
+	limit := r.FileSize()
+	if file.Size > limit {
+		helpers.ResponseBadRequestError(c, "Route files can only be up to "+strconv.FormatInt(limit/1024, 10)+" KB")
+		return
+	}
+
+	uploadedFile, err := file.Open()
+	if err != nil {
+		helpers.ResponseBadRequestError(c, "Unable to open file")
+		return
+	}
+	defer uploadedFile.Close()
+	data, err := io.ReadAll(io.LimitReader(uploadedFile, limit))
+	if err != nil {
+		helpers.ResponseBadRequestError(c, "Unable to open file")
+		return
+	}
+
+	format, points, err := helpers.TrackParse(data)
+	if err != nil {
+		helpers.ResponseBadRequestError(c, err.Error())
+		return
+	}
+
+	stats := helpers.TrackStatistic(points)
+	EventRoutes := models.EventRoutes{
+		EventRoutesEvent:    Events.EventsId,
+		EventRoutesFormat:   format,
+		EventRoutesFileName: file.Filename,
+		EventRoutesTrack: models.GeoJsonLineString{
+			Type:        "LineString",
+			Coordinates: helpers.TrackCoordinates(points),
+		},
+		EventRoutesStatistic: models.EventsRoute{
+			EventsRouteDistance:     stats.Distance,
+			EventsRouteAscent:       stats.Ascent,
+			EventsRouteDescent:      stats.Descent,
+			EventsRouteMaxElevation: stats.MaxElevation,
+			EventsRouteMovingTime:   stats.MovingTime,
+		},
+		EventRoutesCreatedBy: userDetail.UsersId,
+		EventRoutesCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	filters := bson.D{{Key: "event_routes_event", Value: Events.EventsId}}
+	opts := options.Replace().SetUpsert(true)
+	_, err = config.DB.Collection("EventRoutes").ReplaceOne(context.TODO(), filters, EventRoutes, opts)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+
+	Events.EventsRoute = &EventRoutes.EventRoutesStatistic
+	r.UpdateEvent(Events, bson.D{{Key: "$set", Value: bson.M{
+		"events_route":              Events.EventsRoute,
+		"events_statistic_distance": EventRepository{}.StatisticDistance(Events),
+	}}})
+	r.Read(c)
+}
+
+func (r EventRouteRepository) Delete(c *gin.Context) {
+	var Events models.Events
+	err := EventRepository{}.ReadOne(c, &Events)
+	if err != nil {
+		return
+	}
+
+	result, err := config.DB.Collection("EventRoutes").DeleteOne(context.TODO(), bson.D{{Key: "event_routes_event", Value: Events.EventsId}})
+	if err != nil || result.DeletedCount == 0 {
+		helpers.ResponseNotFound(c, "Route not found")
+		return
+	}
+
+	Events.EventsRoute = nil
+	r.UpdateEvent(Events, bson.D{
+		{Key: "$unset", Value: bson.M{"events_route": ""}},
+		{Key: "$set", Value: bson.M{"events_statistic_distance": EventRepository{}.StatisticDistance(Events)}},
+	})
+	helpers.ResultMessageSuccess(c, "Route deleted")
+}
+
+func (r EventRouteRepository) UpdateEvent(Events models.Events, upd bson.D) {
+	filters := bson.D{{Key: "_id", Value: Events.EventsId}}
+	config.DB.Collection("Events").UpdateOne(context.TODO(), filters, upd)
+}
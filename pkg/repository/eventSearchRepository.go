@@ -0,0 +1,396 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const eventSearchMaxLimit = 100
+
+// EventSearchRepository builds the discovery pipeline behind GET /event and
+// GET /event/facets.
+//
+// Query parameters:
+//   - lat, lng, radius (metres): events near a point, adds events_distance
+//   - bbox=minLng,minLat,maxLng,maxLat: events inside a bounding box
+//   - events_type, country_code, paid, requires_approval, has_seats: filters
+//   - sort=date|distance|popularity, limit, cursor: cursor pagination
+type EventSearchRepository struct{}
+
+type eventSearchCursor struct {
+	Value float64 `json:"v"`
+	Id    string  `json:"id"`
+}
+
+// Facets returns the counts per filter value for the events matching the
+// current search.
+func (r EventSearchRepository) Facets(c *gin.Context) {
+	pipeline, err := r.Pipeline(c)
+	if err != nil {
+		helpers.ResponseBadRequestError(c, err.Error())
+		return
+	}
+
+	facetGroup := func(field interface{}) bson.A {
+		return bson.A{
+			bson.D{{Key: "$group", Value: bson.M{"_id": field, "count": bson.M{"$sum": 1}}}},
+			bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}}}},
+		}
+	}
+	pipeline = append(pipeline, bson.D{{
+		Key: "$facet", Value: bson.M{
+			"events_type":              facetGroup("$events_type"),
+			"events_country_code":      facetGroup("$events_country_code"),
+			"events_payment_required":  facetGroup(bson.M{"$eq": bson.A{"$events_payment_required", 1}}),
+			"events_requires_approval": facetGroup(bson.M{"$eq": bson.A{"$events_requires_approval", 1}}),
+			"events_seats_availability": facetGroup(bson.M{"$switch": bson.M{
+				"branches": bson.A{
+					bson.M{"case": bson.M{"$eq": bson.A{bson.M{"$type": "$events_seats_remaining"}, "missing"}}, "then": "UNLIMITED"},
+					bson.M{"case": bson.M{"$gt": bson.A{"$events_seats_remaining", 0}}, "then": "AVAILABLE"},
+				},
+				"default": "FULL",
+			}}),
+		},
+	}})
+
+	var EventsFacets []models.EventsFacets
+	cursor, err := config.DB.Collection("Events").Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	cursor.All(context.TODO(), &EventsFacets)
+
+	if len(EventsFacets) == 0 {
+		helpers.ResponseNoData(c, "No Data")
+		return
+	}
+	c.JSON(http.StatusOK, EventsFacets[0])
+}
+
+// Pipeline returns the stages selecting the events matching the query,
+// annotated with events_accepted_count and events_seats_remaining.
+func (r EventSearchRepository) Pipeline(c *gin.Context) (mongo.Pipeline, error) {
+	pipeline := mongo.Pipeline{}
+	match := r.Match(c)
+
+	lat, lng, hasPoint, err := r.Point(c)
+	if err != nil {
+		return nil, err
+	}
+
+	bbox := c.Query("bbox")
+	if hasPoint && bbox != "" {
+		return nil, errors.New("Search either near a point or within a bounding box")
+	}
+
+	if hasPoint {
+		geoNear := bson.M{
+			"near":          helpers.GeoPoint(lat, lng),
+			"distanceField": "events_distance",
+			"spherical":     true,
+			"query":         match,
+		}
+		if radius := c.Query("radius"); radius != "" {
+			maxDistance, err := strconv.ParseFloat(radius, 64)
+			if err != nil || maxDistance <= 0 {
+				return nil, errors.New("Invalid radius value")
+			}
+			geoNear["maxDistance"] = maxDistance
+		}
+		// $geoNear has to be the first stage of the pipeline
+		pipeline = append(pipeline, bson.D{{Key: "$geoNear", Value: geoNear}})
+	} else {
+		if bbox != "" {
+			box, err := r.BoundingBox(bbox)
+			if err != nil {
+				return nil, err
+			}
+			match["events_location"] = bson.M{"$geoWithin": bson.M{"$geometry": box}}
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: match}})
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{
+			Key: "$lookup", Value: bson.M{
+				"from": "EventParticipants",
+				"let":  bson.M{"eventId": "$_id"},
+				"pipeline": bson.A{
+					bson.D{{Key: "$match", Value: bson.M{"$expr": bson.M{"$and": bson.A{
+						bson.M{"$eq": bson.A{"$event_participants_event", "$$eventId"}},
+						bson.M{"$in": bson.A{"$event_participants_status", bson.A{GetEventParticipantStatus("ACCEPTED"), GetEventParticipantStatus("PAYMENT_PENDING")}}},
+					}}}}},
+					bson.D{{Key: "$count", Value: "count"}},
+				},
+				"as": "events_accepted",
+			},
+		}},
+		bson.D{{
+			Key: "$set", Value: bson.M{
+				"events_accepted_count": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$events_accepted.count", 0}}, 0}},
+			},
+		}},
+		bson.D{{
+			Key: "$set", Value: bson.M{
+				"events_seats_remaining": bson.M{"$cond": bson.A{
+					bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$events_participant_limit", 0}}, 0}},
+					bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{"$events_participant_limit", "$events_accepted_count"}}}},
+					"$$REMOVE",
+				}},
+			},
+		}},
+		bson.D{{Key: "$unset", Value: "events_accepted"}},
+	)
+
+	if c.Query("has_seats") == "true" {
+		pipeline = append(pipeline, bson.D{{
+			Key: "$match", Value: bson.M{"$or": bson.A{
+				bson.M{"events_seats_remaining": bson.M{"$exists": false}},
+				bson.M{"events_seats_remaining": bson.M{"$gt": 0}},
+			}},
+		}})
+	}
+
+	return pipeline, nil
+}
+
+// Match builds the plain field filters of the search.
+func (r EventSearchRepository) Match(c *gin.Context) bson.M {
+	eventPeriodBegin := c.Query("event_period_begin")
+	eventPeriodEnd := c.Query("event_period_end")
+	eventRewilding := c.Query("event_rewilding")
+	eventIsPast := c.Query("event_past")
+
+	var eventBegin time.Time
+	var eventEnd time.Time
+
+	match := bson.M{
+		"events_deleted":   bson.M{"$exists": false},
+		"events_cancelled": bson.M{"$exists": false},
+	}
+	for k, v := range helpers.EventVisibilityFilter(helpers.GetAuthUserByCheckHeaders(c).UsersId) {
+		match[k] = v
+	}
+
+	currentTime := primitive.NewDateTimeFromTime(time.Now())
+
+	if eventPeriodBegin != "" && eventPeriodEnd == "" {
+		eventBegin = helpers.StringToDatetime(eventPeriodBegin + " 00:00:00")
+		eventEnd = helpers.StringToDatetime(eventPeriodBegin + " 23:59:59")
+		match["events_date"] = bson.M{
+			"$gte": primitive.NewDateTimeFromTime(eventBegin),
+			"$lte": primitive.NewDateTimeFromTime(eventEnd),
+		}
+
+	} else if eventPeriodBegin == "" && eventPeriodEnd != "" {
+		eventBegin = helpers.StringToDatetime(eventPeriodEnd + " 00:00:00")
+		eventEnd = helpers.StringToDatetime(eventPeriodEnd + " 23:59:59")
+		match["events_date"] = bson.M{
+			"$gte": primitive.NewDateTimeFromTime(eventBegin),
+			"$lte": primitive.NewDateTimeFromTime(eventEnd),
+		}
+
+	} else if eventPeriodBegin != "" && eventPeriodEnd != "" {
+		eventBegin = helpers.StringToDatetime(eventPeriodBegin + " 00:00:00")
+		eventEnd = helpers.StringToDatetime(eventPeriodEnd + " 23:59:59")
+		match["events_date"] = bson.M{
+			"$gte": primitive.NewDateTimeFromTime(eventBegin),
+			"$lte": primitive.NewDateTimeFromTime(eventEnd),
+		}
+	} else if eventIsPast == "1" {
+		match["events_date"] = bson.M{"$lt": currentTime}
+	} else {
+		match["events_date"] = bson.M{"$gte": currentTime}
+	}
+
+	if eventRewilding != "" {
+		rewildingId := helpers.StringToPrimitiveObjId(eventRewilding)
+		match["events_rewilding"] = rewildingId
+	}
+
+	if eventsType := c.Query("events_type"); eventsType != "" {
+		match["events_type"] = helpers.StringToPrimitiveObjId(eventsType)
+	}
+
+	if countryCode := c.Query("country_code"); countryCode != "" {
+		match["events_country_code"] = countryCode
+	}
+
+	if paid := c.Query("paid"); paid == "true" {
+		match["events_payment_required"] = 1
+	} else if paid == "false" {
+		match["events_payment_required"] = bson.M{"$ne": 1}
+	}
+
+	if approval := c.Query("requires_approval"); approval == "true" {
+		match["events_requires_approval"] = 1
+	} else if approval == "false" {
+		match["events_requires_approval"] = bson.M{"$ne": 1}
+	}
+
+	return match
+}
+
+func (r EventSearchRepository) Point(c *gin.Context) (float64, float64, bool, error) {
+	reqLat := c.Query("lat")
+	reqLng := c.Query("lng")
+	if reqLat == "" && reqLng == "" {
+		return 0, 0, false, nil
+	}
+
+	lat, err := strconv.ParseFloat(reqLat, 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, false, errors.New("Invalid latitude value")
+	}
+
+	lng, err := strconv.ParseFloat(reqLng, 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return 0, 0, false, errors.New("Invalid longitude value")
+	}
+	return lat, lng, true, nil
+}
+
+// BoundingBox turns the bbox query into a GeoJSON polygon, which $geoWithin
+// can match against the GeoJSON points of the 2dsphere index. A polygon has to
+// fit in a hemisphere, so boxes 180 degrees wide or more are refused.
+func (r EventSearchRepository) BoundingBox(bbox string) (bson.M, error) {
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 {
+		return nil, errors.New("Invalid bbox. Use minLng,minLat,maxLng,maxLat")
+	}
+
+	var values []float64
+	for _, v := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, errors.New("Invalid bbox. Use minLng,minLat,maxLng,maxLat")
+		}
+		values = append(values, value)
+	}
+
+	minLng, minLat, maxLng, maxLat := values[0], values[1], values[2], values[3]
+	if minLng < -180 || maxLng > 180 || minLat < -90 || maxLat > 90 || minLng >= maxLng || minLat >= maxLat {
+		return nil, errors.New("Invalid bbox. Use minLng,minLat,maxLng,maxLat")
+	}
+	if maxLng-minLng >= 180 {
+		return nil, errors.New("Invalid bbox. It must be less than 180 degrees wide")
+	}
+
+	ring := bson.A{
+		bson.A{minLng, minLat},
+		bson.A{maxLng, minLat},
+		bson.A{maxLng, maxLat},
+		bson.A{minLng, maxLat},
+		bson.A{minLng, minLat},
+	}
+	return bson.M{"type": "Polygon", "coordinates": bson.A{ring}}, nil
+}
+
+// Paginate returns the sort and cursor stages for the requested order, and
+// the page size (0 when the results are not paginated).
+func (r EventSearchRepository) Paginate(c *gin.Context) (mongo.Pipeline, int, error) {
+	sortField, sortOrder, err := r.Sort(c)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pipeline := mongo.Pipeline{}
+	if reqCursor := c.Query("cursor"); reqCursor != "" {
+		after, err := r.DecodeCursor(reqCursor)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var value interface{} = after.Value
+		if sortField == "events_date" {
+			value = primitive.DateTime(int64(after.Value))
+		}
+		comparison := "$gt"
+		if sortOrder < 0 {
+			comparison = "$lt"
+		}
+		pipeline = append(pipeline, bson.D{{
+			Key: "$match", Value: bson.M{"$or": bson.A{
+				bson.M{sortField: bson.M{comparison: value}},
+				bson.M{sortField: value, "_id": bson.M{"$gt": helpers.StringToPrimitiveObjId(after.Id)}},
+			}},
+		}})
+	}
+
+	pipeline = append(pipeline, bson.D{{
+		Key: "$sort", Value: bson.D{{Key: sortField, Value: sortOrder}, {Key: "_id", Value: 1}},
+	}})
+
+	limit := 0
+	if reqLimit := c.Query("limit"); reqLimit != "" {
+		limit, err = strconv.Atoi(reqLimit)
+		if err != nil || limit < 1 || limit > eventSearchMaxLimit {
+			return nil, 0, errors.New("Limit must be between 1 and " + strconv.Itoa(eventSearchMaxLimit))
+		}
+		// Fetch one extra event to know whether there is a next page
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit + 1}})
+	}
+	return pipeline, limit, nil
+}
+
+func (r EventSearchRepository) Sort(c *gin.Context) (string, int, error) {
+	switch c.DefaultQuery("sort", "date") {
+	case "date":
+		return "events_date", 1, nil
+	case "distance":
+		if c.Query("lat") == "" || c.Query("lng") == "" {
+			return "", 0, errors.New("Sorting by distance requires lat and lng")
+		}
+		return "events_distance", 1, nil
+	case "popularity":
+		return "events_accepted_count", -1, nil
+	}
+	return "", 0, errors.New("Unsupported sort. Use date, distance or popularity")
+}
+
+// NextCursor encodes the position after the last event of the page.
+func (r EventSearchRepository) NextCursor(c *gin.Context, Events models.Events) string {
+	after := eventSearchCursor{Id: Events.EventsId.Hex()}
+	sortField, _, _ := r.Sort(c)
+	switch sortField {
+	case "events_date":
+		after.Value = float64(Events.EventsDate)
+	case "events_distance":
+		if Events.EventsDistance != nil {
+			after.Value = *Events.EventsDistance
+		}
+	case "events_accepted_count":
+		if Events.EventsAcceptedCount != nil {
+			after.Value = float64(*Events.EventsAcceptedCount)
+		}
+	}
+	encoded, _ := json.Marshal(after)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func (r EventSearchRepository) DecodeCursor(value string) (eventSearchCursor, error) {
+	var after eventSearchCursor
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(decoded, &after)
+	}
+	if err != nil || after.Id == "" {
+		return after, errors.New("Invalid cursor")
+	}
+	return after, nil
+}
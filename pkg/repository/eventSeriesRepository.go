@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultEventTimezone = "Asia/Taipei"
+
+type EventSeriesRepository struct{}
+type EventSeriesRequest struct {
+	EventSeriesRule      string `json:"event_series_rule"`
+	EventSeriesFrequency string `json:"event_series_frequency" validate:"omitempty,oneof=DAILY WEEKLY MONTHLY"`
+	EventSeriesInterval  int    `json:"event_series_interval" validate:"omitempty,min=1"`
+	EventSeriesCount     int    `json:"event_series_count" validate:"omitempty,min=2"`
+	EventSeriesUntil     string `json:"event_series_until" validate:"omitempty,datetime=2006-01-02"`
+	EventSeriesTimezone  string `json:"event_series_timezone"`
+}
+
+func (r EventSeriesRepository) Read(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	if helpers.MongoZeroID(Event.EventsSeries) {
+		helpers.ResponseNoData(c, "This event is not part of a series")
+		return
+	}
+
+	var EventSeries models.EventSeries
+	err = config.DB.Collection("EventSeries").FindOne(context.TODO(), bson.D{{Key: "_id", Value: Event.EventsSeries}}).Decode(&EventSeries)
+	if err != nil {
+		helpers.ResultEmpty(c, err)
+		return
+	}
+
+	EventSeries.EventSeriesEvents = r.Occurrences(EventSeries.EventSeriesId, time.Time{})
+	c.JSON(http.StatusOK, EventSeries)
+}
+
+// Create turns the event into the template of a new series and generates the
+// remaining occurrences from the recurrence rule. Every occurrence is a full
+// event with its own participants, schedules and polaroids.
+func (r EventSeriesRepository) Create(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	if !(EventRepository{}).IsOrganizer(c, Event) {
+		helpers.ResponseBadRequestError(c, "Only the event organizer can create a series")
+		return
+	}
+
+	if !helpers.MongoZeroID(Event.EventsSeries) {
+		helpers.ResponseBadRequestError(c, "This event already belongs to a series")
+		return
+	}
+
+	var payload EventSeriesRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	rule := payload.EventSeriesRule
+	if rule == "" && payload.EventSeriesFrequency != "" {
+		rule = r.BuildRule(payload)
+	}
+	recurrence, err := helpers.RecurrenceParse(rule)
+	if err != nil {
+		helpers.ResponseBadRequestError(c, err.Error())
+		return
+	}
+
+	if payload.EventSeriesTimezone == "" {
+		payload.EventSeriesTimezone = defaultEventTimezone
+	}
+	location, err := time.LoadLocation(payload.EventSeriesTimezone)
+	if err != nil {
+		helpers.ResponseBadRequestError(c, "Invalid timezone: "+payload.EventSeriesTimezone)
+		return
+	}
+
+	limit := int(config.APP_LIMIT.EventSeriesOccurrenceLimit)
+	if limit <= 0 {
+		limit = 52
+	}
+	occurrences := recurrence.Occurrences(Event.EventsDate.Time().In(location), limit)
+	if len(occurrences) < 2 {
+		helpers.ResponseBadRequestError(c, "Recurrence rule does not produce any occurrence after this event. Maximum allowed: "+strconv.Itoa(limit))
+		return
+	}
+
+	insertSeries := models.EventSeries{
+		EventSeriesTemplate:  Event.EventsId,
+		EventSeriesRule:      recurrence.String(),
+		EventSeriesTimezone:  payload.EventSeriesTimezone,
+		EventSeriesCreatedBy: userDetail.UsersId,
+		EventSeriesCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+	result, err := config.DB.Collection("EventSeries").InsertOne(context.TODO(), insertSeries)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	seriesId := result.InsertedID.(primitive.ObjectID)
+
+	filters := bson.D{{Key: "_id", Value: Event.EventsId}}
+	upd := bson.D{{Key: "$set", Value: bson.M{"events_series": seriesId}}}
+	config.DB.Collection("Events").UpdateOne(context.TODO(), filters, upd)
+
+	var EventSchedules []models.EventSchedules
+	cursor, _ := config.DB.Collection("EventSchedules").Find(context.TODO(), bson.D{{Key: "event_schedules_event", Value: Event.EventsId}})
+	cursor.All(context.TODO(), &EventSchedules)
+
+	for k, v := range occurrences[1:] {
+		insert := models.Events{
+			EventsSeries:      seriesId,
+			EventsSeriesIndex: k + 1,
+			EventsCreatedBy:   Event.EventsCreatedBy,
+			EventsCreatedAt:   primitive.NewDateTimeFromTime(time.Now()),
+		}
+		r.ApplyTemplate(&insert, Event)
+		r.ApplyDates(&insert, Event, v)
+
+		result, err := config.DB.Collection("Events").InsertOne(context.TODO(), insert)
+		if err != nil {
+			helpers.ResponseError(c, err.Error())
+			return
+		}
+		occurrenceId := result.InsertedID.(primitive.ObjectID)
+
+		config.DB.Collection("EventParticipants").InsertOne(context.TODO(), models.EventParticipants{
+			EventParticipantsEvent:     occurrenceId,
+			EventParticipantsUser:      Event.EventsCreatedBy,
+			EventParticipantsStatus:    GetEventParticipantStatus("ACCEPTED"),
+			EventParticipantsRole:      helpers.EVENT_ROLE_OWNER,
+			EventParticipantsCreatedBy: userDetail.UsersId,
+			EventParticipantsCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+		})
+
+		shift := v.Sub(Event.EventsDate.Time())
+		var insertSchedules []interface{}
+		for _, vSchedule := range EventSchedules {
+			insertSchedules = append(insertSchedules, models.EventSchedules{
+				EventSchedulesEvent:       occurrenceId,
+				EventSchedulesDatetime:    primitive.NewDateTimeFromTime(vSchedule.EventSchedulesDatetime.Time().Add(shift)),
+				EventSchedulesDescription: vSchedule.EventSchedulesDescription,
+				EventSchedulesCreatedBy:   userDetail.UsersId,
+				EventSchedulesCreatedAt:   primitive.NewDateTimeFromTime(time.Now()),
+			})
+		}
+		if len(insertSchedules) > 0 {
+			config.DB.Collection("EventSchedules").InsertMany(context.TODO(), insertSchedules)
+		}
+	}
+
+	r.Read(c)
+}
+
+func (r EventSeriesRepository) BuildRule(payload EventSeriesRequest) string {
+	rule := "FREQ=" + payload.EventSeriesFrequency
+	if payload.EventSeriesInterval > 0 {
+		rule += ";INTERVAL=" + strconv.Itoa(payload.EventSeriesInterval)
+	}
+	if payload.EventSeriesCount > 0 {
+		rule += ";COUNT=" + strconv.Itoa(payload.EventSeriesCount)
+	}
+	if payload.EventSeriesUntil != "" {
+		rule += ";UNTIL=" + helpers.StringDateToDateTime(payload.EventSeriesUntil).Format("20060102")
+	}
+	return rule
+}
+
+// Occurrences returns the non-deleted events of the series starting after
+// the given time, ordered by date.
+func (r EventSeriesRepository) Occurrences(seriesId primitive.ObjectID, after time.Time) []models.Events {
+	var Events []models.Events
+	filter := bson.D{
+		{Key: "events_series", Value: seriesId},
+		{Key: "events_deleted", Value: bson.M{"$exists": false}},
+	}
+	if !after.IsZero() {
+		filter = append(filter, bson.E{Key: "events_date", Value: bson.M{"$gt": primitive.NewDateTimeFromTime(after)}})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "events_date", Value: 1}})
+	cursor, _ := config.DB.Collection("Events").Find(context.TODO(), filter, opts)
+	cursor.All(context.TODO(), &Events)
+	return Events
+}
+
+// UpdateFuture applies an edit made to one occurrence to every later
+// occurrence of its series. Date changes are applied as an offset so each
+// occurrence keeps its own day. Occurrences edited on their own are detached
+// from the series and left as they are.
+func (r EventSeriesRepository) UpdateFuture(c *gin.Context, Events models.Events, previousDate time.Time) {
+	userDetail := helpers.GetAuthUser(c)
+	shift := Events.EventsDate.Time().Sub(previousDate)
+
+	for _, v := range r.Occurrences(Events.EventsSeries, previousDate) {
+		if v.EventsId == Events.EventsId || (v.EventsSeriesDetached != nil && *v.EventsSeriesDetached) {
+			continue
+		}
+		Previous := v
+		r.ApplyTemplate(&v, Events)
+		r.ApplyDates(&v, Events, v.EventsDate.Time().Add(shift))
+		if Previous.EventsCurrency != v.EventsCurrency && (EventAccountingRepository{}).Rebase(Previous, v) != nil {
+			// Its records cannot be converted, so the occurrence keeps its currency
+			v.EventsCurrency = Previous.EventsCurrency
+		}
+		v.EventsUpdatedBy = userDetail.UsersId
+		v.EventsUpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+		filters := bson.D{{Key: "_id", Value: v.EventsId}}
+		upd := bson.D{{Key: "$set", Value: v}}
+		config.DB.Collection("Events").UpdateOne(context.TODO(), filters, upd)
+		History := EventHistoryRepository{}.Record(c, Previous, v)
+		EventRepository{}.NotifyUpdated(c, v, History)
+	}
+}
+
+// DeleteFuture deletes every occurrence of the series after the given event.
+func (r EventSeriesRepository) DeleteFuture(Events models.Events) {
+	filters := bson.D{
+		{Key: "events_series", Value: Events.EventsSeries},
+		{Key: "events_date", Value: bson.M{"$gt": Events.EventsDate}},
+		{Key: "events_deleted", Value: bson.M{"$exists": false}},
+	}
+	upd := bson.D{{Key: "$set", Value: bson.M{
+		"events_deleted":    1,
+		"events_deleted_at": primitive.NewDateTimeFromTime(time.Now()),
+	}}}
+	config.DB.Collection("Events").UpdateMany(context.TODO(), filters, upd)
+}
+
+// ApplyTemplate copies the shared details of an event onto an occurrence,
+// leaving identity, dates and ownership untouched.
+func (r EventSeriesRepository) ApplyTemplate(Occurrence *models.Events, Template models.Events) {
+	Occurrence.EventsName = Template.EventsName
+	Occurrence.EventsRewilding = Template.EventsRewilding
+	Occurrence.EventsRewildingAchievementType = Template.EventsRewildingAchievementType
+	Occurrence.EventsRewildingAchievementTypeID = Template.EventsRewildingAchievementTypeID
+	Occurrence.EventsPlace = Template.EventsPlace
+	Occurrence.EventsCityId = Template.EventsCityId
+	Occurrence.EventsType = Template.EventsType
+	Occurrence.EventsInvitationTemplate = Template.EventsInvitationTemplate
+	Occurrence.EventsInvitationMessage = Template.EventsInvitationMessage
+	Occurrence.EventsParticipantLimit = Template.EventsParticipantLimit
+	Occurrence.EventsPaymentRequired = Template.EventsPaymentRequired
+	Occurrence.EventsPaymentFee = Template.EventsPaymentFee
+	Occurrence.EventsRequiresApproval = Template.EventsRequiresApproval
+	Occurrence.EventsQuestionnaireLink = Template.EventsQuestionnaireLink
+	Occurrence.EventsVisibility = Template.EventsVisibility
+	Occurrence.EventsLat = Template.EventsLat
+	Occurrence.EventsLng = Template.EventsLng
+	Occurrence.EventsLocation = Template.EventsLocation
+	Occurrence.EventsCountryCode = Template.EventsCountryCode
+	Occurrence.EventsCurrency = Template.EventsCurrency
+	Occurrence.EventsMeetingPointLat = Template.EventsMeetingPointLat
+	Occurrence.EventsMeetingPointLng = Template.EventsMeetingPointLng
+	Occurrence.EventsMeetingPointName = Template.EventsMeetingPointName
+	Occurrence.EventsStatisticTime = Template.EventsStatisticTime
+	Occurrence.EventsStatisticDistance = Template.EventsStatisticDistance
+	Occurrence.EventsPhoto = Template.EventsPhoto
+}
+
+// ApplyDates moves the occurrence to start, keeping the template's duration
+// and the distance between its deadline and start date.
+func (r EventSeriesRepository) ApplyDates(Occurrence *models.Events, Template models.Events, start time.Time) {
+	duration := Template.EventsDateEnd.Time().Sub(Template.EventsDate.Time())
+	Occurrence.EventsDate = primitive.NewDateTimeFromTime(start)
+	Occurrence.EventsDateEnd = primitive.NewDateTimeFromTime(start.Add(duration))
+	if !Template.EventsDeadline.Time().IsZero() {
+		deadline := Template.EventsDate.Time().Sub(Template.EventsDeadline.Time())
+		Occurrence.EventsDeadline = primitive.NewDateTimeFromTime(start.Add(-deadline))
+	}
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EventSettlementRepository answers "who owes whom" for the event accounting.
+// Each record is split between its beneficiaries, or equally between the
+// accepted participants when it has none, and transfers marked as settled
+// are counted towards the balances, as are the deposits paid before the event.
+type EventSettlementRepository struct{}
+type EventSettlementRequest struct {
+	EventSettlementsFrom   string  `json:"event_settlements_from" validate:"required"`
+	EventSettlementsTo     string  `json:"event_settlements_to" validate:"required"`
+	EventSettlementsAmount float64 `json:"event_settlements_amount" validate:"omitempty,gt=0"`
+}
+
+func (r EventSettlementRepository) Read(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	Settlement, _ := r.Calculate(Event.EventsId)
+	if len(Settlement.Balances) == 0 {
+		helpers.ResponseNoData(c, "No Data")
+		return
+	}
+	c.JSON(http.StatusOK, Settlement)
+}
+
+// Create marks a transfer as settled. Either side of the transfer or an
+// organizer can mark it, for the whole outstanding amount or part of it.
+func (r EventSettlementRepository) Create(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	var payload EventSettlementRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	from := helpers.StringToPrimitiveObjId(payload.EventSettlementsFrom)
+	to := helpers.StringToPrimitiveObjId(payload.EventSettlementsTo)
+	if userDetail.UsersId != from && userDetail.UsersId != to && !(EventRepository{}).IsOrganizer(c, Event) {
+		helpers.ResponseBadRequestError(c, "Only the participants of a transfer or the event organizer can settle it")
+		return
+	}
+
+	_, transfers := r.Calculate(Event.EventsId)
+	var outstanding int64
+	for _, v := range transfers {
+		if v.From == from && v.To == to {
+			outstanding = v.Amount
+		}
+	}
+	if outstanding == 0 {
+		helpers.ResponseBadRequestError(c, "There is no outstanding transfer between these participants")
+		return
+	}
+
+	amount := outstanding
+	if payload.EventSettlementsAmount > 0 {
+		amount = helpers.SettlementCents(payload.EventSettlementsAmount)
+	}
+	if amount > outstanding {
+		helpers.ResponseBadRequestError(c, "Amount is more than the outstanding transfer")
+		return
+	}
+
+	insert := models.EventSettlements{
+		EventSettlementsEvent:     Event.EventsId,
+		EventSettlementsFrom:      from,
+		EventSettlementsTo:        to,
+		EventSettlementsAmount:    helpers.SettlementAmount(amount),
+		EventSettlementsCreatedBy: userDetail.UsersId,
+		EventSettlementsCreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+	_, err = config.DB.Collection("EventSettlements").InsertOne(context.TODO(), insert)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	r.Read(c)
+}
+
+// Delete reverts a settled transfer.
+func (r EventSettlementRepository) Delete(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	var EventSettlements models.EventSettlements
+	filter := bson.D{
+		{Key: "_id", Value: helpers.StringToPrimitiveObjId(c.Param("settlementId"))},
+		{Key: "event_settlements_event", Value: Event.EventsId},
+	}
+	err = config.DB.Collection("EventSettlements").FindOne(context.TODO(), filter).Decode(&EventSettlements)
+	if err != nil {
+		helpers.ResponseNotFound(c, "Settlement not found")
+		return
+	}
+
+	if userDetail.UsersId != EventSettlements.EventSettlementsFrom && userDetail.UsersId != EventSettlements.EventSettlementsTo && !(EventRepository{}).IsOrganizer(c, Event) {
+		helpers.ResponseBadRequestError(c, "Only the participants of a transfer or the event organizer can revert it")
+		return
+	}
+
+	config.DB.Collection("EventSettlements").DeleteOne(context.TODO(), filter)
+	r.Read(c)
+}
+
+// Calculate returns the settlement of the event along with the outstanding
+// transfers in cents.
+func (r EventSettlementRepository) Calculate(eventId primitive.ObjectID) (models.EventSettlement, []helpers.SettlementTransfer) {
+	Settlement := models.EventSettlement{
+		Balances:  []models.EventSettlementBalance{},
+		Transfers: []models.EventSettlementTransfer{},
+		Settled:   []models.EventSettlements{},
+	}
+
+	var Event models.Events
+	config.DB.Collection("Events").FindOne(context.TODO(), bson.D{{Key: "_id", Value: eventId}}).Decode(&Event)
+	Settlement.Currency = EventAccountingRepository{}.Currency(Event)
+
+	participants := EventAccountingRepository{}.Participants(eventId)
+
+	var EventAccounting []models.EventAccounting
+	cursor, _ := config.DB.Collection("EventAccounting").Find(context.TODO(), bson.D{{Key: "event_accounting_event", Value: eventId}})
+	cursor.All(context.TODO(), &EventAccounting)
+
+	opts := options.Find().SetSort(bson.D{{Key: "event_settlements_created_at", Value: 1}})
+	cursor, _ = config.DB.Collection("EventSettlements").Find(context.TODO(), bson.D{{Key: "event_settlements_event", Value: eventId}}, opts)
+	cursor.All(context.TODO(), &Settlement.Settled)
+
+	users := map[primitive.ObjectID]bool{}
+	for _, v := range participants {
+		users[v] = true
+	}
+
+	paid := map[primitive.ObjectID]int64{}
+	share := map[primitive.ObjectID]int64{}
+	settled := map[primitive.ObjectID]int64{}
+	deposit := map[primitive.ObjectID]int64{}
+	var total int64
+	for _, v := range EventAccounting {
+		amount := helpers.SettlementCents(EventAccountingRepository{}.BaseAmount(v))
+		total += amount
+		paid[v.EventAccountingPaidBy] += amount
+		users[v.EventAccountingPaidBy] = true
+		for user, userShare := range (EventAccountingRepository{}).Shares(v, participants) {
+			share[user] += userShare
+			users[user] = true
+		}
+	}
+	for _, v := range Settlement.Settled {
+		amount := helpers.SettlementCents(v.EventSettlementsAmount)
+		settled[v.EventSettlementsFrom] += amount
+		settled[v.EventSettlementsTo] -= amount
+		users[v.EventSettlementsFrom] = true
+		users[v.EventSettlementsTo] = true
+	}
+
+	Settlement.Deposits = EventBudgetRepository{}.Deposits(eventId)
+	for _, v := range Settlement.Deposits {
+		amount := helpers.SettlementCents(v.EventDepositsAmount)
+		deposit[v.EventDepositsUser] += amount
+		deposit[v.EventDepositsCollector] -= amount
+		users[v.EventDepositsUser] = true
+		users[v.EventDepositsCollector] = true
+	}
+
+	var userIds []primitive.ObjectID
+	balances := map[primitive.ObjectID]int64{}
+	for user := range users {
+		userIds = append(userIds, user)
+		balances[user] = paid[user] - share[user] + settled[user] + deposit[user]
+	}
+	sort.Slice(userIds, func(i, j int) bool { return userIds[i].Hex() < userIds[j].Hex() })
+	UsersDetail := r.Users(userIds)
+
+	Settlement.Total = helpers.SettlementAmount(total)
+	for _, user := range userIds {
+		Settlement.Balances = append(Settlement.Balances, models.EventSettlementBalance{
+			User:       user,
+			UserDetail: UsersDetail[user],
+			Paid:       helpers.SettlementAmount(paid[user]),
+			Share:      helpers.SettlementAmount(share[user]),
+			Settled:    helpers.SettlementAmount(settled[user]),
+			Deposit:    helpers.SettlementAmount(deposit[user]),
+			Balance:    helpers.SettlementAmount(balances[user]),
+		})
+	}
+
+	transfers := helpers.SettlementTransfers(balances)
+	for _, v := range transfers {
+		Settlement.Transfers = append(Settlement.Transfers, models.EventSettlementTransfer{
+			From:     v.From,
+			To:       v.To,
+			Amount:   helpers.SettlementAmount(v.Amount),
+			FromUser: UsersDetail[v.From],
+			ToUser:   UsersDetail[v.To],
+		})
+	}
+	for k, v := range Settlement.Settled {
+		Settlement.Settled[k].EventSettlementsFromUser = UsersDetail[v.EventSettlementsFrom]
+		Settlement.Settled[k].EventSettlementsToUser = UsersDetail[v.EventSettlementsTo]
+	}
+	for k, v := range Settlement.Deposits {
+		Settlement.Deposits[k].EventDepositsUserAgg = UsersDetail[v.EventDepositsUser]
+	}
+	return Settlement, transfers
+}
+
+func (r EventSettlementRepository) Users(userIds []primitive.ObjectID) map[primitive.ObjectID]*models.UsersAgg {
+	UsersDetail := map[primitive.ObjectID]*models.UsersAgg{}
+	if len(userIds) == 0 {
+		return UsersDetail
+	}
+
+	var Users []models.UsersAgg
+	cursor, _ := config.DB.Collection("Users").Find(context.TODO(), bson.M{"_id": bson.M{"$in": userIds}})
+	cursor.All(context.TODO(), &Users)
+	for k, v := range Users {
+		UsersDetail[v.UsersId] = &Users[k]
+	}
+	return UsersDetail
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"io"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventStreamRepository pushes the activity of an event to the client over
+// Server-Sent Events, so that it does not need to poll the message board,
+// announcements, participants and polaroids.
+type EventStreamRepository struct{}
+
+const eventStreamHeartbeat = 25 * time.Second
+
+func (r EventStreamRepository) Stream(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	broker, err := helpers.EventStream()
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	messages, unsubscribe := broker.Subscribe(Event.EventsId)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Keeps nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("ready", gin.H{"events_id": Event.EventsId})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case message, ok := <-messages:
+			if !ok {
+				return false
+			}
+			c.SSEvent(message.Type, message)
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+		}
+		return true
+	})
+}
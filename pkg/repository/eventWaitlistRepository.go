@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"strings"
+	"time"
+
+	"github.com/arwoosa/notifaction/helper"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EventWaitlistRepository struct{}
+type EventWaitlistRequest struct {
+	EventParticipantsId []string `json:"event_participants_id" validate:"required,min=1"`
+}
+
+func (r EventWaitlistRepository) Retrieve(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	agg := mongo.Pipeline{
+		bson.D{{
+			Key: "$match", Value: bson.M{
+				"event_participants_event":  Event.EventsId,
+				"event_participants_status": GetEventParticipantStatus("WAITLISTED"),
+			},
+		}},
+		bson.D{{
+			Key: "$sort", Value: bson.D{
+				{Key: "event_participants_waitlist_position", Value: 1},
+				{Key: "event_participants_created_at", Value: 1},
+			},
+		}},
+		bson.D{{
+			Key: "$lookup", Value: bson.M{
+				"from":         "Users",
+				"localField":   "event_participants_user",
+				"foreignField": "_id",
+				"as":           "event_participants_user_detail",
+			},
+		}},
+		bson.D{{
+			Key: "$unwind", Value: "$event_participants_user_detail",
+		}},
+	}
+
+	var results []models.EventParticipants
+	cursor, err := config.DB.Collection("EventParticipants").Aggregate(context.TODO(), agg)
+	if err != nil {
+		return
+	}
+	cursor.All(context.TODO(), &results)
+
+	if len(results) == 0 {
+		helpers.ResponseNoData(c, "No Data")
+		return
+	}
+
+	for k, v := range results {
+		results[k].EventParticipantsStatusLabel = GetEventParticipantStatusLabel(v.EventParticipantsStatus)
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// Update reorders the waitlist. The payload must list every waitlisted
+// participant of the event exactly once, in the new queue order.
+func (r EventWaitlistRepository) Update(c *gin.Context) {
+	var Event models.Events
+	err := EventRepository{}.ReadOne(c, &Event)
+	if err != nil {
+		return
+	}
+
+	var payload EventWaitlistRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	waitlist := r.Waitlist(Event.EventsId)
+	if len(waitlist) != len(payload.EventParticipantsId) {
+		helpers.ResponseBadRequestError(c, "Waitlist order must contain all "+fmt.Sprint(len(waitlist))+" waitlisted participants")
+		return
+	}
+
+	waitlisted := map[primitive.ObjectID]bool{}
+	for _, v := range waitlist {
+		waitlisted[v.EventParticipantsId] = true
+	}
+
+	var errList []string
+	seen := map[primitive.ObjectID]bool{}
+	for _, v := range payload.EventParticipantsId {
+		participantId := helpers.StringToPrimitiveObjId(v)
+		if !waitlisted[participantId] || seen[participantId] {
+			errList = append(errList, v)
+		}
+		seen[participantId] = true
+	}
+
+	if len(errList) > 0 {
+		helpers.ResponseBadRequestError(c, strings.Join(errList, ", ")+" are not valid waitlist entries")
+		return
+	}
+
+	for k, v := range payload.EventParticipantsId {
+		filters := bson.D{{Key: "_id", Value: helpers.StringToPrimitiveObjId(v)}}
+		upd := bson.D{{Key: "$set", Value: bson.M{"event_participants_waitlist_position": k + 1}}}
+		config.DB.Collection("EventParticipants").UpdateOne(context.TODO(), filters, upd)
+	}
+
+	r.Retrieve(c)
+}
+
+func (r EventWaitlistRepository) Waitlist(eventId primitive.ObjectID) []models.EventParticipants {
+	var Waitlist []models.EventParticipants
+	filter := bson.D{
+		{Key: "event_participants_event", Value: eventId},
+		{Key: "event_participants_status", Value: GetEventParticipantStatus("WAITLISTED")},
+	}
+	opts := options.Find().SetSort(bson.D{
+		{Key: "event_participants_waitlist_position", Value: 1},
+		{Key: "event_participants_created_at", Value: 1},
+	})
+	cursor, _ := config.DB.Collection("EventParticipants").Find(context.TODO(), filter, opts)
+	cursor.All(context.TODO(), &Waitlist)
+	return Waitlist
+}
+
+// SeatsAvailable returns the number of open seats for the event, or -1 when
+// the event has no participant limit.
+func (r EventWaitlistRepository) SeatsAvailable(Event models.Events) int {
+	if Event.EventsParticipantLimit == nil || *Event.EventsParticipantLimit == 0 {
+		return -1
+	}
+	// Participants waiting to pay keep their seat until their deadline
+	countFilter := bson.D{
+		{Key: "event_participants_event", Value: Event.EventsId},
+		{Key: "$or", Value: bson.A{
+			bson.M{"event_participants_status": GetEventParticipantStatus("ACCEPTED")},
+			bson.M{
+				"event_participants_status": GetEventParticipantStatus("PAYMENT_PENDING"),
+				"$or": bson.A{
+					bson.M{"event_participants_payment_deadline": bson.M{"$exists": false}},
+					bson.M{"event_participants_payment_deadline": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())}},
+				},
+			},
+		}},
+	}
+	acceptedCount, _ := config.DB.Collection("EventParticipants").CountDocuments(context.TODO(), countFilter)
+	seats := *Event.EventsParticipantLimit - int(acceptedCount)
+	if seats < 0 {
+		return 0
+	}
+	return seats
+}
+
+// NextPosition returns the queue position for a participant joining the end
+// of the waitlist.
+func (r EventWaitlistRepository) NextPosition(eventId primitive.ObjectID) int {
+	var Last models.EventParticipants
+	filter := bson.D{
+		{Key: "event_participants_event", Value: eventId},
+		{Key: "event_participants_status", Value: GetEventParticipantStatus("WAITLISTED")},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "event_participants_waitlist_position", Value: -1}})
+	config.DB.Collection("EventParticipants").FindOne(context.TODO(), filter, opts).Decode(&Last)
+	return Last.EventParticipantsWaitlistPosition + 1
+}
+
+// NotifyWaitlisted tells an applicant the organizer approved that the event
+// is full and they are on the waitlist.
+func (r EventWaitlistRepository) NotifyWaitlisted(c *gin.Context, Event models.Events, EventParticipants models.EventParticipants) {
+	NotificationMessage := models.NotificationMessage{
+		Message: "{0}已額滿, 你已加入候補名單",
+		Data:    []map[string]interface{}{helpers.NotificationFormatEvent(Event)},
+	}
+	helpers.NotificationsCreate(c, helpers.NOTIFICATION_EVENT_WAITLISTED, EventParticipants.EventParticipantsUser, NotificationMessage, Event.EventsId)
+
+	notifyData := map[string]string{
+		"events_name": Event.EventsName,
+		"event_id":    Event.EventsId.Hex(),
+	}
+	notifyMsg, err := helper.NewNotifyMsg(
+		helpers.NOTIFICATION_EVENT_WAITLISTED,
+		Event.EventsCreatedBy, EventParticipants.EventParticipantsUser,
+		notifyData, helpers.FindUserSourceId)
+	if err != nil {
+		fmt.Println("new notify msg err: " + err.Error())
+		return
+	}
+	notifyMsg.WriteToHeader(c, config.APP.NotificationHeaderName)
+}
+
+// Promote moves participants from the head of the waitlist into the event
+// for as long as seats are available and notifies each promoted user.
+func (r EventWaitlistRepository) Promote(c *gin.Context, eventId primitive.ObjectID) {
+	notifyMsg := r.PromoteAs(helpers.GetAuthUser(c).UsersId, eventId)
+	if notifyMsg != nil {
+		notifyMsg.WriteToHeader(c, config.APP.NotificationHeaderName)
+	}
+}
+
+// PromoteAs promotes the waitlist on behalf of createdBy, for background jobs
+// with no request. It returns the push notification of the promoted users,
+// which only a request can send.
+func (r EventWaitlistRepository) PromoteAs(createdBy primitive.ObjectID, eventId primitive.ObjectID) helper.NotifyMsg {
+	var Event models.Events
+	err := config.DB.Collection("Events").FindOne(context.TODO(), bson.D{{Key: "_id", Value: eventId}}).Decode(&Event)
+	if err != nil {
+		return nil
+	}
+
+	seats := r.SeatsAvailable(Event)
+	if seats == 0 {
+		return nil
+	}
+
+	notifyData := map[string]string{
+		"events_name": Event.EventsName,
+	}
+	// Promoted participants of a paid event hold the seat until they pay
+	status := GetEventParticipantStatus("ACCEPTED")
+	paymentRequired := EventPaymentRepository{}.Required(Event)
+	if paymentRequired {
+		status = GetEventParticipantStatus("PAYMENT_PENDING")
+	}
+
+	promoted := 0
+	var notifyMsg helper.NotifyMsg
+	for _, v := range r.Waitlist(eventId) {
+		if seats == 0 {
+			break
+		}
+
+		filters := bson.D{
+			{Key: "_id", Value: v.EventParticipantsId},
+			{Key: "event_participants_status", Value: GetEventParticipantStatus("WAITLISTED")},
+		}
+		upd := bson.D{
+			{Key: "$set", Value: bson.M{"event_participants_status": status}},
+			{Key: "$unset", Value: bson.M{"event_participants_waitlist_position": ""}},
+		}
+		result, err := config.DB.Collection("EventParticipants").UpdateOne(context.TODO(), filters, upd)
+		if err != nil || result.ModifiedCount == 0 {
+			continue
+		}
+		if seats > 0 {
+			seats--
+		}
+
+		if paymentRequired {
+			v, err = EventPaymentRepository{}.Start(Event, v)
+			if err != nil {
+				fmt.Println("payment intent err: " + err.Error())
+			}
+			EventPaymentRepository{}.NotifyAs(createdBy, Event, v)
+			continue
+		}
+		promoted++
+		EventParticipantsRepository{}.StreamJoined(eventId, v.EventParticipantsUser)
+
+		NotificationMessage := models.NotificationMessage{
+			Message: "{0}有空位了! 你已從候補名單加入活動",
+			Data:    []map[string]interface{}{helpers.NotificationFormatEvent(Event)},
+		}
+		helpers.NotificationsInsert(helpers.NOTIFICATION_EVENT_WAITLIST_PROMOTED, v.EventParticipantsUser, NotificationMessage, eventId, createdBy)
+
+		if notifyMsg == nil {
+			notifyMsg, err = helper.NewNotifyMsg(
+				helpers.NOTIFICATION_EVENT_WAITLIST_PROMOTED,
+				Event.EventsCreatedBy, v.EventParticipantsUser,
+				notifyData, helpers.FindUserSourceId)
+			if err != nil {
+				fmt.Println("new notify msg err: " + err.Error())
+			}
+		} else {
+			notifyMsg.AddTo(v.EventParticipantsUser)
+		}
+	}
+
+	if promoted > 0 {
+		EventRepository{}.HandleBadgesAs(eventId, createdBy)
+		EventRepository{}.SuggestFriends(eventId)
+	}
+	return notifyMsg
+}
@@ -0,0 +1,482 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/arwoosa/notifaction/helper"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ModerationRepository lets admin users work through the reports: hide,
+// restore or delete the reported content, dismiss the reports, and warn or
+// suspend users. Every action is logged in ModerationActions.
+type ModerationRepository struct{}
+type ModerationRequest struct {
+	ModerationActionsMessage string `json:"moderation_actions_message"`
+}
+type ModerationWarnRequest struct {
+	ModerationActionsMessage string `json:"moderation_actions_message" validate:"required"`
+}
+type ModerationSuspendRequest struct {
+	ModerationActionsDays    int    `json:"moderation_actions_days" validate:"required,min=1"`
+	ModerationActionsMessage string `json:"moderation_actions_message"`
+}
+
+// ModerationContent is reported content located in its collection. Filter
+// matches its document, and HiddenField is the field that hides it.
+type ModerationContent struct {
+	Type        string
+	Id          primitive.ObjectID
+	Collection  string
+	Filter      bson.D
+	HiddenField string
+	Hidden      bool
+	CreatedBy   primitive.ObjectID
+	Record      interface{}
+}
+
+// Content finds the content of the type. Rewilding photos are embedded in
+// their rewilding, whose author is taken as the author of the photo.
+func (r ModerationRepository) Content(contentType string, contentId primitive.ObjectID) (ModerationContent, error) {
+	Content := ModerationContent{Type: contentType, Id: contentId}
+	switch contentType {
+	case helpers.REPORT_TYPE_MESSAGE_BOARD:
+		var EventMessageBoard models.EventMessageBoard
+		Content.Collection = "EventMessageBoard"
+		Content.Filter = bson.D{
+			{Key: "_id", Value: contentId},
+			{Key: "event_message_board_base_message", Value: bson.M{"$exists": true}},
+		}
+		Content.HiddenField = "event_message_board_hidden"
+		err := config.DB.Collection(Content.Collection).FindOne(context.TODO(), Content.Filter).Decode(&EventMessageBoard)
+		Content.Hidden = EventMessageBoard.EventMessageBoardHidden
+		Content.CreatedBy = EventMessageBoard.EventMessageBoardCreatedBy
+		Content.Record = EventMessageBoard
+		return Content, err
+	case helpers.REPORT_TYPE_POLAROID:
+		var EventPolaroids models.EventPolaroids
+		Content.Collection = "EventPolaroids"
+		Content.Filter = bson.D{{Key: "_id", Value: contentId}}
+		Content.HiddenField = "event_polaroids_hidden"
+		err := config.DB.Collection(Content.Collection).FindOne(context.TODO(), Content.Filter).Decode(&EventPolaroids)
+		Content.Hidden = EventPolaroids.EventPolaroidsHidden
+		Content.CreatedBy = EventPolaroids.EventPolaroidsCreatedBy
+		Content.Record = EventPolaroids
+		return Content, err
+	case helpers.REPORT_TYPE_REWILDING:
+		var Rewilding models.Rewilding
+		Content.Collection = "Rewilding"
+		Content.Filter = bson.D{
+			{Key: "_id", Value: contentId},
+			{Key: "rewilding_deleted_at", Value: bson.M{"$exists": false}},
+		}
+		Content.HiddenField = "rewilding_hidden"
+		err := config.DB.Collection(Content.Collection).FindOne(context.TODO(), Content.Filter).Decode(&Rewilding)
+		Content.Hidden = Rewilding.RewildingHidden
+		Content.CreatedBy = Rewilding.RewildingCreatedBy
+		Content.Record = Rewilding
+		return Content, err
+	case helpers.REPORT_TYPE_REWILDING_PHOTO:
+		var Rewilding models.Rewilding
+		Content.Collection = "Rewilding"
+		Content.Filter = bson.D{
+			{Key: "rewilding_photos._id", Value: contentId},
+			{Key: "rewilding_deleted_at", Value: bson.M{"$exists": false}},
+		}
+		Content.HiddenField = "rewilding_photos.$.rewilding_photos_hidden"
+		err := config.DB.Collection(Content.Collection).FindOne(context.TODO(), Content.Filter).Decode(&Rewilding)
+		for _, v := range Rewilding.RewildingPhotos {
+			if v.RewildingPhotosID == contentId {
+				Content.Hidden = v.RewildingPhotosHidden
+			}
+		}
+		Content.CreatedBy = Rewilding.RewildingCreatedBy
+		Content.Record = Rewilding
+		return Content, err
+	}
+	return Content, errors.New("unsupported content type " + contentType)
+}
+
+// ReadContent finds the content identified by the :type and :contentId route
+// parameters.
+func (r ModerationRepository) ReadContent(c *gin.Context) (ModerationContent, error) {
+	Content, err := r.Content(c.Param("type"), helpers.StringToPrimitiveObjId(c.Param("contentId")))
+	if err != nil {
+		helpers.ResponseNotFound(c, "Content not found")
+	}
+	return Content, err
+}
+
+// Retrieve is the moderation queue: the reports grouped by content, the most
+// reported first. ?status= picks OPEN (default), RESOLVED or DISMISSED
+// reports and ?type= narrows them to a content type.
+func (r ModerationRepository) Retrieve(c *gin.Context) {
+	status := c.DefaultQuery("status", helpers.REPORT_STATUS_OPEN)
+	filter := bson.D{{Key: "reports_status", Value: status}}
+	if c.Query("type") != "" {
+		filter = append(filter, bson.E{Key: "reports_type", Value: c.Query("type")})
+	}
+
+	var Reports []models.Reports
+	opts := options.Find().SetSort(bson.D{{Key: "reports_created_at", Value: -1}})
+	cursor, err := config.DB.Collection("Reports").Find(context.TODO(), filter, opts)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	cursor.All(context.TODO(), &Reports)
+
+	var Queue []models.ModerationQueue
+	index := map[string]int{}
+	for _, v := range Reports {
+		key := v.ReportsType + ":" + v.ReportsContent.Hex()
+		idx, ok := index[key]
+		if !ok {
+			idx = len(Queue)
+			index[key] = idx
+			Queue = append(Queue, models.ModerationQueue{
+				Type:           v.ReportsType,
+				Content:        v.ReportsContent,
+				ContentUser:    v.ReportsContentUser,
+				Reasons:        map[string]int{},
+				LastReportedAt: v.ReportsCreatedAt,
+			})
+		}
+		Queue[idx].ReportCount++
+		Queue[idx].Reasons[v.ReportsReason]++
+		Queue[idx].Reports = append(Queue[idx].Reports, v)
+	}
+
+	if len(Queue) == 0 {
+		helpers.ResponseNoData(c, "No Data")
+		return
+	}
+	sort.SliceStable(Queue, func(i, j int) bool { return Queue[i].ReportCount > Queue[j].ReportCount })
+	c.JSON(http.StatusOK, Queue)
+}
+
+// RetrieveActions lists the moderation log, newest first, optionally for one
+// ?user=.
+func (r ModerationRepository) RetrieveActions(c *gin.Context) {
+	filter := bson.D{}
+	if c.Query("user") != "" {
+		filter = append(filter, bson.E{Key: "moderation_actions_user", Value: helpers.StringToPrimitiveObjId(c.Query("user"))})
+	}
+
+	var ModerationActions []models.ModerationActions
+	opts := options.Find().SetSort(bson.D{{Key: "moderation_actions_created_at", Value: -1}})
+	cursor, err := config.DB.Collection("ModerationActions").Find(context.TODO(), filter, opts)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+	cursor.All(context.TODO(), &ModerationActions)
+
+	if len(ModerationActions) == 0 {
+		helpers.ResponseNoData(c, "No Data")
+		return
+	}
+	c.JSON(http.StatusOK, ModerationActions)
+}
+
+func (r ModerationRepository) Hide(c *gin.Context) {
+	Content, err := r.ReadContent(c)
+	if err != nil {
+		return
+	}
+	message := r.Message(c)
+	if Content.Hidden {
+		helpers.ResponseBadRequestError(c, "Content is already hidden")
+		return
+	}
+
+	r.SetHidden(c, Content, true)
+	r.Resolve(c, Content, helpers.MODERATION_ACTION_HIDE, helpers.REPORT_STATUS_RESOLVED, message)
+	helpers.ResponseSuccessMessage(c, "Content hidden")
+}
+
+func (r ModerationRepository) Restore(c *gin.Context) {
+	Content, err := r.ReadContent(c)
+	if err != nil {
+		return
+	}
+	message := r.Message(c)
+	if !Content.Hidden {
+		helpers.ResponseBadRequestError(c, "Content is not hidden")
+		return
+	}
+
+	r.SetHidden(c, Content, false)
+	r.Log(c, models.ModerationActions{
+		ModerationActionsAction:  helpers.MODERATION_ACTION_RESTORE,
+		ModerationActionsType:    Content.Type,
+		ModerationActionsContent: Content.Id,
+		ModerationActionsUser:    Content.CreatedBy,
+		ModerationActionsMessage: message,
+	})
+	helpers.ResponseSuccessMessage(c, "Content restored")
+}
+
+func (r ModerationRepository) Delete(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	Content, err := r.ReadContent(c)
+	if err != nil {
+		return
+	}
+	message := r.Message(c)
+
+	switch Content.Type {
+	case helpers.REPORT_TYPE_MESSAGE_BOARD:
+		EventMessageBoardRepository{}.Remove(Content.Record.(models.EventMessageBoard))
+	case helpers.REPORT_TYPE_POLAROID:
+		EventPolaroids := Content.Record.(models.EventPolaroids)
+		var Events models.Events
+		config.DB.Collection("Events").FindOne(context.TODO(), bson.D{{Key: "_id", Value: EventPolaroids.EventPolaroidsEvent}}).Decode(&Events)
+		CollaborativeLogPolaroidRepository{}.Remove(c, Events, EventPolaroids)
+	case helpers.REPORT_TYPE_REWILDING:
+		RewildingRepository{}.Remove(Content.Record.(models.Rewilding), userDetail.UsersId)
+	case helpers.REPORT_TYPE_REWILDING_PHOTO:
+		upd := bson.D{{Key: "$pull", Value: bson.M{"rewilding_photos": bson.M{"_id": Content.Id}}}}
+		config.DB.Collection("Rewilding").UpdateOne(context.TODO(), Content.Filter, upd)
+	}
+
+	r.Resolve(c, Content, helpers.MODERATION_ACTION_DELETE, helpers.REPORT_STATUS_RESOLVED, message)
+	helpers.ResponseSuccessMessage(c, "Content deleted")
+}
+
+// Dismiss closes the open reports of the content without acting on it. A
+// polaroid flagged as a suspicious photo is approved.
+func (r ModerationRepository) Dismiss(c *gin.Context) {
+	Content, err := r.ReadContent(c)
+	if err != nil {
+		return
+	}
+	message := r.Message(c)
+
+	filter := bson.D{
+		{Key: "reports_type", Value: Content.Type},
+		{Key: "reports_content", Value: Content.Id},
+		{Key: "reports_status", Value: helpers.REPORT_STATUS_OPEN},
+	}
+	count, _ := config.DB.Collection("Reports").CountDocuments(context.TODO(), filter)
+	if count == 0 {
+		helpers.ResponseBadRequestError(c, "This content has no open reports")
+		return
+	}
+
+	if Content.Type == helpers.REPORT_TYPE_POLAROID {
+		suspicious := append(filter, bson.E{Key: "reports_reason", Value: helpers.REPORT_REASON_SUSPICIOUS_PHOTO})
+		count, _ := config.DB.Collection("Reports").CountDocuments(context.TODO(), suspicious)
+		if count > 0 {
+			CollaborativeLogPolaroidRepository{}.Approve(c, Content.Record.(models.EventPolaroids))
+		}
+	}
+	r.Resolve(c, Content, helpers.MODERATION_ACTION_DISMISS, helpers.REPORT_STATUS_DISMISSED, message)
+	helpers.ResponseSuccessMessage(c, "Reports dismissed")
+}
+
+// Queue puts content in the moderation queue on behalf of the API. The report
+// has no author.
+func (r ModerationRepository) Queue(contentType string, contentId primitive.ObjectID, contentUser primitive.ObjectID, reason string, message string) {
+	insert := models.Reports{
+		ReportsType:        contentType,
+		ReportsContent:     contentId,
+		ReportsContentUser: contentUser,
+		ReportsReason:      reason,
+		ReportsMessage:     message,
+		ReportsStatus:      helpers.REPORT_STATUS_OPEN,
+		ReportsCreatedAt:   primitive.NewDateTimeFromTime(time.Now()),
+	}
+	_, err := config.DB.Collection("Reports").InsertOne(context.TODO(), insert)
+	if err != nil {
+		fmt.Println("ERROR", err.Error())
+	}
+}
+
+// Message reads the optional moderation_actions_message from the body, which
+// may be empty.
+func (r ModerationRepository) Message(c *gin.Context) string {
+	var payload ModerationRequest
+	c.ShouldBindJSON(&payload)
+	return payload.ModerationActionsMessage
+}
+
+// SetHidden hides or restores the content. Replies are left out of the reply
+// count of their thread while hidden, and polaroids out of the achievements.
+func (r ModerationRepository) SetHidden(c *gin.Context, Content ModerationContent, hidden bool) {
+	upd := bson.D{{Key: "$unset", Value: bson.M{Content.HiddenField: ""}}}
+	if hidden {
+		upd = bson.D{{Key: "$set", Value: bson.M{Content.HiddenField: true}}}
+	}
+	config.DB.Collection(Content.Collection).UpdateOne(context.TODO(), Content.Filter, upd)
+
+	if EventMessageBoard, ok := Content.Record.(models.EventMessageBoard); ok && !EventMessageBoard.EventMessageBoardParent.IsZero() {
+		inc := 1
+		if hidden {
+			inc = -1
+		}
+		EventMessageBoardRepository{}.CountReply(EventMessageBoard.EventMessageBoardParent, inc)
+	}
+
+	if EventPolaroids, ok := Content.Record.(models.EventPolaroids); ok {
+		var Events models.Events
+		config.DB.Collection("Events").FindOne(context.TODO(), bson.D{{Key: "_id", Value: EventPolaroids.EventPolaroidsEvent}}).Decode(&Events)
+		CollaborativeLogPolaroidRepository{}.Reconcile(c, Events, EventPolaroids.EventPolaroidsCreatedBy)
+	}
+}
+
+// Resolve closes the open reports of the content with the action and logs
+// the action.
+func (r ModerationRepository) Resolve(c *gin.Context, Content ModerationContent, action string, status string, message string) {
+	userDetail := helpers.GetAuthUser(c)
+	currentTime := primitive.NewDateTimeFromTime(time.Now())
+	filter := bson.D{
+		{Key: "reports_type", Value: Content.Type},
+		{Key: "reports_content", Value: Content.Id},
+		{Key: "reports_status", Value: helpers.REPORT_STATUS_OPEN},
+	}
+	upd := bson.D{{Key: "$set", Value: bson.M{
+		"reports_status":      status,
+		"reports_resolved_by": userDetail.UsersId,
+		"reports_resolved_at": currentTime,
+		"reports_action":      action,
+	}}}
+	config.DB.Collection("Reports").UpdateMany(context.TODO(), filter, upd)
+
+	r.Log(c, models.ModerationActions{
+		ModerationActionsAction:  action,
+		ModerationActionsType:    Content.Type,
+		ModerationActionsContent: Content.Id,
+		ModerationActionsUser:    Content.CreatedBy,
+		ModerationActionsMessage: message,
+	})
+}
+
+func (r ModerationRepository) Log(c *gin.Context, ModerationActions models.ModerationActions) {
+	userDetail := helpers.GetAuthUser(c)
+	ModerationActions.ModerationActionsCreatedBy = userDetail.UsersId
+	ModerationActions.ModerationActionsCreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	config.DB.Collection("ModerationActions").InsertOne(context.TODO(), ModerationActions)
+}
+
+// ReadUser finds the user identified by the :userId route parameter.
+func (r ModerationRepository) ReadUser(c *gin.Context, Users *models.Users) error {
+	userId := helpers.StringToPrimitiveObjId(c.Param("userId"))
+	err := config.DB.Collection("Users").FindOne(context.TODO(), bson.D{{Key: "_id", Value: userId}}).Decode(Users)
+	if err != nil {
+		helpers.ResultEmpty(c, err)
+	}
+	return err
+}
+
+func (r ModerationRepository) Warn(c *gin.Context) {
+	var payload ModerationWarnRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	var Users models.Users
+	err := r.ReadUser(c, &Users)
+	if err != nil {
+		return
+	}
+
+	r.Log(c, models.ModerationActions{
+		ModerationActionsAction:  helpers.MODERATION_ACTION_WARN,
+		ModerationActionsUser:    Users.UsersId,
+		ModerationActionsMessage: payload.ModerationActionsMessage,
+	})
+	r.Notify(c, helpers.NOTIFICATION_MODERATION_WARNING, Users.UsersId, "你收到了管理員的警告：{0}", map[string]string{
+		"moderation_actions_message": payload.ModerationActionsMessage,
+	})
+	helpers.ResponseSuccessMessage(c, "User warned")
+}
+
+// Suspend keeps the user from posting or changing anything for the number of
+// days. Suspending a suspended user replaces the suspension.
+func (r ModerationRepository) Suspend(c *gin.Context) {
+	var payload ModerationSuspendRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	var Users models.Users
+	err := r.ReadUser(c, &Users)
+	if err != nil {
+		return
+	}
+
+	suspendedUntil := primitive.NewDateTimeFromTime(time.Now().AddDate(0, 0, payload.ModerationActionsDays))
+	upd := bson.D{{Key: "$set", Value: bson.M{"users_suspended_until": suspendedUntil}}}
+	config.DB.Collection("Users").UpdateOne(context.TODO(), bson.D{{Key: "_id", Value: Users.UsersId}}, upd)
+
+	r.Log(c, models.ModerationActions{
+		ModerationActionsAction:         helpers.MODERATION_ACTION_SUSPEND,
+		ModerationActionsUser:           Users.UsersId,
+		ModerationActionsMessage:        payload.ModerationActionsMessage,
+		ModerationActionsSuspendedUntil: suspendedUntil,
+	})
+	r.Notify(c, helpers.NOTIFICATION_MODERATION_SUSPENDED, Users.UsersId, "你的帳號已被停權至{0}", map[string]string{
+		"users_suspended_until":      suspendedUntil.Time().Format(time.RFC3339),
+		"moderation_actions_message": payload.ModerationActionsMessage,
+	})
+	helpers.ResponseSuccessMessage(c, "User suspended for "+strconv.Itoa(payload.ModerationActionsDays)+" days")
+}
+
+// Unsuspend lifts the suspension of the user.
+func (r ModerationRepository) Unsuspend(c *gin.Context) {
+	var Users models.Users
+	err := r.ReadUser(c, &Users)
+	if err != nil {
+		return
+	}
+	if !helpers.UserSuspended(Users) {
+		helpers.ResponseBadRequestError(c, "User is not suspended")
+		return
+	}
+
+	upd := bson.D{{Key: "$unset", Value: bson.M{"users_suspended_until": ""}}}
+	config.DB.Collection("Users").UpdateOne(context.TODO(), bson.D{{Key: "_id", Value: Users.UsersId}}, upd)
+
+	r.Log(c, models.ModerationActions{
+		ModerationActionsAction: helpers.MODERATION_ACTION_UNSUSPEND,
+		ModerationActionsUser:   Users.UsersId,
+	})
+	helpers.ResponseSuccessMessage(c, "User suspension lifted")
+}
+
+// Notify tells the user about the moderation action, in the app and by push
+// notification.
+func (r ModerationRepository) Notify(c *gin.Context, code string, userId primitive.ObjectID, message string, data map[string]string) {
+	userDetail := helpers.GetAuthUser(c)
+	notificationData := map[string]interface{}{}
+	for key, value := range data {
+		notificationData[key] = value
+	}
+	NotificationMessage := models.NotificationMessage{
+		Message: message,
+		Data:    []map[string]interface{}{notificationData},
+	}
+	helpers.NotificationsCreate(c, code, userId, NotificationMessage, userId)
+
+	notifyMsg, err := helper.NewNotifyMsg(code, userDetail.UsersId, userId, data, helpers.FindUserSourceId)
+	if err != nil {
+		fmt.Println("new notify msg err: " + err.Error())
+		return
+	}
+	notifyMsg.WriteToHeader(c, config.APP.NotificationHeaderName)
+}
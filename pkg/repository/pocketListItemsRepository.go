@@ -44,14 +44,7 @@ func (r PocketListItemsRepository) Retrieve(c *gin.Context) {
 				"pocket_list_items_mst": rewildingId,
 			},
 		}},
-		bson.D{{
-			Key: "$lookup", Value: bson.M{
-				"from":         "Rewilding",
-				"localField":   "pocket_list_items_rewilding",
-				"foreignField": "_id",
-				"as":           "pocket_list_items_rewilding_detail",
-			},
-		}},
+		RewildingRepository{}.Lookup("pocket_list_items_rewilding", "pocket_list_items_rewilding_detail"),
 		bson.D{{
 			Key: "$unwind", Value: "$pocket_list_items_rewilding_detail",
 		}},
@@ -79,6 +72,7 @@ func (r PocketListItemsRepository) Retrieve(c *gin.Context) {
 
 func (r PocketListItemsRepository) RetrievePhoto(results *[]models.PocketListItems) {
 	for idx, val := range *results {
+		val.PocketListItemsRewildingDetail.RewildingPhotos = RewildingPhotoRepository{}.Visible(val.PocketListItemsRewildingDetail.RewildingPhotos)
 		if len(val.PocketListItemsRewildingDetail.RewildingPhotos) > 0 {
 			for photoIdx, photo := range val.PocketListItemsRewildingDetail.RewildingPhotos {
 				if photo.RewildingPhotosPath == "" {
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/helpers"
+	"oosa_rewild/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReportRepository struct{}
+type ReportRequest struct {
+	ReportsType    string `json:"reports_type" validate:"required,oneof=MESSAGE_BOARD POLAROID REWILDING_PHOTO REWILDING"`
+	ReportsContent string `json:"reports_content" validate:"required"`
+	ReportsReason  string `json:"reports_reason" validate:"required,oneof=SPAM HARASSMENT HATE_SPEECH VIOLENCE NUDITY MISINFORMATION COPYRIGHT OTHER"`
+	ReportsMessage string `json:"reports_message"`
+}
+
+// Create reports content to the moderators. A user can only have one open
+// report per content.
+func (r ReportRepository) Create(c *gin.Context) {
+	userDetail := helpers.GetAuthUser(c)
+	var payload ReportRequest
+	validateError := helpers.Validate(c, &payload)
+	if validateError != nil {
+		return
+	}
+
+	match, errMessage := helpers.ValidateStringLength(payload.ReportsMessage, int(config.APP_LIMIT.LengthEventMessageBoardMessage))
+	if !match {
+		helpers.ResponseBadRequestError(c, "Report message can only contain "+errMessage)
+		return
+	}
+
+	contentId := helpers.StringToPrimitiveObjId(payload.ReportsContent)
+	Content, err := ModerationRepository{}.Content(payload.ReportsType, contentId)
+	if err != nil || Content.Hidden {
+		helpers.ResponseNotFound(c, "Content not found")
+		return
+	}
+	if Content.CreatedBy == userDetail.UsersId {
+		helpers.ResponseBadRequestError(c, "You cannot report your own content")
+		return
+	}
+
+	filter := bson.D{
+		{Key: "reports_type", Value: payload.ReportsType},
+		{Key: "reports_content", Value: contentId},
+		{Key: "reports_created_by", Value: userDetail.UsersId},
+		{Key: "reports_status", Value: helpers.REPORT_STATUS_OPEN},
+	}
+	count, _ := config.DB.Collection("Reports").CountDocuments(context.TODO(), filter)
+	if count > 0 {
+		helpers.ResponseBadRequestError(c, "You have already reported this content")
+		return
+	}
+
+	insert := models.Reports{
+		ReportsType:        payload.ReportsType,
+		ReportsContent:     contentId,
+		ReportsContentUser: Content.CreatedBy,
+		ReportsReason:      payload.ReportsReason,
+		ReportsMessage:     payload.ReportsMessage,
+		ReportsStatus:      helpers.REPORT_STATUS_OPEN,
+		ReportsCreatedBy:   userDetail.UsersId,
+		ReportsCreatedAt:   primitive.NewDateTimeFromTime(time.Now()),
+	}
+	result, err := config.DB.Collection("Reports").InsertOne(context.TODO(), insert)
+	if err != nil {
+		helpers.ResponseError(c, err.Error())
+		return
+	}
+
+	var Reports models.Reports
+	config.DB.Collection("Reports").FindOne(context.TODO(), bson.D{{Key: "_id", Value: result.InsertedID}}).Decode(&Reports)
+	c.JSON(http.StatusOK, Reports)
+}
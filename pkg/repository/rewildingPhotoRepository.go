@@ -26,14 +26,26 @@ type RewildingPhotoRepository struct{}
 func (r RewildingPhotoRepository) Retrieve(c *gin.Context) {
 	id, _ := primitive.ObjectIDFromHex(c.Param("id"))
 	var Rewilding models.Rewilding
-	err := config.DB.Collection("Rewilding").FindOne(context.TODO(), bson.D{{Key: "_id", Value: id}}).Decode(&Rewilding)
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "rewilding_hidden", Value: bson.M{"$ne": true}}}
+	err := config.DB.Collection("Rewilding").FindOne(context.TODO(), filter).Decode(&Rewilding)
 
 	if err != nil {
 		helpers.ResultEmpty(c, err)
 		return
 	}
 
-	c.JSON(200, Rewilding.RewildingPhotos)
+	c.JSON(200, r.Visible(Rewilding.RewildingPhotos))
+}
+
+// Visible leaves out the photos hidden by a moderator.
+func (r RewildingPhotoRepository) Visible(RewildingPhotos []models.RewildingPhotos) []models.RewildingPhotos {
+	visible := make([]models.RewildingPhotos, 0)
+	for _, v := range RewildingPhotos {
+		if !v.RewildingPhotosHidden {
+			visible = append(visible, v)
+		}
+	}
+	return visible
 }
 
 func (r RewildingPhotoRepository) Read(c *gin.Context) {
@@ -43,7 +55,11 @@ func (r RewildingPhotoRepository) Read(c *gin.Context) {
 
 	filter := bson.D{
 		{Key: "_id", Value: id},
-		{Key: "rewilding_photos._id", Value: photosId},
+		{Key: "rewilding_hidden", Value: bson.M{"$ne": true}},
+		{Key: "rewilding_photos", Value: bson.M{"$elemMatch": bson.M{
+			"_id":                     photosId,
+			"rewilding_photos_hidden": bson.M{"$ne": true},
+		}}},
 	}
 	err := config.DB.Collection("Rewilding").FindOne(context.TODO(), filter).Decode(&Rewilding)
 
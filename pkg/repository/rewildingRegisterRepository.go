@@ -28,7 +28,10 @@ type RewildingRegisterRequest struct {
 func (r RewildingRegisterRepository) Retrieve(c *gin.Context) {
 	userDetail := helpers.GetAuthUser(c)
 	var results []models.Rewilding
-	filter := bson.D{{Key: "rewilding_created_by", Value: userDetail.UsersId}}
+	filter := bson.D{
+		{Key: "rewilding_created_by", Value: userDetail.UsersId},
+		{Key: "rewilding_hidden", Value: bson.M{"$ne": true}},
+	}
 	cursor, err := config.DB.Collection("Rewilding").Find(context.TODO(), filter)
 	if err != nil {
 		panic(err)
@@ -38,6 +41,9 @@ func (r RewildingRegisterRepository) Retrieve(c *gin.Context) {
 		helpers.ResponseNoData(c, "No Data")
 		return
 	}
+	for key, v := range results {
+		results[key].RewildingPhotos = RewildingPhotoRepository{}.Visible(v.RewildingPhotos)
+	}
 	c.JSON(http.StatusOK, results)
 }
 
@@ -48,6 +54,7 @@ func (r RewildingRegisterRepository) Read(c *gin.Context) {
 	filter := bson.D{
 		{Key: "_id", Value: id},
 		{Key: "rewilding_created_by", Value: userDetail.UsersId},
+		{Key: "rewilding_hidden", Value: bson.M{"$ne": true}},
 	}
 	err := config.DB.Collection("Rewilding").FindOne(context.TODO(), filter).Decode(&Rewilding)
 
@@ -56,6 +63,7 @@ func (r RewildingRegisterRepository) Read(c *gin.Context) {
 		return
 	}
 
+	Rewilding.RewildingPhotos = RewildingPhotoRepository{}.Visible(Rewilding.RewildingPhotos)
 	c.JSON(200, Rewilding)
 }
 
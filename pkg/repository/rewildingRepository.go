@@ -84,12 +84,14 @@ func (r RewildingRepository) Retrieve(c *gin.Context) {
 			Key: "$match", Value: bson.D{
 				{Key: "rewilding_created_by", Value: userDetail.UsersId},
 				{Key: "rewilding_deleted_at", Value: bson.M{"$exists": false}},
+				{Key: "rewilding_hidden", Value: bson.M{"$ne": true}},
 			},
 		}})
 	} else {
 		agg = append(agg, bson.D{{
 			Key: "$match", Value: bson.D{
 				{Key: "rewilding_deleted_at", Value: bson.M{"$exists": false}},
+				{Key: "rewilding_hidden", Value: bson.M{"$ne": true}},
 			},
 		}})
 	}
@@ -105,29 +107,44 @@ func (r RewildingRepository) Retrieve(c *gin.Context) {
 	}
 
 	for key, v := range results {
-		if v.RewildingPhotos == nil {
-			results[key].RewildingPhotos = make([]models.RewildingPhotos, 0)
-		}
+		results[key].RewildingPhotos = RewildingPhotoRepository{}.Visible(v.RewildingPhotos)
 	}
 
 	c.JSON(http.StatusOK, results)
 }
 
+// Lookup joins the rewilding referenced by localField into as, leaving out
+// rewildings hidden by a moderator.
+func (r RewildingRepository) Lookup(localField string, as string) bson.D {
+	return bson.D{{
+		Key: "$lookup", Value: bson.M{
+			"from": "Rewilding",
+			"let":  bson.M{"rewildingId": "$" + localField},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"$expr":            bson.M{"$eq": bson.A{"$_id", "$$rewildingId"}},
+					"rewilding_hidden": bson.M{"$ne": true},
+				}},
+			},
+			"as": as,
+		},
+	}}
+}
+
 func (r RewildingRepository) Read(c *gin.Context) {
 	userDetail := helpers.GetAuthUserByCheckHeaders(c)
 
 	id, _ := primitive.ObjectIDFromHex(c.Param("id"))
 	var Rewilding models.Rewilding
-	err := config.DB.Collection("Rewilding").FindOne(context.TODO(), bson.D{{Key: "_id", Value: id}}).Decode(&Rewilding)
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "rewilding_hidden", Value: bson.M{"$ne": true}}}
+	err := config.DB.Collection("Rewilding").FindOne(context.TODO(), filter).Decode(&Rewilding)
 
 	if err != nil {
 		helpers.ResultEmpty(c, err)
 		return
 	}
 
-	if Rewilding.RewildingPhotos == nil {
-		Rewilding.RewildingPhotos = make([]models.RewildingPhotos, 0)
-	}
+	Rewilding.RewildingPhotos = RewildingPhotoRepository{}.Visible(Rewilding.RewildingPhotos)
 
 	isBookmarked := false
 
@@ -363,19 +380,24 @@ func (r RewildingRepository) Delete(c *gin.Context) {
 		return
 	}
 
+	r.Remove(Rewilding, userDetail.UsersId)
+	helpers.ResponseSuccessMessage(c, "Rewilding deleted")
+}
+
+// Remove marks the rewilding as deleted by the user and takes it out of the
+// pocket lists.
+func (r RewildingRepository) Remove(Rewilding models.Rewilding, userId primitive.ObjectID) {
 	filterRewilding := bson.D{
 		{Key: "pocket_list_items_rewilding", Value: Rewilding.RewildingID},
 	}
 	config.DB.Collection("PocketListItems").DeleteMany(context.TODO(), filterRewilding)
 
-	updFilter := bson.D{{Key: "_id", Value: id}}
+	updFilter := bson.D{{Key: "_id", Value: Rewilding.RewildingID}}
 	currentTime := primitive.NewDateTimeFromTime(time.Now())
 	Rewilding.RewildingDeletedAt = &currentTime
-	Rewilding.RewildingDeletedBy = &userDetail.UsersId
+	Rewilding.RewildingDeletedBy = &userId
 	upd := bson.D{{Key: "$set", Value: Rewilding}}
 	config.DB.Collection("Rewilding").UpdateOne(context.TODO(), updFilter, upd)
-
-	helpers.ResponseSuccessMessage(c, "Rewilding deleted")
 }
 
 func (r RewildingRepository) Options(c *gin.Context) {
@@ -188,13 +188,15 @@ func (r RewildingSearchRepository) Create(c *gin.Context) {
 func (r RewildingSearchRepository) Read(c *gin.Context) {
 	id, _ := primitive.ObjectIDFromHex(c.Param("id"))
 	var Rewilding models.Rewilding
-	err := config.DB.Collection("Rewilding").FindOne(context.TODO(), bson.D{{Key: "_id", Value: id}}).Decode(&Rewilding)
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "rewilding_hidden", Value: bson.M{"$ne": true}}}
+	err := config.DB.Collection("Rewilding").FindOne(context.TODO(), filter).Decode(&Rewilding)
 
 	if err != nil {
 		helpers.ResultEmpty(c, err)
 		return
 	}
 
+	Rewilding.RewildingPhotos = RewildingPhotoRepository{}.Visible(Rewilding.RewildingPhotos)
 	c.JSON(200, Rewilding)
 }
 
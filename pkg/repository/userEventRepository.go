@@ -128,16 +128,10 @@ func (r UserEventRepository) GetEventByUserId(c *gin.Context, userId primitive.O
 		bson.D{{
 			Key: "$unwind", Value: "$events_created_by_user",
 		}},
+		RewildingRepository{}.Lookup("events_rewilding", "events_rewilding_detail"),
+		// Events keep showing when their rewilding is hidden
 		bson.D{{
-			Key: "$lookup", Value: bson.M{
-				"from":         "Rewilding",
-				"localField":   "events_rewilding",
-				"foreignField": "_id",
-				"as":           "events_rewilding_detail",
-			},
-		}},
-		bson.D{{
-			Key: "$unwind", Value: "$events_rewilding_detail",
+			Key: "$unwind", Value: bson.M{"path": "$events_rewilding_detail", "preserveNullAndEmptyArrays": true},
 		}},
 		bson.D{{
 			Key: "$facet", Value: bson.D{
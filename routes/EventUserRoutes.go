@@ -9,11 +9,15 @@ import (
 
 func EventUserRoutes(r gin.IRouter) gin.IRouter {
 	repo := repository.UserEventRepository{}
+	repoCalendar := repository.CalendarRepository{}
 
 	main := r.Group("/my/event", middleware.AuthMiddleware())
 	{
 		main.GET("", repo.Retrieve)
+		main.GET("/calendar", repoCalendar.Read)
+		main.PUT("/calendar", repoCalendar.Update)
 	}
+	r.GET("/my/event/calendar.ics", repoCalendar.Feed)
 
 	return r
 }
@@ -11,6 +11,8 @@ func CollaborativeLogRoutes(r gin.IRouter) gin.IRouter {
 	repo := repository.CollaborativeLogRepository{}
 	repoAlbumLink := repository.CollaborativeLogAlbumLinkRepository{}
 	repoPolaroid := repository.CollaborativeLogPolaroidRepository{}
+	repoPolaroidLike := repository.CollaborativeLogPolaroidLikeRepository{}
+	repoPolaroidComment := repository.CollaborativeLogPolaroidCommentRepository{}
 	repoQuestionnaire := repository.CollaborativeLogQuestionnaireRepository{}
 	repoExperience := repository.CollaborativeLogExperienceRepository{}
 	randomCountRepo := repository.CollaborativeLogRandomCountRepository{}
@@ -20,7 +22,7 @@ func CollaborativeLogRoutes(r gin.IRouter) gin.IRouter {
 		main.GET("", repo.Retrieve)
 	}
 
-	detail := main.Group("/:id", middleware.AuthMiddleware())
+	detail := main.Group("/:id", middleware.AuthMiddleware(), middleware.EventVisibilityMiddleware())
 	{
 	}
 
@@ -37,9 +39,13 @@ func CollaborativeLogRoutes(r gin.IRouter) gin.IRouter {
 	{
 		polaroid.GET("", repoPolaroid.Retrieve)
 		polaroid.POST("", repoPolaroid.Create)
-		// albumLink.GET("/:messageBoardId", repoAlbumLink.Read)
-		// albumLink.PUT("/:messageBoardId", repoAlbumLink.Update)
-		// albumLink.DELETE("/:messageBoardId", repoAlbumLink.Delete)
+		polaroid.PUT("/:polaroidId", repoPolaroid.Update)
+		polaroid.DELETE("/:polaroidId", repoPolaroid.Delete)
+		polaroid.POST("/:polaroidId/like", repoPolaroidLike.Create)
+		polaroid.DELETE("/:polaroidId/like", repoPolaroidLike.Delete)
+		polaroid.GET("/:polaroidId/comments", repoPolaroidComment.Retrieve)
+		polaroid.POST("/:polaroidId/comments", repoPolaroidComment.Create)
+		polaroid.DELETE("/:polaroidId/comments/:commentId", repoPolaroidComment.Delete)
 	}
 
 	questionnaire := detail.Group("/questionnaire", middleware.AuthMiddleware())
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"oosa_rewild/internal/helpers"
 	"oosa_rewild/internal/middleware"
 	"oosa_rewild/pkg/repository"
 
@@ -18,7 +19,7 @@ func EventInvitationRoutes(r gin.IRouter) gin.IRouter {
 	detail := main.Group("/:id", middleware.AuthMiddleware())
 	{
 		detail.GET("", repo.Read)
-		detail.PUT("", repo.Update)
+		detail.PUT("", middleware.EventApplicationRoleMiddleware(helpers.EVENT_ROLE_OWNER, helpers.EVENT_ROLE_COHOST), repo.Update)
 	}
 
 	return r
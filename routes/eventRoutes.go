@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"oosa_rewild/internal/helpers"
 	"oosa_rewild/internal/middleware"
 	"oosa_rewild/pkg/repository"
 
@@ -17,22 +18,49 @@ func EventRoutes(r gin.IRouter) gin.IRouter {
 	repoAccounting := repository.EventAccountingRepository{}
 	repoParticipants := repository.EventParticipantsRepository{}
 	repoInvitation := repository.EventInvitationMessageRepository{}
+	repoWaitlist := repository.EventWaitlistRepository{}
+	repoSeries := repository.EventSeriesRepository{}
+	repoCalendar := repository.CalendarRepository{}
+	repoCancellation := repository.EventCancellationRepository{}
+	repoSearch := repository.EventSearchRepository{}
+	repoInvitationLink := repository.EventInvitationLinkRepository{}
+	repoHistory := repository.EventHistoryRepository{}
+	repoRoute := repository.EventRouteRepository{}
+	repoPayment := repository.EventPaymentRepository{}
+	repoSettlement := repository.EventSettlementRepository{}
+	repoAccountingReceipt := repository.EventAccountingReceiptRepository{}
+	repoAccountingExport := repository.EventAccountingExportRepository{}
+	repoBudget := repository.EventBudgetRepository{}
+	repoStream := repository.EventStreamRepository{}
+	repoAnnouncementRead := repository.EventAnnouncementReadRepository{}
+
+	owner := middleware.EventRoleMiddleware(helpers.EVENT_ROLE_OWNER)
+	organizer := middleware.EventRoleMiddleware(helpers.EVENT_ROLE_OWNER, helpers.EVENT_ROLE_COHOST)
+	member := middleware.EventRoleMiddleware(helpers.EVENT_ROLE_OWNER, helpers.EVENT_ROLE_COHOST, helpers.EVENT_ROLE_MEMBER)
 
 	main := r.Group("/event")
 	{
 		main.GET("", repo.Retrieve)
 		main.POST("", middleware.AuthMiddleware(), repo.Create)
+		main.GET("/facets", repoSearch.Facets)
+		main.GET("/join/:token", repoInvitationLink.Read)
+		main.POST("/join/:token", middleware.AuthMiddleware(), repoInvitationLink.Join)
 		// main.GET("/references", repo.Options)
 	}
 
 	detail := main.Group("/:id")
 	{
 		detail.GET("", repo.Read)
-		detail.PUT("", middleware.AuthMiddleware(), repo.Update)
-		detail.DELETE("", middleware.AuthMiddleware(), repo.Delete)
+		detail.PUT("", middleware.AuthMiddleware(), organizer, repo.Update)
+		detail.DELETE("", middleware.AuthMiddleware(), owner, repo.Delete)
+		detail.GET("/calendar.ics", repoCalendar.EventFeed)
+		detail.GET("/history", middleware.AuthMiddleware(), middleware.EventVisibilityMiddleware(), repoHistory.Retrieve)
+		detail.GET("/stream", middleware.AuthMiddleware(), middleware.EventVisibilityMiddleware(), repoStream.Stream)
+		detail.POST("/cancel", middleware.AuthMiddleware(), owner, repoCancellation.Create)
+		detail.DELETE("/cancel", middleware.AuthMiddleware(), owner, repoCancellation.Delete)
 	}
 
-	messageBoard := detail.Group("/message-board", middleware.AuthMiddleware())
+	messageBoard := detail.Group("/message-board", middleware.AuthMiddleware(), middleware.EventVisibilityMiddleware())
 	{
 		messageBoard.GET("", repoMessageBoard.Retrieve)
 		messageBoard.POST("", repoMessageBoard.Create)
@@ -40,15 +68,21 @@ func EventRoutes(r gin.IRouter) gin.IRouter {
 		messageBoard.PUT("/:messageBoardId", repoMessageBoard.Update)
 		messageBoard.DELETE("/:messageBoardId", repoMessageBoard.Delete)
 		messageBoard.POST("/:messageBoardId/pin", repoMessageBoard.Pin)
+		messageBoard.GET("/:messageBoardId/replies", repoMessageBoard.Replies)
+		messageBoard.POST("/:messageBoardId/reaction", repoMessageBoard.CreateReaction)
+		messageBoard.DELETE("/:messageBoardId/reaction/:emoji", repoMessageBoard.DeleteReaction)
 	}
 
 	announcement := detail.Group("/announcement", middleware.AuthMiddleware())
 	{
 		announcement.GET("", repoAnnouncement.Retrieve)
-		announcement.POST("", repoAnnouncement.Create)
+		announcement.POST("", organizer, repoAnnouncement.Create)
+		announcement.POST("/read", repoAnnouncementRead.Create)
+		announcement.GET("/receipts", organizer, repoAnnouncementRead.Retrieve)
+		announcement.POST("/remind", organizer, repoAnnouncementRead.Remind)
 		announcement.GET("/:messageBoardId", repoAnnouncement.Read)
-		announcement.PUT("/:messageBoardId", repoAnnouncement.Update)
-		announcement.DELETE("/:messageBoardId", repoAnnouncement.Delete)
+		announcement.PUT("/:messageBoardId", organizer, repoAnnouncement.Update)
+		announcement.DELETE("/:messageBoardId", organizer, repoAnnouncement.Delete)
 	}
 
 	referenceLinks := detail.Group("/reference-links", middleware.AuthMiddleware())
@@ -63,36 +97,84 @@ func EventRoutes(r gin.IRouter) gin.IRouter {
 	schedule := detail.Group("/schedule", middleware.AuthMiddleware())
 	{
 		schedule.GET("", repoSchedule.Retrieve)
-		schedule.POST("", repoSchedule.Create)
-		schedule.DELETE("", repoSchedule.DeleteAll)
+		schedule.POST("", organizer, repoSchedule.Create)
+		schedule.DELETE("", organizer, repoSchedule.DeleteAll)
 		schedule.GET("/:scheduleId", repoSchedule.Read)
-		schedule.PUT("/:scheduleId", repoSchedule.Update)
-		schedule.DELETE("/:scheduleId", repoSchedule.Delete)
+		schedule.PUT("/:scheduleId", organizer, repoSchedule.Update)
+		schedule.DELETE("/:scheduleId", organizer, repoSchedule.Delete)
 	}
 
 	accounting := detail.Group("/accounting", middleware.AuthMiddleware())
 	{
 		accounting.GET("", repoAccounting.Retrieve)
-		accounting.POST("", repoAccounting.Create)
+		accounting.POST("", organizer, repoAccounting.Create)
+		accounting.GET("/settlement", member, repoSettlement.Read)
+		accounting.POST("/settlement", member, repoSettlement.Create)
+		accounting.DELETE("/settlement/:settlementId", member, repoSettlement.Delete)
+		accounting.GET("/export", member, repoAccountingExport.Retrieve)
 		accounting.GET("/:accountingId", repoAccounting.Read)
-		accounting.PUT("/:accountingId", repoAccounting.Update)
-		accounting.DELETE("/:accountingId", repoAccounting.Delete)
+		accounting.PUT("/:accountingId", organizer, repoAccounting.Update)
+		accounting.DELETE("/:accountingId", organizer, repoAccounting.Delete)
+		accounting.POST("/:accountingId/receipt", member, repoAccountingReceipt.Create)
+		accounting.DELETE("/:accountingId/receipt/:receiptId", member, repoAccountingReceipt.Delete)
 	}
 
 	participants := detail.Group("/participants", middleware.AuthMiddleware())
 	{
 		participants.GET("", repoParticipants.Retrieve)
-		participants.POST("", repoParticipants.Create)
+		participants.POST("", organizer, repoParticipants.Create)
 		// participants.PUT("/:accountingId", repoParticipants.Update)
 		participants.DELETE("/:participantId", repoParticipants.Delete)
+		participants.PUT("/:participantId/role", owner, repoParticipants.UpdateRole)
 	}
 	main.GET("/participants/:participantId", repoParticipants.Read)
 
+	waitlist := detail.Group("/waitlist", middleware.AuthMiddleware())
+	{
+		waitlist.GET("", organizer, repoWaitlist.Retrieve)
+		waitlist.PUT("", organizer, repoWaitlist.Update)
+	}
+
+	payment := detail.Group("/payment", middleware.AuthMiddleware())
+	{
+		payment.GET("", repoPayment.Read)
+		payment.POST("", repoPayment.Create)
+	}
+
+	route := detail.Group("/route")
+	{
+		route.GET("", middleware.AuthMiddleware(), middleware.EventVisibilityMiddleware(), repoRoute.Read)
+		route.PUT("", middleware.AuthMiddleware(), organizer, repoRoute.Update)
+		route.DELETE("", middleware.AuthMiddleware(), organizer, repoRoute.Delete)
+	}
+
+	budget := detail.Group("/budget", middleware.AuthMiddleware())
+	{
+		budget.GET("", middleware.EventVisibilityMiddleware(), repoBudget.Read)
+		budget.PUT("", organizer, repoBudget.Update)
+		budget.DELETE("", organizer, repoBudget.Delete)
+		budget.POST("/deposit", organizer, repoBudget.CreateDeposit)
+		budget.DELETE("/deposit/:depositId", organizer, repoBudget.DeleteDeposit)
+	}
+
+	series := detail.Group("/series", middleware.AuthMiddleware())
+	{
+		series.GET("", repoSeries.Read)
+		series.POST("", organizer, repoSeries.Create)
+	}
+
 	invitation := detail.Group("/invitation", middleware.AuthMiddleware())
 	{
 		invitation.PUT("", repoInvitation.Update)
 	}
 
+	invitationLinks := detail.Group("/invitation-links", middleware.AuthMiddleware(), organizer)
+	{
+		invitationLinks.GET("", repoInvitationLink.Retrieve)
+		invitationLinks.POST("", repoInvitationLink.Create)
+		invitationLinks.DELETE("/:linkId", repoInvitationLink.Delete)
+	}
+
 	join := detail.Group("/join", middleware.AuthMiddleware())
 	{
 		join.POST("", repoInvitation.Join)
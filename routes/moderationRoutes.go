@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"oosa_rewild/internal/middleware"
+	"oosa_rewild/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ModerationRoutes(r gin.IRouter) gin.IRouter {
+	repoReport := repository.ReportRepository{}
+	repoModeration := repository.ModerationRepository{}
+
+	r.POST("/report", middleware.AuthMiddleware(), repoReport.Create)
+
+	moderation := r.Group("/moderation", middleware.AuthMiddleware(), middleware.AuthAdminUserMiddleware())
+	{
+		moderation.GET("/reports", repoModeration.Retrieve)
+		moderation.GET("/actions", repoModeration.RetrieveActions)
+
+		content := moderation.Group("/content/:type/:contentId")
+		{
+			content.POST("/hide", repoModeration.Hide)
+			content.POST("/restore", repoModeration.Restore)
+			content.POST("/dismiss", repoModeration.Dismiss)
+			content.DELETE("", repoModeration.Delete)
+		}
+
+		user := moderation.Group("/user/:userId")
+		{
+			user.POST("/warn", repoModeration.Warn)
+			user.POST("/suspend", repoModeration.Suspend)
+			user.DELETE("/suspend", repoModeration.Unsuspend)
+		}
+	}
+
+	return r
+}
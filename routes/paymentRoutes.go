@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"oosa_rewild/internal/config"
+	"oosa_rewild/internal/middleware"
+	"oosa_rewild/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PaymentRoutes(r gin.IRouter) gin.IRouter {
+	repo := repository.EventPaymentRepository{}
+
+	main := r.Group("/payment")
+	{
+		main.POST("/webhook", repo.Webhook)
+		// Completes payments without a gateway, for development and testing only
+		if config.APP.PaymentFakeCheckout {
+			main.POST("/fake/:intentId", middleware.AuthMiddleware(), repo.Fake)
+		}
+	}
+
+	return r
+}
@@ -28,9 +28,11 @@ func RegisterRoutes() *gin.Engine {
 	TestRoutes(checkSsoUserGroup)
 	UserRoutes(checkSsoUserGroup)
 	NewsRoutes(checkSsoUserGroup)
+	ModerationRoutes(checkSsoUserGroup)
 	StaticRoutes(checkSsoUserGroup)
 
 	healthRoutes(r)
+	PaymentRoutes(r)
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	return r